@@ -0,0 +1,149 @@
+package main
+
+import (
+	"dara"
+	"dara/sched"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// A Cluster is the description of a cluster read from the -cluster
+// file.
+type Cluster struct {
+	// Procs are the processes of the cluster, in DARAPID order.
+	Procs []Proc
+	// SharedMem is the file backing shared memory, a temporary file in
+	// /dev/shm if empty.
+	SharedMem string
+	// MaxEvents bounds the number of scheduling decisions of a run.
+	MaxEvents int
+	// LogLevel is passed to the runtimes as DARA_LOG_LEVEL.
+	LogLevel string
+	// Partitions are installed and healed at random when the cluster
+	// is explored.
+	Partitions []Partition
+}
+
+// A Partition splits the cluster in two.
+type Partition struct {
+	// Side lists the DARAPIDs on one side of the partition.
+	Side []int
+	// Action is what happens to the data sent across it: drop, delay,
+	// duplicate or reset. Drop if empty.
+	Action string
+}
+
+// A Proc describes one process of a cluster.
+type Proc struct {
+	Path string
+	Args []string
+	Env  []string
+	Dir  string
+	// Faults are injected into the syscalls of the process when the
+	// cluster is recorded or explored.
+	Faults []dara.Fault
+}
+
+// readCluster reads the cluster description in file. Relative paths are
+// taken relative to the directory of file.
+func readCluster(file string) (*Cluster, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c := new(Cluster)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if len(c.Procs) == 0 {
+		return nil, fmt.Errorf("%s: no processes", file)
+	}
+	for i, pt := range c.Partitions {
+		if pt.Action == "" {
+			continue
+		}
+		if _, ok := dara.ParseNetAction(pt.Action); !ok {
+			return nil, fmt.Errorf("%s: partition %d has unknown action %q", file, i+1, pt.Action)
+		}
+	}
+	base, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+	for i := range c.Procs {
+		p := &c.Procs[i]
+		if p.Path == "" {
+			return nil, fmt.Errorf("%s: process %d has no path", file, i+1)
+		}
+		p.Path = resolve(base, p.Path)
+		if p.Dir != "" {
+			p.Dir = resolve(base, p.Dir)
+		}
+	}
+	return c, nil
+}
+
+func resolve(base, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
+
+// config returns the scheduler configuration for one run of the
+// cluster. The output of process N goes to N.stdout and N.stderr in
+// outDir, the caller closes the returned files once the run is over.
+func (c *Cluster) config(mode sched.Mode, outDir string) (sched.Config, []*os.File, error) {
+	cfg := sched.Config{
+		SharedMemPath: c.SharedMem,
+		Mode:          mode,
+		MaxEvents:     c.MaxEvents,
+		LogLevel:      c.LogLevel,
+	}
+	for _, pt := range c.Partitions {
+		action, _ := dara.ParseNetAction(pt.Action)
+		cfg.Partitions = append(cfg.Partitions, sched.Partition{Side: pt.Side, Action: action})
+	}
+	if err := os.MkdirAll(outDir, 0777); err != nil {
+		return cfg, nil, err
+	}
+	var files []*os.File
+	for i, p := range c.Procs {
+		stdout, err := os.Create(filepath.Join(outDir, fmt.Sprintf("%d.stdout", i+1)))
+		if err != nil {
+			closeAll(files)
+			return cfg, nil, err
+		}
+		files = append(files, stdout)
+		stderr, err := os.Create(filepath.Join(outDir, fmt.Sprintf("%d.stderr", i+1)))
+		if err != nil {
+			closeAll(files)
+			return cfg, nil, err
+		}
+		files = append(files, stderr)
+		if len(p.Faults) > 0 {
+			if cfg.Faults == nil {
+				cfg.Faults = make(map[int][]dara.Fault)
+			}
+			cfg.Faults[i+1] = p.Faults
+		}
+		cfg.Procs = append(cfg.Procs, sched.ProcConfig{
+			Path:   p.Path,
+			Args:   p.Args,
+			Env:    p.Env,
+			Dir:    p.Dir,
+			Stdout: stdout,
+			Stderr: stderr,
+		})
+	}
+	return cfg, files, nil
+}
+
+func closeAll(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"dara"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCluster(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dara-cluster-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cluster.json")
+	desc := `{"Procs": [{"Path": "server", "Args": ["-v"]}, {"Path": "/bin/client", "Dir": "work", "Faults": [{"Syscall": 1, "Call": 2, "Errno": 28}]}], "MaxEvents": 7, "Partitions": [{"Side": [1], "Action": "delay"}]}`
+	if err := ioutil.WriteFile(file, []byte(desc), 0666); err != nil {
+		t.Fatal(err)
+	}
+	c, err := readCluster(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Procs) != 2 || c.MaxEvents != 7 {
+		t.Fatalf("readCluster = %+v", c)
+	}
+	if want := filepath.Join(dir, "server"); c.Procs[0].Path != want {
+		t.Errorf("relative path resolved to %q, want %q", c.Procs[0].Path, want)
+	}
+	if c.Procs[1].Path != "/bin/client" {
+		t.Errorf("absolute path resolved to %q", c.Procs[1].Path)
+	}
+	if want := filepath.Join(dir, "work"); c.Procs[1].Dir != want {
+		t.Errorf("dir resolved to %q, want %q", c.Procs[1].Dir, want)
+	}
+	if f := c.Procs[1].Faults; len(f) != 1 || f[0] != (dara.Fault{Syscall: dara.DSYS_WRITE, Call: 2, Errno: 28}) {
+		t.Errorf("faults read as %+v", f)
+	}
+	if pt := c.Partitions; len(pt) != 1 || len(pt[0].Side) != 1 || pt[0].Side[0] != 1 || pt[0].Action != "delay" {
+		t.Errorf("partitions read as %+v", pt)
+	}
+
+	if err := ioutil.WriteFile(file, []byte(`{"Procs": [{"Path": "server"}], "Partitions": [{"Side": [1], "Action": "lose"}]}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCluster(file); err == nil {
+		t.Error("readCluster accepted a partition with an unknown action")
+	}
+
+	if err := ioutil.WriteFile(file, []byte(`{"Procs": []}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCluster(file); err == nil {
+		t.Error("readCluster accepted a cluster without processes")
+	}
+}
+
+func TestFailures(t *testing.T) {
+	s := &dara.Schedule{
+		LogEvents: []dara.Event{
+			{Type: dara.INIT_EVENT, P: 1},
+			{Type: dara.SCHED_EVENT, P: 1},
+			{Type: dara.END_EVENT, P: 1},
+		},
+	}
+	if errs := failures(s); errs != nil {
+		t.Fatalf("clean schedule has failures %v", errs)
+	}
+	s.LogEvents = append(s.LogEvents, dara.Event{Type: dara.CRASH_EVENT, P: 2})
+	s.PropEvents = []dara.PropCheckEvent{
+		dara.CreatePropCheckEvent([]dara.FailedPropertyEvent{{Name: "leader"}}, 1),
+	}
+	if errs := failures(s); len(errs) != 2 {
+		t.Fatalf("failures = %v, want a crash and a property failure", errs)
+	}
+}
@@ -0,0 +1,446 @@
+// Dara runs a cluster of processes built with the Dara runtime under the
+// control of the global scheduler.
+//
+// Usage:
+//
+//	go tool dara [-cluster file] [-out dir] record [-o trace] [-seed s] [-syscalls classes] [-hold] [-vnet]
+//	go tool dara [-cluster file] [-out dir] replay trace
+//	go tool dara [-cluster file] [-out dir] explore [-iterations n] [-seed s] [-syscalls classes] [-hold] [-vnet]
+//	go tool dara inspect trace
+//
+// Record runs the cluster, letting each runtime schedule its own
+// goroutines, and writes the schedule it observed to the -o trace
+// file, schedule.trace by default. The processes are seeded with
+// DARA_SEED values derived from -seed, 0 by default, which decide map
+// iteration order, select and math/rand.
+//
+// Replay runs the cluster again, forcing the goroutine choices and the
+// DARA_SEED of every process of a recorded trace onto it. It warns if a
+// binary differs from the one the trace was recorded from.
+//
+// Explore runs the cluster -iterations times, choosing runnable
+// goroutines and timers at random. Iteration i uses seed s+i, both for
+// those choices and for the DARA_SEED of the processes. The trace of
+// every iteration that fails is written to the -out directory.
+//
+// The -syscalls flag of record and explore makes syscalls scheduling
+// points, at which the scheduler may switch to another goroutine or
+// process before the syscall is made. It takes a comma separated list
+// of the classes file-write, fsync, net-write and mutex-lock. A replay
+// uses the classes of the recording.
+//
+// The -hold flag of record and explore holds whatever a process writes
+// to a connection to another process of the cluster until the
+// scheduler delivers it. Record delivers the messages as soon as it
+// can, explore chooses when to deliver them like it chooses goroutines,
+// which reorders the messages of different connections. A replay
+// delivers them the way the recording did.
+//
+// The -vnet flag of record and explore carries the TCP connections of
+// the processes over a virtual network in shared memory rather than
+// the network of the machine. Every process of the cluster is on the
+// same virtual host, the ports processes choose themselves come out
+// the same in every run, and explore decides when a process sees what
+// the others sent it. A replay uses the virtual network if the
+// recording did.
+//
+// Inspect prints the events of a trace.
+//
+// Traces are written in the binary format of package dara/trace, or in
+// its JSON form if the file name ends in .json. Both are read.
+//
+// The cluster description is a JSON file, cluster.json by default:
+//
+//	{
+//		"Procs": [
+//			{"Path": "./server", "Args": ["-port", "9000"]},
+//			{"Path": "./client", "Env": ["SERVER=:9000"]}
+//		],
+//		"MaxEvents": 10000,
+//		"LogLevel": "2"
+//	}
+//
+// A process can be given Faults to inject into its syscalls when the
+// cluster is recorded or explored, such as
+//
+//	"Faults": [{"Syscall": 1, "Call": 2, "Errno": 28}]
+//
+// which fails the second write of the process with ENOSPC. Syscall is
+// one of the DSYS numbers of package dara, and a fault with no Errno
+// cuts a read or write down to Short bytes. A replay injects the faults
+// of the recording.
+//
+// Partitions list ways of splitting the cluster in two, which explore
+// installs and heals at random while it runs the cluster, such as
+//
+//	"Partitions": [{"Side": [1], "Action": "drop"}]
+//
+// which cuts process 1 off from the others. Action is what happens to
+// the data sent across the partition: drop, delay, duplicate or reset,
+// drop by default. A replay installs and heals the partitions of the
+// trace.
+//
+// Process N, counting from 1, runs with DARAPID=N. Relative paths are
+// relative to the directory of the cluster file. The standard output and
+// standard error of process N are written to N.stdout and N.stderr in
+// the -out directory, dara-out by default.
+//
+// Dara exits with status 1 if any process crashed or the trace
+// contains a failed property.
+package main
+
+import (
+	"dara"
+	"dara/sched"
+	"dara/trace"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	clusterFile = flag.String("cluster", "cluster.json", "cluster description `file`")
+	outDir      = flag.String("out", "dara-out", "write process output and failing schedules to `dir`")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: go tool dara [-cluster file] [-out dir] record [-o trace] [-seed s] [-syscalls classes] [-hold] [-vnet]\n")
+	fmt.Fprintf(os.Stderr, "       go tool dara [-cluster file] [-out dir] replay trace\n")
+	fmt.Fprintf(os.Stderr, "       go tool dara [-cluster file] [-out dir] explore [-iterations n] [-seed s] [-syscalls classes] [-hold] [-vnet]\n")
+	fmt.Fprintf(os.Stderr, "       go tool dara inspect trace\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dara: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+	}
+	args := flag.Args()[1:]
+	var failed bool
+	switch flag.Arg(0) {
+	case "record":
+		failed = record(args)
+	case "replay":
+		failed = replay(args)
+	case "explore":
+		failed = explore(args)
+	case "inspect":
+		failed = inspect(args)
+	default:
+		log.Printf("unknown command %q", flag.Arg(0))
+		usage()
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// subFlags returns a flag set for the subcommand name.
+func subFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = usage
+	return fs
+}
+
+func record(args []string) bool {
+	fs := subFlags("record")
+	out := fs.String("o", "schedule.trace", "write the trace to `file`")
+	seed := fs.Int64("seed", 0, "derive the DARA_SEED of the processes from `s`")
+	points := syscallFlag(fs)
+	hold := holdFlag(fs)
+	vnet := vnetFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		usage()
+	}
+	c := loadCluster()
+	res, err := run(c, sched.Record, nil, *outDir, *seed, parseSyscallPoints(*points), *hold, *vnet)
+	if werr := writeTrace(*out, c, &res.Schedule); werr != nil {
+		log.Fatal(werr)
+	}
+	return report(res, err)
+}
+
+func replay(args []string) bool {
+	fs := subFlags("replay")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	h, s, err := readTrace(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := loadCluster()
+	checkBuildIDs(c, h)
+	return report(run(c, sched.Replay, s, *outDir, 0, 0, false, false))
+}
+
+func explore(args []string) bool {
+	fs := subFlags("explore")
+	iterations := fs.Int("iterations", 1, "number of runs")
+	seed := fs.Int64("seed", 1, "seed of the first run")
+	points := syscallFlag(fs)
+	hold := holdFlag(fs)
+	vnet := vnetFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		usage()
+	}
+	syscalls := parseSyscallPoints(*points)
+	c := loadCluster()
+	failed := false
+	for i := 0; i < *iterations; i++ {
+		dir := filepath.Join(*outDir, fmt.Sprint(i))
+		res, err := run(c, sched.Explore, nil, dir, *seed+int64(i), syscalls, *hold, *vnet)
+		if !report(res, err) {
+			continue
+		}
+		failed = true
+		file := filepath.Join(dir, "schedule.trace")
+		if err := writeTrace(file, c, &res.Schedule); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("iteration %d (seed %d) failed, trace written to %s", i, *seed+int64(i), file)
+	}
+	return failed
+}
+
+func inspect(args []string) bool {
+	fs := subFlags("inspect")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	h, s, err := readTrace(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range h.Procs {
+		fmt.Printf("process %d\t%s\t%s\n", p.PID, p.Path, p.BuildID)
+	}
+	printSchedule(os.Stdout, s)
+	return failures(s) != nil
+}
+
+// syscallFlag defines the -syscalls flag of fs.
+func syscallFlag(fs *flag.FlagSet) *string {
+	return fs.String("syscalls", "", "make the syscalls of the comma separated `classes` scheduling points")
+}
+
+// holdFlag defines the -hold flag of fs.
+func holdFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("hold", false, "hold the messages between the processes until the scheduler delivers them")
+}
+
+// vnetFlag defines the -vnet flag of fs.
+func vnetFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("vnet", false, "connect the processes over a virtual network in shared memory")
+}
+
+func parseSyscallPoints(s string) int {
+	points, bad := dara.ParseSyscallPoints(s)
+	if bad != "" {
+		log.Fatalf("unknown class of syscalls %q", bad)
+	}
+	return points
+}
+
+func loadCluster() *Cluster {
+	c, err := readCluster(*clusterFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return c
+}
+
+// run runs the cluster once. A run that fails part way still returns
+// what was logged up to the failure, which is what we need to look into
+// it.
+func run(c *Cluster, mode sched.Mode, s *dara.Schedule, dir string, seed int64, syscalls int, hold, vnet bool) (*sched.Result, error) {
+	cfg, files, err := c.config(mode, dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeAll(files)
+	cfg.Schedule = s
+	cfg.Seed = seed
+	cfg.SyscallPoints = syscalls
+	cfg.HoldMessages = hold
+	cfg.VirtualNet = vnet
+	res, err := sched.Run(cfg)
+	if res == nil {
+		log.Fatal(err)
+	}
+	return res, err
+}
+
+// report prints what went wrong during a run and reports whether
+// anything did.
+func report(res *sched.Result, err error) bool {
+	if err != nil {
+		log.Print(err)
+	}
+	for pid, err := range res.ExitErrors {
+		log.Printf("process %d: %v", pid, err)
+	}
+	errs := failures(&res.Schedule)
+	for _, err := range errs {
+		log.Print(err)
+	}
+	return err != nil || errs != nil || len(res.Crashed) != 0
+}
+
+// failures returns the crashes and failed properties in s.
+func failures(s *dara.Schedule) []error {
+	var errs []error
+	for i, e := range s.LogEvents {
+		if e.Type == dara.CRASH_EVENT {
+			errs = append(errs, fmt.Errorf("process %d crashed in goroutine %d at event %d", e.P, e.G.Gid, i))
+		}
+	}
+	for _, pe := range s.PropEvents {
+		for _, f := range pe.PropFailures {
+			errs = append(errs, fmt.Errorf("property %s failed at event %d", f.Name, pe.EventIndex))
+		}
+	}
+	return errs
+}
+
+func readTrace(file string) (*trace.Header, *dara.Schedule, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	h, s, err := trace.Read(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return h, s, nil
+}
+
+// writeTrace writes s to file along with the build IDs of the binaries
+// of c, in the JSON form if the name of file ends in .json.
+func writeTrace(file string, c *Cluster, s *dara.Schedule) error {
+	h := &trace.Header{}
+	for i, p := range c.Procs {
+		id, err := trace.ReadBuildID(p.Path)
+		if err != nil {
+			log.Print(err)
+		}
+		h.Procs = append(h.Procs, trace.Proc{PID: i + 1, Path: p.Path, BuildID: id})
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(file, ".json") {
+		err = trace.WriteJSON(f, h, s)
+	} else {
+		err = trace.Write(f, h, s)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// checkBuildIDs warns about binaries of c which are not the ones h was
+// recorded from.
+func checkBuildIDs(c *Cluster, h *trace.Header) {
+	if len(h.Procs) != len(c.Procs) {
+		log.Printf("warning: trace has %d processes, cluster has %d", len(h.Procs), len(c.Procs))
+	}
+	for i, p := range c.Procs {
+		if i >= len(h.Procs) || h.Procs[i].BuildID == "" {
+			continue
+		}
+		id, err := trace.ReadBuildID(p.Path)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		if id != h.Procs[i].BuildID {
+			log.Printf("warning: process %d: %s has build ID %s, the trace was recorded with %s", i+1, p.Path, id, h.Procs[i].BuildID)
+		}
+	}
+}
+
+var eventNames = map[int]string{
+	dara.LOG_EVENT:       "LOG",
+	dara.SYSCALL_EVENT:   "SYSCALL",
+	dara.SEND_EVENT:      "SEND",
+	dara.REC_EVENT:       "REC",
+	dara.SCHED_EVENT:     "SCHED",
+	dara.INIT_EVENT:      "INIT",
+	dara.END_EVENT:       "END",
+	dara.THREAD_EVENT:    "THREAD",
+	dara.CRASH_EVENT:     "CRASH",
+	dara.DELETEVAR_EVENT: "DELETEVAR",
+	dara.TIMER_EVENT:     "TIMER",
+	dara.SELECT_EVENT:    "SELECT",
+	dara.NET_EVENT:       "NET",
+	dara.MESSAGE_EVENT:   "MESSAGE",
+}
+
+var messageOps = map[int]string{
+	dara.MESSAGE_HELD:      "held",
+	dara.MESSAGE_DELIVERED: "delivered",
+	dara.MESSAGE_FLUSHED:   "flushed",
+}
+
+func printSchedule(w io.Writer, s *dara.Schedule) {
+	for i, e := range s.LogEvents {
+		name, ok := eventNames[e.Type]
+		if !ok {
+			name = fmt.Sprintf("EVENT(%d)", e.Type)
+		}
+		fmt.Fprintf(w, "%d\tP%d\tG%d\t%s", i, e.P, e.G.Gid, name)
+		switch e.Type {
+		case dara.LOG_EVENT, dara.DELETEVAR_EVENT:
+			fmt.Fprintf(w, "\t%s", e.LE.LogID)
+			for _, v := range e.LE.Vars {
+				fmt.Fprintf(w, " %s=%v", v.VarName, v.Value)
+			}
+		case dara.SYSCALL_EVENT, dara.TIMER_EVENT:
+			fmt.Fprintf(w, "\tsyscall %d", e.SyscallInfo.SyscallNum)
+		case dara.SEND_EVENT, dara.REC_EVENT:
+			m := &e.Msg
+			fmt.Fprintf(w, "\tchan %#x/%d %d/%d", m.Chan.Pc, m.Chan.Index, m.Len, m.Cap)
+			if m.Partner.Gid != 0 {
+				fmt.Fprintf(w, " with G%d", m.Partner.Gid)
+			}
+			if m.Closed {
+				fmt.Fprint(w, " closed")
+			}
+		case dara.NET_EVENT:
+			remote, action, _ := e.NetLink()
+			fmt.Fprintf(w, "\tlink to P%d %s", remote, dara.NetActionString(action))
+		case dara.MESSAGE_EVENT:
+			if m, ok := e.HeldMessage(); ok {
+				fmt.Fprintf(w, "\tmessage %d to P%d on %s %s %d bytes", m.ID, m.Dst, m.Conn, messageOps[m.Op], m.Len)
+			}
+		case dara.SELECT_EVENT:
+			fmt.Fprintf(w, "\tcase %d of", e.Select.Chosen)
+			for _, c := range e.Select.Ready {
+				fmt.Fprintf(w, " %d@%#x", c.Index, c.PC)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	for _, pe := range s.PropEvents {
+		for _, f := range pe.PropFailures {
+			fmt.Fprintf(w, "property %s failed at event %d\n", f.Name, pe.EventIndex)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package dara
+
+//ClockEntry is the count of events a single goroutine has logged, as
+//far as the owner of a vector clock knows. Goroutines are named by
+//their process and goroutine id.
+type ClockEntry struct {
+	P int
+	Gid int
+	Tick uint64
+}
+
+//CLOCKENTRYSIZE is the size of an encoded ClockEntry
+const CLOCKENTRYSIZE = 24
+
+//VectorClock is a vector clock of fixed size, so that it can be kept
+//in shared memory and updated by the runtime without allocating. Its
+//entries are sorted by P and Gid, goroutines without an entry have a
+//Tick of 0. A clock which would grow beyond MAXCLOCKENTRIES entries
+//drops the new ones.
+type VectorClock struct {
+	Len int
+	Entries [MAXCLOCKENTRIES]ClockEntry
+}
+
+//clockLess reports whether (p, gid) sorts before (q, qgid)
+func clockLess(p, gid, q, qgid int) bool {
+	return p < q || p == q && gid < qgid
+}
+
+//find returns the index of the entry of (p, gid) in c, or the index it
+//would be inserted at and false
+func (c *VectorClock) find(p, gid int) (int, bool) {
+	i := 0
+	for i < c.Len && clockLess(c.Entries[i].P, c.Entries[i].Gid, p, gid) {
+		i++
+	}
+	return i, i < c.Len && c.Entries[i].P == p && c.Entries[i].Gid == gid
+}
+
+//Get returns the tick of (p, gid) in c
+func (c *VectorClock) Get(p, gid int) uint64 {
+	if i, ok := c.find(p, gid); ok {
+		return c.Entries[i].Tick
+	}
+	return 0
+}
+
+//Tick counts an event of (p, gid) in its own clock c. It returns false
+//if c is full and has no entry for (p, gid).
+func (c *VectorClock) Tick(p, gid int) bool {
+	i, ok := c.find(p, gid)
+	if !ok {
+		if c.Len == len(c.Entries) {
+			return false
+		}
+		copy(c.Entries[i+1:c.Len+1], c.Entries[i:c.Len])
+		c.Entries[i] = ClockEntry{P: p, Gid: gid}
+		c.Len++
+	}
+	c.Entries[i].Tick++
+	return true
+}
+
+//Join sets every entry of c to the larger of its own tick and the tick
+//in o. It returns false if entries of o were dropped because c is
+//full.
+func (c *VectorClock) Join(o *VectorClock) bool {
+	ok := true
+	i := 0
+	for j := 0; j < o.Len; j++ {
+		oe := &o.Entries[j]
+		for i < c.Len && clockLess(c.Entries[i].P, c.Entries[i].Gid, oe.P, oe.Gid) {
+			i++
+		}
+		if i < c.Len && c.Entries[i].P == oe.P && c.Entries[i].Gid == oe.Gid {
+			if oe.Tick > c.Entries[i].Tick {
+				c.Entries[i].Tick = oe.Tick
+			}
+			continue
+		}
+		if c.Len == len(c.Entries) {
+			ok = false
+			continue
+		}
+		copy(c.Entries[i+1:c.Len+1], c.Entries[i:c.Len])
+		c.Entries[i] = *oe
+		c.Len++
+	}
+	return ok
+}
+
+//Encode writes the entries of c to b, as many as fit, and returns the
+//number of bytes written. Every entry is written as P, Gid and Tick,
+//each of them 8 bytes little endian.
+func (c *VectorClock) Encode(b []byte) int {
+	n := 0
+	for i := 0; i < c.Len && n+CLOCKENTRYSIZE <= len(b); i++ {
+		e := &c.Entries[i]
+		putUint64(b[n:], uint64(e.P))
+		putUint64(b[n+8:], uint64(e.Gid))
+		putUint64(b[n+16:], e.Tick)
+		n += CLOCKENTRYSIZE
+	}
+	return n
+}
+
+//DecodeClock returns the entries written by VectorClock.Encode
+func DecodeClock(b []byte) []ClockEntry {
+	if len(b) < CLOCKENTRYSIZE {
+		return nil
+	}
+	c := make([]ClockEntry, len(b)/CLOCKENTRYSIZE)
+	for i := range c {
+		off := i * CLOCKENTRYSIZE
+		c[i] = ClockEntry{
+			P:    int(getUint64(b[off:])),
+			Gid:  int(getUint64(b[off+8:])),
+			Tick: getUint64(b[off+16:]),
+		}
+	}
+	return c
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := 0; i < 8; i++ {
+		b[i] = byte(v >> (8 * uint(i)))
+	}
+}
+
+func getUint64(b []byte) uint64 {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v |= uint64(b[i]) << (8 * uint(i))
+	}
+	return v
+}
+
+//clockTick returns the tick of (p, gid) in the sorted entries c
+func clockTick(c []ClockEntry, p, gid int) uint64 {
+	for i := range c {
+		if c[i].P == p && c[i].Gid == gid {
+			return c[i].Tick
+		}
+	}
+	return 0
+}
+
+//clockLeq reports whether every entry of a is at most the one in b
+func clockLeq(a, b []ClockEntry) bool {
+	for i := range a {
+		if a[i].Tick > clockTick(b, a[i].P, a[i].Gid) {
+			return false
+		}
+	}
+	return true
+}
+
+//HappensBefore reports whether the event with clock a happened before
+//the event with clock b
+func HappensBefore(a, b []ClockEntry) bool {
+	return clockLeq(a, b) && !clockLeq(b, a)
+}
+
+//Concurrent reports whether neither of the events with clocks a and b
+//happened before the other
+func Concurrent(a, b []ClockEntry) bool {
+	return !clockLeq(a, b) && !clockLeq(b, a)
+}
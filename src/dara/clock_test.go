@@ -0,0 +1,79 @@
+package dara_test
+
+import (
+	"dara"
+	"reflect"
+	"testing"
+)
+
+func entries(c *dara.VectorClock) []dara.ClockEntry {
+	return append([]dara.ClockEntry(nil), c.Entries[:c.Len]...)
+}
+
+func TestVectorClock(t *testing.T) {
+	var a, b dara.VectorClock
+	a.Tick(1, 5)
+	a.Tick(1, 5)
+	b.Tick(2, 1)
+	b.Tick(1, 7)
+	if !a.Join(&b) {
+		t.Fatal("Join dropped entries")
+	}
+	want := []dara.ClockEntry{{1, 5, 2}, {1, 7, 1}, {2, 1, 1}}
+	if got := entries(&a); !reflect.DeepEqual(got, want) {
+		t.Fatalf("joined clock is %v, want %v", got, want)
+	}
+	if a.Get(1, 7) != 1 || a.Get(3, 1) != 0 {
+		t.Errorf("Get returned %d and %d, want 1 and 0", a.Get(1, 7), a.Get(3, 1))
+	}
+
+	buf := make([]byte, a.Len*dara.CLOCKENTRYSIZE)
+	if n := a.Encode(buf); n != len(buf) {
+		t.Fatalf("Encode wrote %d bytes, want %d", n, len(buf))
+	}
+	if got := dara.DecodeClock(buf); !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded clock is %v, want %v", got, want)
+	}
+	if n := a.Encode(buf[:dara.CLOCKENTRYSIZE+1]); n != dara.CLOCKENTRYSIZE {
+		t.Errorf("Encode into a short buffer wrote %d bytes, want %d", n, dara.CLOCKENTRYSIZE)
+	}
+}
+
+func TestVectorClockFull(t *testing.T) {
+	var a, b dara.VectorClock
+	for i := 0; i < dara.MAXCLOCKENTRIES; i++ {
+		if !a.Tick(1, i) {
+			t.Fatalf("Tick of entry %d failed", i)
+		}
+	}
+	if a.Tick(2, 0) {
+		t.Error("Tick succeeded on a full clock")
+	}
+	if !a.Tick(1, 0) {
+		t.Error("Tick of an existing entry failed on a full clock")
+	}
+	b.Tick(0, 0)
+	b.Tick(1, 3)
+	b.Tick(1, 3)
+	if a.Join(&b) {
+		t.Error("Join into a full clock reported no dropped entries")
+	}
+	if a.Get(1, 3) != 2 {
+		t.Errorf("Join into a full clock did not update an existing entry")
+	}
+}
+
+func TestHappensBefore(t *testing.T) {
+	a := []dara.ClockEntry{{1, 1, 1}}
+	b := []dara.ClockEntry{{1, 1, 1}, {2, 1, 1}}
+	c := []dara.ClockEntry{{1, 1, 2}}
+	if !dara.HappensBefore(a, b) || dara.HappensBefore(b, a) {
+		t.Errorf("HappensBefore(%v, %v) should hold only one way", a, b)
+	}
+	if !dara.Concurrent(b, c) || dara.Concurrent(a, c) {
+		t.Errorf("Concurrent reports the wrong pairs")
+	}
+	if dara.HappensBefore(a, a) || dara.Concurrent(a, a) {
+		t.Errorf("an event is neither before nor concurrent with itself")
+	}
+}
@@ -1,5 +1,7 @@
 package dara
 
+import "unsafe"
+
 //DARA Specific consts
 //Constants for shared memory.
 const (
@@ -7,35 +9,100 @@ const (
 	PROT_READ = 0x1
 	PROT_WRITE = 0x2
 
-	//The number of preallocated communication channels in shared
-	//memory. This value can change in the future, for now 3 is the
-	//maximum number of Processes. Invariant: dara.CHANNELS > procs. TODO
-	//assert this
-    // 3 is maximum because DaraProcID starts from 1.... Need to fix that
-	CHANNELS = 4
+	//Size of the region at the start of shared memory reserved for the
+	//SharedHeader. The DaraProc slots start directly after it, so it
+	//is kept page aligned.
+	HEADERSIZE = 4096
 
 	//File discriptor for shared memory. This is set in the runscript.
 	DARAFD = 666
 
 	//State of spin locks. These are used by cas operations to control
-	//the execution of the insturmented runtimes
+	//the execution of the insturmented runtimes. A lock is CONTENDED
+	//when it is held and the other side is asleep on it with futex, it
+	//must be woken up when the lock is released.
 	UNLOCKED = 0
 	LOCKED = 1
+	CONTENDED = 2
 
-	// TODO : This must be automated and not hardcoded
-    // THIS SHOULD MATCH THE SIZE OF THE DaraProc struct.
-	DARAPROCSIZE = 127533208
+	//Size of a single DaraProc. The global scheduler records this in the
+	//SharedHeader so that runtimes built against a different layout
+	//refuse to start.
+	DARAPROCSIZE = unsafe.Sizeof(DaraProc{})
 
 	SCHEDLEN = 1000000000
-	PROCS = 3
 	MAXGOROUTINES = 4096
 
+	//Size of the event ring in DaraProc.Log. It must be a power of two
+	//so that the ring indices stay valid when they wrap around.
 	MAXLOGENTRIES = 4096
 	MAXLOGVARIABLES = 128
-	VARBUFLEN = 64
+	//Size of DaraProc.Arena, the variable length data of the events
+	//in the ring. It must be a power of two for the same reason as
+	//MAXLOGENTRIES.
+	ARENASIZE = 4 << 20
+	//How many of the ready cases of a select the runtime lets the
+	//global scheduler choose from
+	MAXSELECTCASES = 16
+	//How many goroutines a VectorClock keeps track of
+	MAXCLOCKENTRIES = 32
+	//How many connections a DaraProc publishes the clocks of its
+	//writes for in NetClocks, and the longest name of a connection
+	MAXNETCLOCKS = 64
+	MAXCONNLEN = 128
+	//How many faults the global scheduler can inject into the syscalls
+	//of a single process
+	MAXFAULTS = 64
+	//How many addresses a DaraProc publishes in Addrs, and the
+	//longest of them
+	MAXNETADDRS = 256
+	MAXADDRLEN = 64
+	//How many processes the links of a DaraProc lead to, the links to
+	//processes with a larger DARAPID can not be cut
+	MAXNETPEERS = 64
+	//How many listeners and connections a process has on the virtual
+	//network, how many connections a listener keeps waiting to be
+	//accepted, and how many bytes each direction of a connection
+	//buffers. VNETPIPESIZE must be a power of two so that the pipe
+	//indices stay valid when they wrap around.
+	MAXVLISTENERS = 16
+	MAXVCONNS = 64
+	VNETBACKLOG = 32
+	VNETPIPESIZE = 64 << 10
+	//The virtual network hands out ephemeral ports from VNETPORTBASE
+	//on, VNETPORTSPAN of them to each process in turn
+	VNETPORTBASE = 32768
+	VNETPORTSPAN = 256
 	UNSUPPORTEDVAL = 2440
-    BLOCKIDLEN = 256
     MAXBLOCKS = 4096
+	//Size of DaraProc.CoverageNames, the block IDs of a single
+	//coverage report
+	COVERAGENAMESSIZE = 1 << 20
+)
+
+//Shared memory header identification. SHMMAGIC marks memory that has
+//been set up by a global scheduler, SHMVERSION is bumped whenever the
+//meaning of the shared structures changes without their size changing.
+const (
+	SHMMAGIC   = 0x314d485341524144 // "DARASHM1" little endian
+	SHMVERSION = 10
+)
+
+//VIRTUALEPOCH is the default SharedHeader.WallClock, midnight UTC on
+//the 1st of January 2018. A fixed default keeps time.Now the same in
+//a recording and its replays.
+const VIRTUALEPOCH int64 = 1514764800 * 1e9
+
+//Feature bits advertised in SharedHeader.Features. The global
+//scheduler sets the bits for the features it relies on, a runtime
+//refuses to start if any of them are missing from FEATURES.
+const (
+	FEATURE_COVERAGE = 1 << iota // Coverage is reported through DaraProc.Coverage
+	FEATURE_FAST_REPLAY          // The schedule is preloaded into DaraProc.Log
+	FEATURE_FUTEX                // The scheduler futex wakes runtimes sleeping on a CONTENDED lock
+
+	//FEATURES is the set of features supported by this runtime.
+	FEATURES = FEATURE_COVERAGE | FEATURE_FAST_REPLAY | FEATURE_FUTEX
 )
 
 const (
@@ -53,6 +120,11 @@ const (
 	INT_STRING = "int"
 	FLOAT_STRING = "float"
 	STRING_STRING = "string"
+	UINT_STRING = "uint"
+	BYTES_STRING = "[]byte"
+	SLICE_STRING = "slice"
+	MAP_STRING = "map"
+	NIL_STRING = "nil"
 )
 
 //Goroutine states from runtime/proc.go
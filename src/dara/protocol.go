@@ -0,0 +1,263 @@
+package dara
+
+//The global scheduler drives each runtime through the command channel
+//in its DaraProc. The channel is only ever touched by whoever holds
+//DaraProc.Lock.
+//
+//To issue a command the global scheduler takes the lock, writes Cmd
+//(and CmdArg or RunningRoutine if the command needs them), increments
+//CmdSeq and CmdSignal, releases the lock and futex wakes CmdSignal.
+//The runtime takes the lock, notices that CmdSeq differs from
+//ReplySeq, checks that the command is legal in its current State,
+//carries it out and answers by writing Reply, setting ReplySeq to
+//CmdSeq and incrementing ReplySignal before releasing the lock and
+//futex waking ReplySignal. A command whose CmdSeq equals ReplySeq has
+//been answered and is never run twice. Whoever finds the lock held for
+//long marks it CONTENDED and sleeps on it, so a CONTENDED lock must be
+//futex woken when it is released.
+//
+//Commands are numbered from 1. Before the first command the runtime
+//runs main up to its first scheduling point and answers the implicit
+//command 0 with REPLY_READY.
+//
+//While replaying, a goroutine which reaches a select statement with
+//more than one ready case lists them in DaraProc.Select and answers
+//the command that let it run with REPLY_SELECT. The global scheduler
+//must answer with CMD_CHOOSE_SELECT, naming the case to take in
+//CmdArg, and the goroutine carries on until it answers that command in
+//turn.
+//
+//A goroutine which reaches a syscall point, a syscall of one of the
+//classes in DARA_SYSCALL_POINTS, stays runnable and answers the
+//command that let it run with REPLY_RAN before it makes the syscall.
+//DaraProc.Syscall names the syscall until the next command, it is -1
+//after a goroutine stopped anywhere else.
+//
+//A goroutine which finds the link to another process delayed, see
+//NET_DELAY, stays runnable and answers the command that let it run
+//with REPLY_RAN. It carries on once it is run after the global
+//scheduler set the link to anything else.
+//
+//A runtime whose goroutines all wait for timers answers the command
+//that let the last one run and keeps taking commands. It carries out
+//CMD_FIRE_TIMER, and answers a command to run a goroutine with
+//REPLY_RAN straight away unless the virtual time the global scheduler
+//set for it made one of its own timers due.
+//
+//A runtime run with DARA_HOLD_MESSAGES holds what its goroutines
+//write to the connections to other Dara processes until the global
+//scheduler delivers it with CMD_DELIVER_MESSAGE, see MESSAGE_HELD. It
+//takes commands while it holds messages, even if none of its
+//goroutines can run. A delivery which leaves the runtime with nothing
+//to run and nothing more to deliver blocks on the network before it
+//is answered, see NET_BLOCK.
+//
+//A runtime run with DARA_VIRTUAL_NET keeps taking commands while its
+//goroutines wait on the virtual network. CMD_POLL_NET readies the
+//ones that can carry on, see dara/vnet.go. DaraProc.NetSeq tells the
+//global scheduler whether anything changed since it last polled.
+//
+//NET_BLOCK and NET_WAKEUP are interim replies. They are written while
+//a command is still being carried out and leave ReplySeq untouched.
+//NET_BLOCK means the runtime has released the lock while it waits on
+//the network, NET_WAKEUP means it has been woken up and is waiting to
+//get the lock back. The global scheduler must hand the lock back
+//without issuing a new command.
+
+//Command is issued by the global scheduler to a runtime
+type Command int
+
+const (
+	//CMD_NONE means no command has been issued yet
+	CMD_NONE Command = iota
+	//CMD_START lets the runtime run whichever goroutine it chose, without
+	//recording it. Used for the first event of a replay
+	CMD_START
+	//CMD_RECORD_FREELY lets the runtime run whichever goroutine it chose,
+	//the goroutine is reported back through RunningRoutine
+	CMD_RECORD_FREELY
+	//CMD_RUN_GOROUTINE runs the goroutine described by RunningRoutine
+	CMD_RUN_GOROUTINE
+	//CMD_FIRE_TIMER fires the timer with the id in CmdArg
+	CMD_FIRE_TIMER
+	//CMD_END_REPLAY releases the runtime from the control of the global
+	//scheduler, it keeps running without reporting events
+	CMD_END_REPLAY
+	//CMD_SHUTDOWN makes the runtime log its end event and exit
+	CMD_SHUTDOWN
+	//CMD_CHOOSE_SELECT answers REPLY_SELECT with the index of the case
+	//to take in CmdArg
+	CMD_CHOOSE_SELECT
+	//CMD_DELIVER_MESSAGE delivers the held message with the ID in
+	//CmdArg, see MESSAGE_HELD
+	CMD_DELIVER_MESSAGE
+	//CMD_POLL_NET readies the goroutines waiting on the virtual network
+	//which can carry on
+	CMD_POLL_NET
+	numCommands
+)
+
+var commandStrings = [...]string{
+	CMD_NONE:            "None",
+	CMD_START:           "Start",
+	CMD_RECORD_FREELY:   "RecordFreely",
+	CMD_RUN_GOROUTINE:   "RunGoroutine",
+	CMD_FIRE_TIMER:      "FireTimer",
+	CMD_END_REPLAY:      "EndReplay",
+	CMD_SHUTDOWN:        "Shutdown",
+	CMD_CHOOSE_SELECT:   "ChooseSelect",
+	CMD_DELIVER_MESSAGE: "DeliverMessage",
+	CMD_POLL_NET:        "PollNet",
+}
+
+func (c Command) String() string {
+	if c < 0 || c >= numCommands {
+		return "Unknown"
+	}
+	return commandStrings[c]
+}
+
+//Reply is written by a runtime to answer a command
+type Reply int
+
+const (
+	//REPLY_NONE means the runtime has not replied yet
+	REPLY_NONE Reply = iota
+	//REPLY_READY answers the implicit first command, main has run up to
+	//its first scheduling point
+	REPLY_READY
+	//REPLY_RAN means the goroutine in RunningRoutine ran until it
+	//reached a scheduling point
+	REPLY_RAN
+	//REPLY_TIMER_FIRED means the timer in CmdArg was fired
+	REPLY_TIMER_FIRED
+	//REPLY_DETACHED answers CMD_END_REPLAY
+	REPLY_DETACHED
+	//REPLY_FINISHED means main returned or the process exited
+	REPLY_FINISHED
+	//REPLY_CRASHED means the process panicked or threw
+	REPLY_CRASHED
+	//REPLY_ILLEGAL means the command was not legal in the runtime's
+	//current State and has been ignored
+	REPLY_ILLEGAL
+	//REPLY_NET_BLOCK is an interim reply, see above
+	REPLY_NET_BLOCK
+	//REPLY_NET_WAKEUP is an interim reply, see above
+	REPLY_NET_WAKEUP
+	//REPLY_SELECT asks the global scheduler to choose among the ready
+	//cases in DaraProc.Select
+	REPLY_SELECT
+	//REPLY_DELIVERED means the message in CmdArg was delivered
+	REPLY_DELIVERED
+	//REPLY_POLLED means the virtual network was polled
+	REPLY_POLLED
+	numReplies
+)
+
+var replyStrings = [...]string{
+	REPLY_NONE:        "None",
+	REPLY_READY:       "Ready",
+	REPLY_RAN:         "Ran",
+	REPLY_TIMER_FIRED: "TimerFired",
+	REPLY_DETACHED:    "Detached",
+	REPLY_FINISHED:    "Finished",
+	REPLY_CRASHED:     "Crashed",
+	REPLY_ILLEGAL:     "Illegal",
+	REPLY_NET_BLOCK:   "NetBlock",
+	REPLY_NET_WAKEUP:  "NetWakeup",
+	REPLY_SELECT:      "Select",
+	REPLY_DELIVERED:   "Delivered",
+	REPLY_POLLED:      "Polled",
+}
+
+func (r Reply) String() string {
+	if r < 0 || r >= numReplies {
+		return "Unknown"
+	}
+	return replyStrings[r]
+}
+
+//ProcState is the state of a runtime as far as the command channel is
+//concerned. It is written by the runtime.
+type ProcState int
+
+const (
+	//STATE_INIT is the state before the first command
+	STATE_INIT ProcState = iota
+	//STATE_RECORDING is entered with CMD_RECORD_FREELY
+	STATE_RECORDING
+	//STATE_REPLAYING is entered with CMD_START, CMD_RUN_GOROUTINE or
+	//CMD_FIRE_TIMER
+	STATE_REPLAYING
+	//STATE_DETACHED is entered with CMD_END_REPLAY
+	STATE_DETACHED
+	//STATE_FINISHED is entered once the process is done
+	STATE_FINISHED
+	//STATE_SELECTING is entered from STATE_REPLAYING with
+	//REPLY_SELECT, the runtime waits for CMD_CHOOSE_SELECT
+	STATE_SELECTING
+	numStates
+)
+
+var stateStrings = [...]string{
+	STATE_INIT:      "Init",
+	STATE_RECORDING: "Recording",
+	STATE_REPLAYING: "Replaying",
+	STATE_DETACHED:  "Detached",
+	STATE_FINISHED:  "Finished",
+	STATE_SELECTING: "Selecting",
+}
+
+func (s ProcState) String() string {
+	if s < 0 || s >= numStates {
+		return "Unknown"
+	}
+	return stateStrings[s]
+}
+
+//NextState returns the state a runtime in state s moves to when it
+//carries out command c, and false if c is not legal in s.
+//
+//A runtime may switch from replaying to recording, which lets a
+//schedule be replayed up to a point and then explored from there, but
+//once it records it has given up control over which goroutine runs and
+//can not go back to replaying. Timers are only fired on command while
+//replaying, a recording runtime fires its own. A runtime waiting for
+//the choice of a select case takes nothing but that choice, or
+//CMD_SHUTDOWN. Messages are delivered and the virtual network is
+//polled in every state a goroutine may be run in, and the runtime
+//stays in that state. Nothing is legal once the runtime has detached
+//or finished.
+func NextState(s ProcState, c Command) (ProcState, bool) {
+	switch c {
+	case CMD_DELIVER_MESSAGE, CMD_POLL_NET:
+		if s == STATE_INIT || s == STATE_RECORDING || s == STATE_REPLAYING {
+			return s, true
+		}
+	case CMD_START:
+		if s == STATE_INIT {
+			return STATE_REPLAYING, true
+		}
+	case CMD_RECORD_FREELY:
+		if s == STATE_INIT || s == STATE_RECORDING || s == STATE_REPLAYING {
+			return STATE_RECORDING, true
+		}
+	case CMD_RUN_GOROUTINE, CMD_FIRE_TIMER:
+		if s == STATE_INIT || s == STATE_REPLAYING {
+			return STATE_REPLAYING, true
+		}
+	case CMD_END_REPLAY:
+		if s == STATE_INIT || s == STATE_RECORDING || s == STATE_REPLAYING {
+			return STATE_DETACHED, true
+		}
+	case CMD_CHOOSE_SELECT:
+		if s == STATE_SELECTING {
+			return STATE_REPLAYING, true
+		}
+	case CMD_SHUTDOWN:
+		if s != STATE_FINISHED {
+			return STATE_FINISHED, true
+		}
+	}
+	return s, false
+}
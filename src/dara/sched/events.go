@@ -0,0 +1,111 @@
+package sched
+
+import (
+	"dara"
+	"runtime"
+)
+
+// arenaBytes returns the bytes ref refers to in the arena of dp, or
+// nil if ref is out of bounds.
+func arenaBytes(dp *dara.DaraProc, ref dara.ArenaRef) []byte {
+	return refBytes(dp.Arena[:], ref)
+}
+
+func refBytes(buf []byte, ref dara.ArenaRef) []byte {
+	end := uint64(ref.Off) + uint64(ref.Len)
+	if end > uint64(len(buf)) {
+		return nil
+	}
+	return buf[ref.Off:end]
+}
+
+func arenaString(dp *dara.DaraProc, ref dara.ArenaRef) string {
+	return string(arenaBytes(dp, ref))
+}
+
+// decodeEvent turns an event logged in the ring of dp into a
+// dara.Event.
+func decodeEvent(dp *dara.DaraProc, e *dara.EncEvent) dara.Event {
+	ev := dara.Event{
+		Type:  e.Type,
+		P:     e.P,
+		G:     e.G,
+		Epoch: e.Epoch,
+		Msg: dara.Message{
+			Body:    arenaString(dp, e.EM.Body),
+			Chan:    e.EM.Chan,
+			Len:     e.EM.Len,
+			Cap:     e.EM.Cap,
+			Closed:  e.EM.Closed,
+			Partner: e.EM.Partner,
+		},
+		Clock: dara.DecodeClock(arenaBytes(dp, e.Clock)),
+		Now:   e.Now,
+		Fault: e.Fault,
+	}
+	ev.SyscallInfo.SyscallNum = e.SyscallInfo.SyscallNum
+	ev.SyscallInfo.NumArgs = e.SyscallInfo.NumArgs
+	ev.SyscallInfo.NumRets = e.SyscallInfo.NumRets
+	for i := range e.SyscallInfo.Args {
+		ev.SyscallInfo.Args[i] = decodeGeneralType(dp, &e.SyscallInfo.Args[i])
+	}
+	for i := range e.SyscallInfo.Rets {
+		ev.SyscallInfo.Rets[i] = decodeGeneralType(dp, &e.SyscallInfo.Rets[i])
+	}
+	if e.Type == dara.SELECT_EVENT {
+		n := e.Select.NumReady
+		if n < 0 {
+			n = 0
+		} else if n > len(e.Select.Ready) {
+			n = len(e.Select.Ready)
+		}
+		ev.Select.Ready = append([]dara.SelectCase(nil), e.Select.Ready[:n]...)
+		ev.Select.Chosen = e.Select.Chosen
+	}
+	if e.Type == dara.LOG_EVENT || e.Type == dara.DELETEVAR_EVENT {
+		ev.LE.LogID = arenaString(dp, e.ELE.LogID)
+		for i := 0; i < e.ELE.Length && i < len(e.ELE.Vars); i++ {
+			v := &e.ELE.Vars[i]
+			typ := arenaString(dp, v.Type)
+			ev.LE.Vars = append(ev.LE.Vars, dara.NameValuePair{
+				VarName: arenaString(dp, v.VarName),
+				Value:   decodeValue(arenaBytes(dp, v.Value)),
+				Type:    typ,
+			})
+		}
+	}
+	return ev
+}
+
+// decodeValue decodes a value logged with runtime.DaraLog, a value
+// which does not decode is reported as nil.
+func decodeValue(buf []byte) interface{} {
+	v, _ := runtime.DecodeValue(buf)
+	return v
+}
+
+func decodeGeneralType(dp *dara.DaraProc, t *dara.EncGeneralType) dara.GeneralType {
+	return dara.GeneralType{
+		Type:        t.Type,
+		Integer:     t.Integer,
+		Bool:        t.Bool,
+		Float:       t.Float,
+		Integer64:   t.Integer64,
+		String:      arenaString(dp, t.String),
+		Unsupported: t.Unsupported,
+	}
+}
+
+// decodeCoverage collects the coverage reported in a DaraProc.
+func decodeCoverage(dp *dara.DaraProc, eventIndex int) dara.CoverageEvent {
+	cov := dara.CoverageEvent{CoverageInfo: make(map[string]uint64), EventIndex: eventIndex}
+	names := dp.CoverageNames[:]
+	if dp.CoverageNamesLen < uint32(len(names)) {
+		names = names[:dp.CoverageNamesLen]
+	}
+	for i := 0; i < dp.CoverageIndex && i < len(dp.Coverage); i++ {
+		c := &dp.Coverage[i]
+		cov.CoverageInfo[string(refBytes(names, c.BlockID))] += c.Count
+	}
+	return cov
+}
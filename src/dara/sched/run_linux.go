@@ -0,0 +1,892 @@
+package sched
+
+import (
+	"dara"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// How long processes get to exit on their own at the end of a run
+// before they are killed.
+const exitGrace = 5 * time.Second
+
+// proc is the scheduler's view of one process of the cluster.
+type proc struct {
+	pid    int
+	cfg    ProcConfig
+	cmd    *exec.Cmd
+	dp     *dara.DaraProc
+	exited chan struct{}
+	err    error
+	// seq is the CmdSeq of the last command issued to the process.
+	seq uint64
+	// held is set while the scheduler holds dp.Lock, the process is
+	// paused at a scheduling point.
+	held bool
+	// blocked is set while the process waits on the network with the
+	// lock released.
+	blocked bool
+	// done is set once the process has finished, crashed or detached.
+	done bool
+	// selecting is set while the process waits for the scheduler to
+	// choose a case of a select, see REPLY_SELECT.
+	selecting bool
+	// timers maps the ids of the timers the process has armed and
+	// which have neither fired nor been cancelled to the virtual time
+	// they are due at.
+	timers map[int64]int64
+	// outbox holds the messages p holds for the scheduler to deliver,
+	// in the order they were written. Len is what is left of each.
+	outbox []dara.HeldMessage
+	// netSeq is the NetSeq of p when its virtual network was last
+	// polled.
+	netSeq uint32
+}
+
+// pending returns the oldest message p holds on each of its
+// connections, the ones that may be delivered next.
+func (p *proc) pending() []dara.HeldMessage {
+	var ms []dara.HeldMessage
+	seen := make(map[string]bool)
+	for _, m := range p.outbox {
+		if !seen[m.Conn] {
+			seen[m.Conn] = true
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+// netChanged reports whether p has goroutines waiting on the virtual
+// network and somebody has changed it since it was last polled.
+func (p *proc) netChanged() bool {
+	return atomic.LoadUint32(&p.dp.NetWaiters) > 0 && atomic.LoadUint32(&p.dp.NetSeq) != p.netSeq
+}
+
+// answered reports whether p has answered its last command. The
+// interim replies are no answer, which matters before the first
+// command too, when p has not answered anything and the sequence
+// numbers match. The lock of p must be held.
+func (p *proc) answered() bool {
+	r := p.dp.Reply
+	return p.dp.ReplySeq == p.seq && r != dara.REPLY_NONE && r != dara.REPLY_NET_BLOCK && r != dara.REPLY_NET_WAKEUP
+}
+
+func (p *proc) alive() bool {
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// runnable returns the goroutines p may be asked to run.
+func (p *proc) runnable() []dara.RoutineInfo {
+	var gs []dara.RoutineInfo
+	for i := range p.dp.Routines {
+		r := &p.dp.Routines[i]
+		if r.Gid > 0 && dara.GetDaraProcStatus(r.Status) == dara.Runnable {
+			gs = append(gs, *r)
+		}
+	}
+	return gs
+}
+
+type scheduler struct {
+	cfg   Config
+	shm   *SharedMem
+	procs []*proc
+	rng   *rand.Rand
+	res   Result
+	// decisions counts the commands issued so far.
+	decisions int
+	// started is when the run started, virtual time follows the time
+	// since then in Record mode.
+	started time.Time
+	// partition is the partition Explore mode has installed, nil if
+	// the network is whole.
+	partition *Partition
+}
+
+// Run launches the processes described by cfg, drives them in cfg.Mode
+// until they are all done or cfg.MaxEvents scheduling decisions have
+// been made, shuts them down and returns what they logged.
+func Run(cfg Config) (*Result, error) {
+	if len(cfg.Procs) == 0 {
+		return nil, errors.New("dara/sched: no processes to run")
+	}
+	if cfg.Mode == Replay && cfg.Schedule == nil {
+		return nil, errors.New("dara/sched: replay needs a schedule")
+	}
+	for pid, faults := range cfg.Faults {
+		if len(faults) > dara.MAXFAULTS {
+			return nil, fmt.Errorf("dara/sched: %d faults for process %d, at most %d are supported", len(faults), pid, dara.MAXFAULTS)
+		}
+	}
+	if err := checkPartitions(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.MaxEvents <= 0 {
+		cfg.MaxEvents = DefaultMaxEvents
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = strconv.Itoa(dara.WARN)
+	}
+	path := cfg.SharedMemPath
+	if path == "" {
+		f, err := ioutil.TempFile(sharedMemDir(), "dara-shm-")
+		if err != nil {
+			return nil, err
+		}
+		path = f.Name()
+		f.Close()
+	}
+	shm, err := CreateSharedMem(path, len(cfg.Procs))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+	defer shm.Close()
+	if !cfg.StartTime.IsZero() {
+		shm.Header.WallClock = cfg.StartTime.UnixNano()
+	}
+
+	s := &scheduler{
+		cfg:     cfg,
+		shm:     shm,
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+		res:     Result{ExitErrors: make(map[int]error)},
+		started: time.Now(),
+	}
+	err = s.start()
+	if err == nil {
+		switch cfg.Mode {
+		case Record:
+			err = s.record()
+		case Replay:
+			err = s.replay()
+		case Explore:
+			err = s.explore()
+		default:
+			err = fmt.Errorf("dara/sched: unknown mode %v", cfg.Mode)
+		}
+	}
+	s.shutdown()
+	return &s.res, err
+}
+
+// checkPartitions checks that the partitions of cfg only name its
+// processes and cut links the runtimes can cut.
+func checkPartitions(cfg *Config) error {
+	if len(cfg.Partitions) > 0 && len(cfg.Procs) > dara.MAXNETPEERS {
+		return fmt.Errorf("dara/sched: can not partition more than %d processes", dara.MAXNETPEERS)
+	}
+	for i, pt := range cfg.Partitions {
+		if pt.Action < dara.NET_DELIVER || pt.Action > dara.NET_RESET {
+			return fmt.Errorf("dara/sched: partition %d has unknown action %d", i, pt.Action)
+		}
+		for _, pid := range pt.Side {
+			if pid < 1 || pid > len(cfg.Procs) {
+				return fmt.Errorf("dara/sched: partition %d names unknown process %d", i, pid)
+			}
+		}
+	}
+	return nil
+}
+
+// sharedMemDir returns the directory shared memory files are created
+// in by default.
+func sharedMemDir() string {
+	if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() {
+		return "/dev/shm"
+	}
+	return os.TempDir()
+}
+
+// start launches every process and lets each of them run main up to
+// its first scheduling point, one at a time.
+func (s *scheduler) start() error {
+	for i, pc := range s.cfg.Procs {
+		p := &proc{
+			pid:    i + 1,
+			cfg:    pc,
+			dp:     s.shm.Proc(i + 1),
+			exited: make(chan struct{}),
+			timers: make(map[int64]int64),
+		}
+		// The runtime blocks on the lock in initDara until the
+		// scheduler lets it go.
+		p.dp.Lock = dara.LOCKED
+		p.dp.NumFaults = copy(p.dp.Faults[:], s.faultsOf(p))
+		p.held = true
+		s.procs = append(s.procs, p)
+		if err := s.launch(p); err != nil {
+			return err
+		}
+	}
+	for _, p := range s.procs {
+		s.release(p)
+		r, err := s.await(p)
+		if err != nil {
+			return err
+		}
+		if err := s.handle(p, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scheduler) launch(p *proc) error {
+	cmd := exec.Command(p.cfg.Path, p.cfg.Args...)
+	cmd.Dir = p.cfg.Dir
+	cmd.Stdout = p.cfg.Stdout
+	cmd.Stderr = p.cfg.Stderr
+	seed, points, hold, vnet := s.initOf(p)
+	cmd.Env = append(os.Environ(), p.cfg.Env...)
+	cmd.Env = append(cmd.Env,
+		"DARAON=true",
+		"DARAPID="+strconv.Itoa(p.pid),
+		"DARA_MODE="+s.cfg.Mode.String(),
+		"DARA_LOG_LEVEL="+s.cfg.LogLevel,
+		"DARA_SEED="+strconv.FormatInt(seed, 10),
+		"DARA_SYSCALL_POINTS="+strconv.Itoa(points),
+		"DARA_HOLD_MESSAGES="+strconv.FormatBool(hold),
+		"DARA_VIRTUAL_NET="+strconv.FormatBool(vnet),
+	)
+	// ExtraFiles[i] becomes fd 3+i in the child.
+	cmd.ExtraFiles = make([]*os.File, dara.DARAFD-2)
+	cmd.ExtraFiles[dara.DARAFD-3] = s.shm.File()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("dara/sched: starting process %d: %v", p.pid, err)
+	}
+	p.cmd = cmd
+	go func() {
+		p.err = cmd.Wait()
+		close(p.exited)
+	}()
+	return nil
+}
+
+// initOf returns the DARA_SEED, DARA_SYSCALL_POINTS,
+// DARA_HOLD_MESSAGES and DARA_VIRTUAL_NET of p.
+func (s *scheduler) initOf(p *proc) (seed int64, points int, hold, vnet bool) {
+	if s.cfg.Mode != Replay {
+		return dara.ProcSeed(s.cfg.Seed, p.pid), s.cfg.SyscallPoints, s.cfg.HoldMessages, s.cfg.VirtualNet
+	}
+	for i := range s.cfg.Schedule.LogEvents {
+		e := &s.cfg.Schedule.LogEvents[i]
+		if e.P != p.pid || e.Type != dara.INIT_EVENT {
+			continue
+		}
+		seed, _ = e.InitSeed()
+		points, _ = e.InitSyscallPoints()
+		hold = e.InitHoldMessages()
+		vnet = e.InitVirtualNet()
+		break
+	}
+	return seed, points, hold, vnet
+}
+
+// faultsOf returns the faults to inject into the syscalls of p.
+func (s *scheduler) faultsOf(p *proc) []dara.Fault {
+	if s.cfg.Mode != Replay {
+		return s.cfg.Faults[p.pid]
+	}
+	var faults []dara.Fault
+	for _, e := range s.cfg.Schedule.LogEvents {
+		if e.P == p.pid && e.Type == dara.SYSCALL_EVENT && e.Fault.Call != 0 {
+			faults = append(faults, e.Fault)
+		}
+	}
+	return faults
+}
+
+// release hands the lock of p back to its runtime.
+func (s *scheduler) release(p *proc) {
+	p.held = false
+	unlockWord(&p.dp.Lock)
+}
+
+// advance moves the virtual clock of p, which must be held, forward to
+// now. The clock never goes backwards.
+func (s *scheduler) advance(p *proc, now int64) {
+	if now > p.dp.Now {
+		p.dp.Now = now
+	}
+}
+
+// issue sends cmd to p, which must be held, and waits for the answer.
+func (s *scheduler) issue(p *proc, cmd dara.Command, arg int64, g *dara.RoutineInfo) (dara.Reply, error) {
+	dp := p.dp
+	dp.Cmd = cmd
+	dp.CmdArg = arg
+	if g != nil {
+		dp.RunningRoutine = *g
+	}
+	if cmd == dara.CMD_POLL_NET || cmd == dara.CMD_RECORD_FREELY {
+		// Both poll the virtual network, whatever changes it from here
+		// on is news to p.
+		p.netSeq = atomic.LoadUint32(&dp.NetSeq)
+	}
+	p.seq++
+	dp.CmdSeq = p.seq
+	atomic.AddUint32(&dp.CmdSignal, 1)
+	p.selecting = false
+	s.release(p)
+	futexWake(&dp.CmdSignal)
+	s.decisions++
+	return s.await(p)
+}
+
+// await waits until p answers its last command or blocks on the
+// network, in which case it returns REPLY_NET_BLOCK. On return p is
+// held unless it blocked. It fails if p has not answered within
+// Config.Timeout.
+func (s *scheduler) await(p *proc) (dara.Reply, error) {
+	dp := p.dp
+	deadline := time.Now().Add(s.cfg.Timeout)
+	for {
+		signal := atomic.LoadUint32(&dp.ReplySignal)
+		// A runtime whose event ring is full wakes us up while we wait
+		// for the lock.
+		idle := func() bool {
+			s.drainEvents(p)
+			return p.alive()
+		}
+		switch err := lockWord(&dp.Lock, deadline, idle); err {
+		case errGone:
+			p.done = true
+			return dara.REPLY_NONE, fmt.Errorf("dara/sched: process %d exited without answering %v: %v", p.pid, dp.Cmd, p.err)
+		case errTimeout:
+			return dara.REPLY_NONE, fmt.Errorf("dara/sched: process %d did not answer %v within %v", p.pid, dp.Cmd, s.cfg.Timeout)
+		}
+		if p.answered() {
+			p.held = true
+			p.blocked = false
+			return dp.Reply, nil
+		}
+		if dp.Reply == dara.REPLY_NET_BLOCK {
+			p.blocked = true
+			unlockWord(&dp.Lock)
+			return dara.REPLY_NET_BLOCK, nil
+		}
+		// The runtime has not picked the command up yet.
+		unlockWord(&dp.Lock)
+		if time.Now().After(deadline) {
+			return dara.REPLY_NONE, fmt.Errorf("dara/sched: process %d did not pick up %v within %v", p.pid, dp.Cmd, s.cfg.Timeout)
+		}
+		futexWait(&dp.ReplySignal, signal, pollInterval)
+	}
+}
+
+// poll checks on a blocked process without waiting for it. It reports
+// whether p has answered its last command.
+func (s *scheduler) poll(p *proc) (dara.Reply, bool, error) {
+	dp := p.dp
+	if !tryLock(&dp.Lock) {
+		// Woken up and running again.
+		return dara.REPLY_NONE, false, nil
+	}
+	if p.answered() {
+		p.held = true
+		p.blocked = false
+		return dp.Reply, true, nil
+	}
+	unlockWord(&dp.Lock)
+	if !p.alive() {
+		p.blocked = false
+		p.done = true
+		return dara.REPLY_NONE, false, fmt.Errorf("dara/sched: process %d exited while blocked on the network: %v", p.pid, p.err)
+	}
+	return dara.REPLY_NONE, false, nil
+}
+
+// waitBlocked waits until at least one blocked process has answered
+// and handles its reply. It returns false if no process is blocked. It
+// fails if none has answered within Config.Timeout.
+func (s *scheduler) waitBlocked() (bool, error) {
+	deadline := time.Now().Add(s.cfg.Timeout)
+	for {
+		waiting := false
+		for _, p := range s.procs {
+			if !p.blocked {
+				continue
+			}
+			waiting = true
+			r, ok, err := s.poll(p)
+			if err != nil {
+				return true, err
+			}
+			if ok {
+				return true, s.handle(p, r)
+			}
+		}
+		if !waiting {
+			return false, nil
+		}
+		if time.Now().After(deadline) {
+			return true, fmt.Errorf("dara/sched: no process blocked on the network answered within %v", s.cfg.Timeout)
+		}
+		time.Sleep(pollInterval / 10)
+	}
+}
+
+// handle collects what p logged while carrying out the command it
+// answered with r.
+func (s *scheduler) handle(p *proc, r dara.Reply) error {
+	if r == dara.REPLY_NET_BLOCK {
+		// What p logged before it blocked comes before anything the
+		// other processes log while it waits, like the delivery of a
+		// message they go on to read.
+		s.drainEvents(p)
+		return nil
+	}
+	s.drain(p)
+	switch r {
+	case dara.REPLY_ILLEGAL:
+		return fmt.Errorf("dara/sched: process %d rejected %v in state %v", p.pid, p.dp.Cmd, p.dp.State)
+	case dara.REPLY_SELECT:
+		p.selecting = true
+	case dara.REPLY_CRASHED:
+		if !containsPid(s.res.Crashed, p.pid) {
+			s.res.Crashed = append(s.res.Crashed, p.pid)
+		}
+		fallthrough
+	case dara.REPLY_FINISHED, dara.REPLY_DETACHED:
+		p.done = true
+		s.release(p)
+	}
+	return nil
+}
+
+// drain moves the events and coverage logged by p into the result.
+// p must be held.
+func (s *scheduler) drain(p *proc) {
+	s.drainEvents(p)
+	dp := p.dp
+	sched := &s.res.Schedule
+	if dp.CoverageIndex > 0 {
+		sched.CovEvents = append(sched.CovEvents, decodeCoverage(dp, len(sched.LogEvents)-1))
+		dp.CoverageIndex = 0
+	}
+}
+
+// drainEvents moves the events in the ring of p into the result. It
+// may be called at any time, the runtime only ever writes to the free
+// part of the ring.
+func (s *scheduler) drainEvents(p *proc) {
+	dp := p.dp
+	sched := &s.res.Schedule
+	head := atomic.LoadUint32(&dp.LogHead)
+	tail := dp.LogTail
+	if head == tail {
+		return
+	}
+	arenaTail := dp.ArenaTail
+	for ; tail != head; tail++ {
+		enc := &dp.Log[tail%dara.MAXLOGENTRIES]
+		e := decodeEvent(dp, enc)
+		arenaTail = enc.ArenaEnd
+		switch e.Type {
+		case dara.TIMER_EVENT:
+			id, op, ok := e.TimerOp()
+			switch {
+			case !ok:
+			case op == dara.TIMER_ARMED:
+				p.timers[id] = e.SyscallInfo.Args[1].Integer64
+			case op == dara.TIMER_CANCELLED, op == dara.TIMER_FIRED:
+				delete(p.timers, id)
+			}
+		case dara.MESSAGE_EVENT:
+			if m, ok := e.HeldMessage(); ok {
+				p.track(m)
+			}
+		case dara.CRASH_EVENT:
+			if !containsPid(s.res.Crashed, p.pid) {
+				s.res.Crashed = append(s.res.Crashed, p.pid)
+			}
+		}
+		sched.LogEvents = append(sched.LogEvents, e)
+	}
+	atomic.StoreUint32(&dp.ArenaTail, arenaTail)
+	atomic.StoreUint32(&dp.LogTail, tail)
+	futexWake(&dp.LogTail)
+}
+
+// track follows what the MESSAGE_EVENT m did to the outbox of p.
+func (p *proc) track(m dara.HeldMessage) {
+	if m.Op == dara.MESSAGE_HELD {
+		p.outbox = append(p.outbox, m)
+		return
+	}
+	for i := range p.outbox {
+		if p.outbox[i].ID != m.ID {
+			continue
+		}
+		if m.Op == dara.MESSAGE_DELIVERED && m.Len < p.outbox[i].Len {
+			p.outbox[i].Len -= m.Len
+			return
+		}
+		p.outbox = append(p.outbox[:i], p.outbox[i+1:]...)
+		return
+	}
+}
+
+func containsPid(pids []int, pid int) bool {
+	for _, p := range pids {
+		if p == pid {
+			return true
+		}
+	}
+	return false
+}
+
+// record lets the runtimes choose their own goroutines, one process at
+// a time in round robin order. Virtual time keeps up with the time that
+// has passed since the start of the run, the timers of a process fire
+// once it has caught up with them. The messages a process holds are
+// delivered as soon as it has had its turn. A process polls its
+// virtual network whenever it gets a turn.
+func (s *scheduler) record() error {
+	next := 0
+	for s.decisions < s.cfg.MaxEvents {
+		var p *proc
+		for i := 0; i < len(s.procs); i++ {
+			q := s.procs[(next+i)%len(s.procs)]
+			if q.held && !q.done {
+				p = q
+				next = (next + i + 1) % len(s.procs)
+				break
+			}
+		}
+		if p == nil {
+			if blocked, err := s.waitBlocked(); err != nil || !blocked {
+				return err
+			}
+			continue
+		}
+		s.advance(p, int64(time.Since(s.started)))
+		r, err := s.issue(p, dara.CMD_RECORD_FREELY, 0, nil)
+		if err != nil {
+			return err
+		}
+		if err := s.handle(p, r); err != nil {
+			return err
+		}
+		if err := s.deliverAll(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverAll delivers the messages p holds in the order they were
+// written, until they are all delivered or a connection takes no more.
+func (s *scheduler) deliverAll(p *proc) error {
+	for p.held && !p.done && len(p.outbox) > 0 && s.decisions < s.cfg.MaxEvents {
+		m := p.outbox[0]
+		if err := s.deliver(p, m.ID); err != nil {
+			return err
+		}
+		if len(p.outbox) > 0 && p.outbox[0].ID == m.ID && p.outbox[0].Len == m.Len {
+			return nil
+		}
+	}
+	return nil
+}
+
+// deliver asks p to deliver the message id it holds.
+func (s *scheduler) deliver(p *proc, id int64) error {
+	r, err := s.issue(p, dara.CMD_DELIVER_MESSAGE, id, nil)
+	if err != nil {
+		return err
+	}
+	return s.handle(p, r)
+}
+
+// setLink sets what happens to the data the process local exchanges
+// with the process remote to action, and logs it with a NET_EVENT.
+func (s *scheduler) setLink(local, remote, action int) error {
+	if local < 1 || local > len(s.procs) || remote < 1 || remote > len(s.procs) || remote > dara.MAXNETPEERS {
+		return fmt.Errorf("dara/sched: no link from process %d to process %d", local, remote)
+	}
+	// Whatever the processes logged so far happened before the link
+	// changed.
+	for _, p := range s.procs {
+		s.drainEvents(p)
+	}
+	atomic.StoreUint32(&s.procs[local-1].dp.Links[remote-1], uint32(action))
+	s.res.Schedule.LogEvents = append(s.res.Schedule.LogEvents, dara.NetEvent(local, remote, action))
+	return nil
+}
+
+// partitionNet installs pt if it is not nil, or heals the partition
+// installed before. It sets the links across the partition in both
+// directions.
+func (s *scheduler) partitionNet(pt *Partition) error {
+	cut := pt
+	if cut == nil {
+		cut = s.partition
+	}
+	action := dara.NET_DELIVER
+	if pt != nil {
+		action = pt.Action
+		if action == dara.NET_DELIVER {
+			action = dara.NET_DROP
+		}
+	}
+	for _, p := range s.procs {
+		if containsPid(cut.Side, p.pid) {
+			continue
+		}
+		for _, pid := range cut.Side {
+			if err := s.setLink(pid, p.pid, action); err != nil {
+				return err
+			}
+			if err := s.setLink(p.pid, pid, action); err != nil {
+				return err
+			}
+		}
+	}
+	s.partition = pt
+	return nil
+}
+
+// replay runs the goroutines named by the scheduling events of
+// cfg.Schedule and fires the timers that fired in the recording, in
+// order and at the virtual time they were recorded at, and takes the
+// recorded case of every select the runtimes ask about, and delivers
+// the held messages when they were delivered in the recording. The first
+// scheduling event of each process is logged by main itself while it
+// runs up to REPLY_READY, it has been replayed by start already, and so
+// have the selects main ran into on the way.
+func (s *scheduler) replay() error {
+	started := make([]bool, len(s.procs))
+	ran := make([]bool, len(s.procs))
+	for i, e := range s.cfg.Schedule.LogEvents {
+		if remote, action, ok := e.NetLink(); ok {
+			if err := s.setLink(e.P, remote, action); err != nil {
+				return fmt.Errorf("dara/sched: event %d: %v", i, err)
+			}
+			continue
+		}
+		timer, op, _ := e.TimerOp()
+		fired := op == dara.TIMER_FIRED
+		m, _ := e.HeldMessage()
+		delivered := e.Type == dara.MESSAGE_EVENT && m.Op == dara.MESSAGE_DELIVERED
+		if e.Type != dara.SCHED_EVENT && e.Type != dara.SELECT_EVENT && !fired && !delivered {
+			continue
+		}
+		if e.P < 1 || e.P > len(s.procs) {
+			return fmt.Errorf("dara/sched: event %d schedules unknown process %d", i, e.P)
+		}
+		if !started[e.P-1] {
+			started[e.P-1] = true
+			continue
+		}
+		p := s.procs[e.P-1]
+		// Keep to the recorded order, a process blocked on the network
+		// has to finish its last event before it runs the next.
+		for p.blocked {
+			if _, err := s.waitBlocked(); err != nil {
+				return err
+			}
+		}
+		if p.done {
+			return fmt.Errorf("dara/sched: event %d schedules process %d which is done", i, e.P)
+		}
+		if s.decisions >= s.cfg.MaxEvents {
+			return nil
+		}
+		s.advance(p, e.Now)
+		var r dara.Reply
+		var err error
+		switch {
+		case e.Type == dara.SELECT_EVENT && p.selecting:
+			r, err = s.issue(p, dara.CMD_CHOOSE_SELECT, int64(e.Select.Chosen), nil)
+		case e.Type == dara.SELECT_EVENT && !ran[e.P-1]:
+			continue
+		case e.Type == dara.SELECT_EVENT:
+			return fmt.Errorf("dara/sched: event %d: process %d did not reach the recorded select", i, e.P)
+		case p.selecting:
+			return fmt.Errorf("dara/sched: event %d: process %d waits on a select that was not recorded", i, e.P)
+		case fired:
+			r, err = s.issue(p, dara.CMD_FIRE_TIMER, timer, nil)
+		case delivered:
+			r, err = s.issue(p, dara.CMD_DELIVER_MESSAGE, m.ID, nil)
+		default:
+			g := e.G
+			ran[e.P-1] = true
+			r, err = s.issue(p, dara.CMD_RUN_GOROUTINE, 0, &g)
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.handle(p, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// explore picks a process at random, then one of its runnable
+// goroutines, pending timers or held messages at random. Only the
+// oldest message held on a connection may be delivered. A process waiting on a
+// select gets one of the ready cases at random instead. Polling the
+// virtual network of a process is a choice too once another process
+// has changed it, a process left with nothing but goroutines waiting
+// on the virtual network sits out until then. Virtual time only moves
+// when a timer fires, to the time the timer was due at.
+// With Partitions configured, installing one of them or healing the
+// one installed is a choice as good as any process, and the partition
+// is healed when every process waits on the network.
+func (s *scheduler) explore() error {
+	for s.decisions < s.cfg.MaxEvents {
+		var ready []*proc
+		for _, p := range s.procs {
+			if !p.held || p.done {
+				continue
+			}
+			if !p.selecting && atomic.LoadUint32(&p.dp.NetWaiters) > 0 && !p.netChanged() &&
+				len(p.runnable())+len(p.timers)+len(p.outbox) == 0 {
+				continue
+			}
+			ready = append(ready, p)
+		}
+		if len(ready) == 0 && s.partition != nil {
+			// Everybody waits on the network, which may never deliver
+			// anything before it heals.
+			if err := s.partitionNet(nil); err != nil {
+				return err
+			}
+			s.decisions++
+			continue
+		}
+		if len(ready) == 0 {
+			if blocked, err := s.waitBlocked(); err != nil || !blocked {
+				return err
+			}
+			continue
+		}
+		if len(s.cfg.Partitions) > 0 && s.rng.Intn(len(ready)+1) == 0 {
+			var pt *Partition
+			if s.partition == nil {
+				pt = &s.cfg.Partitions[s.rng.Intn(len(s.cfg.Partitions))]
+			}
+			if err := s.partitionNet(pt); err != nil {
+				return err
+			}
+			s.decisions++
+			continue
+		}
+		p := ready[s.rng.Intn(len(ready))]
+		if p.selecting {
+			sel := &p.dp.Select
+			n := sel.NumReady
+			if n < 1 || n > len(sel.Ready) {
+				return fmt.Errorf("dara/sched: process %d asks about a select with %d ready cases", p.pid, n)
+			}
+			c := sel.Ready[s.rng.Intn(n)]
+			r, err := s.issue(p, dara.CMD_CHOOSE_SELECT, int64(c.Index), nil)
+			if err != nil {
+				return err
+			}
+			if err := s.handle(p, r); err != nil {
+				return err
+			}
+			continue
+		}
+		gs := p.runnable()
+		var timers []int64
+		for id := range p.timers {
+			timers = append(timers, id)
+		}
+		sort.Slice(timers, func(i, j int) bool { return timers[i] < timers[j] })
+		msgs := p.pending()
+		polls := 0
+		if p.netChanged() {
+			polls = 1
+		}
+		if len(gs)+len(timers)+len(msgs)+polls == 0 {
+			return fmt.Errorf("dara/sched: process %d has nothing to run", p.pid)
+		}
+		var r dara.Reply
+		var err error
+		switch k := s.rng.Intn(len(gs) + len(timers) + len(msgs) + polls); {
+		case k < len(gs):
+			r, err = s.issue(p, dara.CMD_RUN_GOROUTINE, 0, &gs[k])
+		case k >= len(gs)+len(timers)+len(msgs):
+			r, err = s.issue(p, dara.CMD_POLL_NET, 0, nil)
+		case k >= len(gs)+len(timers):
+			r, err = s.issue(p, dara.CMD_DELIVER_MESSAGE, msgs[k-len(gs)-len(timers)].ID, nil)
+		default:
+			id := timers[k-len(gs)]
+			s.advance(p, p.timers[id])
+			delete(p.timers, id)
+			r, err = s.issue(p, dara.CMD_FIRE_TIMER, id, nil)
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.handle(p, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shutdown stops every process that is still running and collects the
+// exit status of all of them.
+func (s *scheduler) shutdown() {
+	for _, p := range s.procs {
+		if p.cmd == nil {
+			continue
+		}
+		if p.held && !p.done && p.alive() {
+			// The runtime exits as soon as it sees the command, there is
+			// no reply to wait for.
+			dp := p.dp
+			dp.Cmd = dara.CMD_SHUTDOWN
+			p.seq++
+			dp.CmdSeq = p.seq
+			atomic.AddUint32(&dp.CmdSignal, 1)
+			s.release(p)
+			futexWake(&dp.CmdSignal)
+		} else if !p.done {
+			p.cmd.Process.Kill()
+		}
+	}
+	timeout := time.NewTimer(exitGrace)
+	defer timeout.Stop()
+	for _, p := range s.procs {
+		if p.cmd == nil {
+			continue
+		}
+		select {
+		case <-p.exited:
+		case <-timeout.C:
+			for _, q := range s.procs {
+				if q.cmd != nil {
+					q.cmd.Process.Kill()
+				}
+			}
+			<-p.exited
+		}
+		if p.err != nil {
+			s.res.ExitErrors[p.pid] = p.err
+		}
+	}
+}
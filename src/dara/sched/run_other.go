@@ -0,0 +1,10 @@
+// +build !linux
+
+package sched
+
+import "errors"
+
+// Run is only implemented on Linux.
+func Run(cfg Config) (*Result, error) {
+	return nil, errors.New("dara/sched: the global scheduler only runs on linux")
+}
@@ -0,0 +1,157 @@
+// Package sched is a reference implementation of the Dara global
+// scheduler.
+//
+// It sets up the shared memory that instrumented runtimes map on
+// dara.DARAFD, launches the processes of a cluster and drives them
+// through the command protocol described in dara/protocol.go. Three
+// modes are supported: Record lets every runtime choose its own
+// goroutines and records what they chose, Replay forces a recorded
+// schedule onto the processes and Explore picks runnable goroutines,
+// timers, held messages and network partitions at random.
+//
+// The package only works on Linux.
+package sched
+
+import (
+	"dara"
+	"io"
+	"time"
+)
+
+// Mode selects what a Scheduler does with the processes it controls.
+type Mode int
+
+const (
+	Record Mode = iota
+	Replay
+	Explore
+)
+
+var modeStrings = [...]string{
+	Record:  "record",
+	Replay:  "replay",
+	Explore: "explore",
+}
+
+func (m Mode) String() string {
+	if m < 0 || int(m) >= len(modeStrings) {
+		return "unknown"
+	}
+	return modeStrings[m]
+}
+
+// DefaultMaxEvents bounds the number of scheduling decisions of a run
+// when Config.MaxEvents is not set.
+const DefaultMaxEvents = 10000
+
+// DefaultTimeout bounds how long the scheduler waits for a process when
+// Config.Timeout is not set.
+const DefaultTimeout = 30 * time.Second
+
+// How often a waiting scheduler checks whether a process has died.
+const pollInterval = 10 * time.Millisecond
+
+// ProcConfig describes one process of a cluster.
+type ProcConfig struct {
+	// Path is the instrumented binary to run.
+	Path string
+	// Args are passed to the binary.
+	Args []string
+	// Env is added to the scheduler's environment. The Dara variables
+	// are set by the scheduler.
+	Env []string
+	// Dir is the working directory, the scheduler's if empty.
+	Dir string
+	// Stdout and Stderr receive the output of the process, it is
+	// discarded if they are nil.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Config describes a single run of a cluster.
+type Config struct {
+	// SharedMemPath is the file backing shared memory. It is created if
+	// needed and removed at the end of the run. A file in /dev/shm is
+	// used if it is empty.
+	SharedMemPath string
+	// Procs are the processes of the cluster, the first one gets
+	// DARAPID 1.
+	Procs []ProcConfig
+	Mode  Mode
+	// Schedule is the schedule to replay in Replay mode.
+	Schedule *dara.Schedule
+	// MaxEvents bounds the number of scheduling decisions,
+	// DefaultMaxEvents if zero.
+	MaxEvents int
+	// Timeout bounds how long the scheduler waits for a process to
+	// answer a command, DefaultTimeout if zero. The run fails with an
+	// error once a process has not answered in time.
+	Timeout time.Duration
+	// Seed seeds the random choices made in Explore mode. In Record
+	// and Explore mode each process also gets the DARA_SEED
+	// dara.ProcSeed derives from it, which seeds the hashes of maps,
+	// select and math/rand. A replay seeds every process with the
+	// DARA_SEED recorded in its INIT_EVENT instead.
+	Seed int64
+	// SyscallPoints are the classes of syscalls, dara.SYSPOINT_FILE_WRITE
+	// and so on or'ed together, which are scheduling points in Record
+	// and Explore mode. A replay uses the classes recorded in the
+	// INIT_EVENT of each process instead.
+	SyscallPoints int
+	// Faults are the faults to inject into the syscalls of each
+	// process in Record and Explore mode, by DARAPID. A process takes
+	// at most dara.MAXFAULTS of them. A replay injects the faults
+	// logged in the schedule instead.
+	Faults map[int][]dara.Fault
+	// Partitions are the partitions Explore mode installs and heals
+	// at random, one at a time, as it goes. A replay sets the links
+	// between the processes the way the NET_EVENTs of the schedule do
+	// instead.
+	Partitions []Partition
+	// HoldMessages makes the runtimes hold what they write to the
+	// connections between the processes in Record and Explore mode,
+	// for the scheduler to deliver as one more of its choices. A
+	// replay holds the messages if the INIT_EVENT of a process says it
+	// was recorded holding them, and delivers them when they were
+	// delivered in the recording.
+	HoldMessages bool
+	// VirtualNet makes the runtimes carry the TCP connections of the
+	// processes over the virtual network in shared memory instead of
+	// the network of the machine, see dara/vnet.go. Explore mode polls
+	// the virtual network of a process as one more of its choices
+	// whenever another process has changed it. A replay uses the
+	// virtual network if the INIT_EVENT of a process says it was
+	// recorded on it.
+	VirtualNet bool
+	// StartTime is the wall clock time the virtual clocks of the
+	// processes start at, dara.VIRTUALEPOCH if zero. A replay must use
+	// the StartTime of the recording for time.Now to return the same
+	// times.
+	StartTime time.Time
+	// LogLevel is passed to the runtimes as DARA_LOG_LEVEL, WARN if
+	// empty.
+	LogLevel string
+}
+
+// Partition splits a cluster in two.
+type Partition struct {
+	// Side lists the DARAPIDs on one side of the partition, the other
+	// processes are on the other side.
+	Side []int
+	// Action is what happens to the data sent across the partition,
+	// one of the dara.NET_ actions. dara.NET_DELIVER stands for
+	// dara.NET_DROP.
+	Action int
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	// Schedule holds every event the runtimes logged, in the order the
+	// scheduler collected them.
+	Schedule dara.Schedule
+	// Crashed lists the DARAPIDs of the processes that crashed.
+	Crashed []int
+	// ExitErrors holds the error returned by waiting on each process
+	// that did not exit cleanly, by DARAPID.
+	ExitErrors map[int]error
+}
@@ -0,0 +1,1076 @@
+// +build linux
+
+package sched
+
+import (
+	"bytes"
+	"dara"
+	"errors"
+	"fmt"
+	"internal/testenv"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+var pingProgram = testProgram{src: `package main
+
+import "fmt"
+
+func main() {
+	c := make(chan int)
+	for i := 0; i < 3; i++ {
+		go func(i int) { c <- i }(i)
+	}
+	sum := 0
+	for i := 0; i < 3; i++ {
+		sum += <-c
+	}
+	fmt.Println("sum", sum)
+}
+`, check: wantLine("sum 3")}
+
+// floodEvents is how many events floodProgram logs, half as many again
+// as fit in the event ring.
+const floodEvents = dara.MAXLOGENTRIES * 3 / 2
+
+// floodProgram logs more events between two scheduling points than fit
+// in the event ring.
+var floodProgram = testProgram{src: fmt.Sprintf(`package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func main() {
+	for i := 0; i < %d; i++ {
+		runtime.DaraLog("flood", "i", i)
+	}
+	fmt.Println("logged", %d)
+}
+`, floodEvents, floodEvents), check: wantLine(fmt.Sprint("logged ", floodEvents))}
+
+// longProgram logs strings much longer than the fixed size buffers
+// events used to have, more of them than fit in the arena at once.
+var longProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	for i := 0; i < 300; i++ {
+		runtime.DaraLog("long", "i,s", i, strings.Repeat(strconv.Itoa(i%10), 20000))
+	}
+	fmt.Println("logged 300")
+}
+`, check: wantLine("logged 300")}
+
+// selectProgram runs selects with two ready cases on a goroutine of its
+// own, so that they are reached once the scheduler is in control.
+var selectProgram = testProgram{src: `package main
+
+import "fmt"
+
+func main() {
+	a := make(chan int, 3)
+	b := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		a <- 1
+		b <- 2
+	}
+	done := make(chan []int)
+	go func() {
+		var took []int
+		for i := 0; i < 3; i++ {
+			select {
+			case v := <-a:
+				took = append(took, v)
+			case v := <-b:
+				took = append(took, v)
+			}
+		}
+		done <- took
+	}()
+	fmt.Println("took", <-done)
+}
+`, check: checkSelect}
+
+// checkSelect checks that selectProgram took three values, each of them
+// from one of its channels.
+func checkSelect(pid int, out string) error {
+	var took [3]int
+	for _, line := range strings.Split(out, "\n") {
+		if n, _ := fmt.Sscanf(line, "took [%d %d %d]", &took[0], &took[1], &took[2]); n == 3 {
+			for _, v := range took {
+				if v != 1 && v != 2 {
+					return fmt.Errorf("took %v, want only 1 and 2", took)
+				}
+			}
+			return nil
+		}
+	}
+	return errors.New("took nothing")
+}
+
+// sleepProgram sleeps for an hour of virtual time, which passes as
+// soon as the scheduler fires the timer of the sleep.
+var sleepProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func main() {
+	start := time.Now()
+	done := make(chan bool)
+	go func() {
+		time.Sleep(time.Hour)
+		done <- true
+	}()
+	<-done
+	fmt.Println("slept", int64(time.Since(start)), "from", start.UTC().Year())
+}
+`, check: checkSleep}
+
+// checkSleep checks that sleepProgram slept for an hour of virtual time,
+// which starts at dara.VIRTUALEPOCH.
+func checkSleep(pid int, out string) error {
+	for _, line := range strings.Split(out, "\n") {
+		var d time.Duration
+		var year int
+		if n, _ := fmt.Sscanf(line, "slept %d from %d", &d, &year); n != 2 {
+			continue
+		}
+		if d < time.Hour || year != time.Unix(0, dara.VIRTUALEPOCH).UTC().Year() {
+			return fmt.Errorf("slept %v from %d", d, year)
+		}
+		return nil
+	}
+	return errors.New("did not sleep")
+}
+
+// tickerProgram waits for a ticker to tick three times, and stops and
+// resets timers on the way.
+var tickerProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func main() {
+	stopped := time.NewTimer(time.Hour)
+	if !stopped.Stop() {
+		fmt.Println("timer fired before it was stopped")
+		return
+	}
+	tick := time.NewTicker(time.Minute)
+	for i := 0; i < 3; i++ {
+		<-tick.C
+	}
+	tick.Stop()
+	r := time.NewTimer(time.Hour)
+	r.Reset(time.Second)
+	<-r.C
+	fmt.Println("ticked 3 times")
+}
+`, check: wantLine("ticked 3 times")}
+
+// timerProgram sleeps and waits on a timer channel while another
+// goroutine sleeps too.
+var timerProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func main() {
+	start := time.Now()
+	done := make(chan bool)
+	go func() {
+		time.Sleep(15 * time.Millisecond)
+		done <- true
+	}()
+	for i := 0; i < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	<-time.After(10 * time.Millisecond)
+	<-done
+	fmt.Println("waited at least", time.Since(start) >= 40*time.Millisecond)
+}
+`, check: wantLine("waited at least true")}
+
+// mutexProgram has two goroutines take turns on a mutex.
+var mutexProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+func main() {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	locked := 0
+	for i := 1; i <= 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				mu.Lock()
+				locked++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Println("locked", locked, "times")
+}
+`, check: wantLine("locked 10 times")}
+
+// faultProgram checks that its first write failed with ENOSPC and its
+// second one was cut down to 2 bytes.
+var faultProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+)
+
+func main() {
+	f, err := ioutil.TempFile("", "dara-fault")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.Write([]byte("hello")); err == nil || err.(*os.PathError).Err != syscall.ENOSPC {
+		fmt.Println("first write:", err)
+		return
+	}
+	n, err := f.Write([]byte("hello"))
+	if err != io.ErrShortWrite {
+		fmt.Println("second write:", err)
+		return
+	}
+	fmt.Println("wrote", n, "bytes of 5")
+}
+`, check: wantLine("wrote 2 bytes of 5")}
+
+// seedProgram checks that math/rand was seeded with the DARA_SEED of
+// the process, which it also prints.
+var seedProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+)
+
+func main() {
+	seed, err := strconv.ParseInt(os.Getenv("DARA_SEED"), 10, 64)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("seed", seed, "rand", rand.Int63())
+}
+`, check: checkSeed}
+
+// checkSeed checks that the first number seedProgram got from math/rand
+// is the first one of a source seeded with its DARA_SEED.
+func checkSeed(pid int, out string) error {
+	for _, line := range strings.Split(out, "\n") {
+		var seed, got int64
+		if n, _ := fmt.Sscanf(line, "seed %d rand %d", &seed, &got); n != 2 {
+			continue
+		}
+		if want := rand.New(rand.NewSource(seed)).Int63(); got != want {
+			return fmt.Errorf("got %d from math/rand, want %d with seed %d", got, want, seed)
+		}
+		return nil
+	}
+	return errors.New("printed no seed")
+}
+
+// gcProgram allocates enough garbage for the runtime to collect it a
+// few times while goroutines are handing values to each other.
+var gcProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var sink []byte
+
+func main() {
+	c := make(chan int)
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			for j := 0; j < 1000; j++ {
+				sink = make([]byte, 64<<10)
+			}
+			c <- i
+		}(i)
+	}
+	sum := 0
+	for i := 0; i < 3; i++ {
+		sum += <-c
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	fmt.Println("sum", sum, "collected", stats.NumGC > 0)
+}
+`, check: wantLine("sum 3 collected true")}
+
+// pingPongProgram sends a ping over TCP from process 2 to process 1,
+// which answers with a pong. Process 1 leaves the address it listens on
+// in a file next to the binary for process 2 to find.
+var pingPongProgram = testProgram{src: `package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func exchange(c net.Conn, send, want string) {
+	defer c.Close()
+	if _, err := c.Write([]byte(send)); err != nil {
+		fmt.Println(err)
+		return
+	}
+	b := make([]byte, len(want))
+	if _, err := io.ReadFull(c, b); err != nil || string(b) != want {
+		fmt.Printf("read %q: %v\n", b, err)
+		return
+	}
+	fmt.Println("sent", send)
+}
+
+func main() {
+	file := filepath.Join(filepath.Dir(os.Args[0]), "addr")
+	if os.Getenv("DARAPID") == "1" {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer ln.Close()
+		if err := ioutil.WriteFile(file+".tmp", []byte(ln.Addr().String()), 0644); err != nil {
+			fmt.Println(err)
+			return
+		}
+		os.Rename(file+".tmp", file)
+		c, err := ln.Accept()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		b := make([]byte, 4)
+		if _, err := io.ReadFull(c, b); err != nil || string(b) != "ping" {
+			fmt.Printf("read %q: %v\n", b, err)
+			return
+		}
+		exchange(c, "pong", "")
+		return
+	}
+	addr, err := ioutil.ReadFile(file)
+	for err != nil {
+		time.Sleep(time.Millisecond)
+		addr, err = ioutil.ReadFile(file)
+	}
+	c, err := net.Dial("tcp", string(addr))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	exchange(c, "ping", "pong")
+}
+`, check: checkPingPong}
+
+// checkPingPong checks that process 1 of pingPongProgram answered the
+// ping of process 2.
+func checkPingPong(pid int, out string) error {
+	if pid == 1 {
+		return wantLine("sent pong")(pid, out)
+	}
+	return wantLine("sent ping")(pid, out)
+}
+
+// testProgram is a program the tests run as a cluster.
+type testProgram struct {
+	src string
+	// check checks the output of process pid of the program.
+	check func(pid int, out string) error
+}
+
+// wantLine returns a check that the program printed line.
+func wantLine(line string) func(pid int, out string) error {
+	return func(pid int, out string) error {
+		for _, l := range strings.Split(out, "\n") {
+			if l == line {
+				return nil
+			}
+		}
+		return fmt.Errorf("did not print %q", line)
+	}
+}
+
+// buildProgram builds src into a binary in dir.
+func buildProgram(t *testing.T, dir, src string) string {
+	testenv.MustHaveGoBuild(t)
+	srcPath := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(srcPath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "prog")
+	out, err := exec.Command(testenv.GoToolPath(t), "build", "-o", bin, srcPath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("building test program: %v\n%s", err, out)
+	}
+	return bin
+}
+
+func schedEvents(s *dara.Schedule) []dara.Event {
+	return eventsOfType(s, dara.SCHED_EVENT)
+}
+
+func clockTick(c []dara.ClockEntry, p, gid int) uint64 {
+	for _, e := range c {
+		if e.P == p && e.Gid == gid {
+			return e.Tick
+		}
+	}
+	return 0
+}
+
+func eventsOfType(s *dara.Schedule, typ int) []dara.Event {
+	var evs []dara.Event
+	for _, e := range s.LogEvents {
+		if e.Type == typ {
+			evs = append(evs, e)
+		}
+	}
+	return evs
+}
+
+// checkSameSchedule checks that the replay rep made the scheduling
+// decisions of the recording rec, in the same order.
+func checkSameSchedule(t *testing.T, rep, rec *dara.Schedule) {
+	got, want := schedEvents(rep), schedEvents(rec)
+	if len(got) != len(want) {
+		t.Errorf("replay logged %d scheduling events, recorded %d", len(got), len(want))
+		return
+	}
+	for i := range want {
+		if got[i].P != want[i].P || got[i].G.LogicalID != want[i].G.LogicalID {
+			t.Errorf("scheduling event %d: replayed P%d G%d (%#x), recorded P%d G%d (%#x)", i, got[i].P, got[i].G.Gid, got[i].G.LogicalID, want[i].P, want[i].G.Gid, want[i].G.LogicalID)
+		}
+	}
+}
+
+// checkSameEvents checks that every process logged the same events on
+// replay as in the recording rec, in the same goroutines, however the
+// processes were interleaved.
+func checkSameEvents(t *testing.T, rep, rec *dara.Schedule) {
+	byProc := func(s *dara.Schedule) map[int][]dara.Event {
+		m := make(map[int][]dara.Event)
+		for _, e := range s.LogEvents {
+			m[e.P] = append(m[e.P], e)
+		}
+		return m
+	}
+	got, want := byProc(rep), byProc(rec)
+	for pid := 1; pid <= len(want); pid++ {
+		g, w := got[pid], want[pid]
+		for i := 0; i < len(g) && i < len(w); i++ {
+			if g[i].Type != w[i].Type || g[i].G.LogicalID != w[i].G.LogicalID || g[i].SyscallInfo.SyscallNum != w[i].SyscallInfo.SyscallNum {
+				t.Errorf("P%d event %d: replayed type %d in G%d, recorded type %d in G%d", pid, i, g[i].Type, g[i].G.Gid, w[i].Type, w[i].G.Gid)
+				break
+			}
+		}
+		if len(g) != len(w) {
+			t.Errorf("P%d: replay logged %d events, recorded %d", pid, len(g), len(w))
+		}
+	}
+}
+
+// testTimeout is the Config.Timeout of the test runs, a runtime which
+// stops answering fails the test instead of hanging it.
+const testTimeout = 20 * time.Second
+
+// clusterTest runs a test program as a cluster of two processes.
+type clusterTest struct {
+	t    *testing.T
+	prog testProgram
+	// dir holds the binary and the shared memory of the runs.
+	dir string
+	bin string
+}
+
+// newClusterTest builds prog for t in a new directory, which close
+// removes. It skips t in short mode.
+func newClusterTest(t *testing.T, prog testProgram) *clusterTest {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	dir, err := ioutil.TempDir("", "dara-sched-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clusterTest{t: t, prog: prog, dir: dir}
+	built := false
+	defer func() {
+		if !built {
+			c.close()
+		}
+	}()
+	c.bin = buildProgram(t, dir, prog.src)
+	built = true
+	return c
+}
+
+func (c *clusterTest) close() {
+	os.RemoveAll(c.dir)
+}
+
+// path returns the path of the file name in the directory of c.
+func (c *clusterTest) path(name string) string {
+	return filepath.Join(c.dir, name)
+}
+
+// run runs two copies of the program under cfg and checks that both of
+// them ran to completion and did what the program checks.
+func (c *clusterTest) run(cfg Config) *Result {
+	t := c.t
+	var outs []*bytes.Buffer
+	for i := 0; i < 2; i++ {
+		out := new(bytes.Buffer)
+		outs = append(outs, out)
+		cfg.Procs = append(cfg.Procs, ProcConfig{Path: c.bin, Stdout: out, Stderr: out})
+	}
+	cfg.SharedMemPath = c.path("shm")
+	if cfg.Timeout == 0 {
+		cfg.Timeout = testTimeout
+	}
+	res, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("%v: %v", cfg.Mode, err)
+	}
+	for pid, err := range res.ExitErrors {
+		t.Errorf("%v: process %d: %v\n%s", cfg.Mode, pid, err, outs[pid-1])
+	}
+	for i, out := range outs {
+		if err := c.prog.check(i+1, out.String()); err != nil {
+			t.Errorf("%v: process %d: %v, it printed %q", cfg.Mode, i+1, err, out)
+		}
+	}
+	return res
+}
+
+func TestRecordReplay(t *testing.T) {
+	c := newClusterTest(t, pingProgram)
+	defer c.close()
+
+	rec := c.run(Config{Mode: Record})
+	for pid := 1; pid <= 2; pid++ {
+		var init, end bool
+		for _, e := range rec.Schedule.LogEvents {
+			if e.P != pid {
+				continue
+			}
+			init = init || e.Type == dara.INIT_EVENT
+			end = end || e.Type == dara.END_EVENT
+		}
+		if !init || !end {
+			t.Errorf("record: process %d logged init %v, end %v", pid, init, end)
+		}
+	}
+	want := schedEvents(&rec.Schedule)
+	if len(want) == 0 {
+		t.Fatal("record: no scheduling events")
+	}
+	// Every value sent on the unbuffered channel is handed to main.
+	sends := eventsOfType(&rec.Schedule, dara.SEND_EVENT)
+	recvs := eventsOfType(&rec.Schedule, dara.REC_EVENT)
+	if len(sends) < 6 || len(recvs) < 6 {
+		t.Fatalf("record: logged %d sends and %d receives, want at least 6 of each", len(sends), len(recvs))
+	}
+	for _, e := range recvs {
+		if e.Msg.Cap != 0 || e.Msg.Closed || e.G.Gid != 1 {
+			continue
+		}
+		if e.Msg.Chan.Pc == 0 || e.Msg.Partner.Gid == 0 || e.Msg.Partner.Gid == 1 {
+			t.Errorf("record: P%d main received on %+v from G%d, want a worker", e.P, e.Msg.Chan, e.Msg.Partner.Gid)
+		}
+		if e.Msg.Partner.Gid != 0 && clockTick(e.Clock, e.P, e.Msg.Partner.Gid) == 0 {
+			t.Errorf("record: P%d main received from G%d, but its clock %v does not know about it", e.P, e.Msg.Partner.Gid, e.Clock)
+		}
+	}
+
+	rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+	checkSameSchedule(t, &rep.Schedule, &rec.Schedule)
+}
+
+func TestExplore(t *testing.T) {
+	c := newClusterTest(t, pingProgram)
+	defer c.close()
+
+	for seed := int64(1); seed <= 3; seed++ {
+		res := c.run(Config{Mode: Explore, Seed: seed})
+		if len(res.Crashed) != 0 {
+			t.Errorf("seed %d: processes %v crashed", seed, res.Crashed)
+		}
+	}
+}
+
+func TestEventRingFull(t *testing.T) {
+	c := newClusterTest(t, floodProgram)
+	defer c.close()
+
+	res := c.run(Config{Mode: Record})
+	next := []int{0, 0}
+	for _, e := range res.Schedule.LogEvents {
+		if e.Type != dara.LOG_EVENT || e.LE.LogID != "flood" {
+			continue
+		}
+		if len(e.LE.Vars) != 1 || e.LE.Vars[0].Value != next[e.P-1] {
+			t.Fatalf("process %d logged %+v, want i=%d", e.P, e.LE.Vars, next[e.P-1])
+		}
+		next[e.P-1]++
+	}
+	for i, n := range next {
+		if n != floodEvents {
+			t.Errorf("process %d: collected %d events, want %d", i+1, n, floodEvents)
+		}
+	}
+}
+
+func TestLongValues(t *testing.T) {
+	c := newClusterTest(t, longProgram)
+	defer c.close()
+
+	res := c.run(Config{Mode: Record})
+	n := 0
+	for _, e := range res.Schedule.LogEvents {
+		if e.Type != dara.LOG_EVENT || e.LE.LogID != "long" {
+			continue
+		}
+		n++
+		if len(e.LE.Vars) != 2 {
+			t.Fatalf("process %d logged %d variables, want 2", e.P, len(e.LE.Vars))
+		}
+		i, _ := e.LE.Vars[0].Value.(int)
+		want := strings.Repeat(strconv.Itoa(i%10), 20000)
+		if v := e.LE.Vars[1]; v.VarName != "s" || v.Value != want {
+			t.Fatalf("process %d logged %s of %d bytes for i=%d, want %d bytes", e.P, v.VarName, len(fmt.Sprint(v.Value)), i, len(want))
+		}
+	}
+	if n != 600 {
+		t.Errorf("collected %d events, want 600", n)
+	}
+}
+
+func TestSelectReplay(t *testing.T) {
+	c := newClusterTest(t, selectProgram)
+	defer c.close()
+
+	rec := c.run(Config{Mode: Record})
+	want := eventsOfType(&rec.Schedule, dara.SELECT_EVENT)
+	if len(want) != 6 {
+		t.Fatalf("record: logged %d select events, want 6", len(want))
+	}
+	for i, e := range want {
+		if len(e.Select.Ready) != 2 || e.Select.Ready[0].Index != 0 || e.Select.Ready[1].Index != 1 {
+			t.Errorf("record: select event %d lists ready cases %+v, want 0 and 1", i, e.Select.Ready)
+		}
+	}
+	// Take the other case in every select, the replay must follow.
+	for i := range rec.Schedule.LogEvents {
+		if e := &rec.Schedule.LogEvents[i]; e.Type == dara.SELECT_EVENT {
+			e.Select.Chosen = 1 - e.Select.Chosen
+		}
+	}
+	want = eventsOfType(&rec.Schedule, dara.SELECT_EVENT)
+
+	for _, mode := range []Mode{Replay, Explore} {
+		res := c.run(Config{Mode: mode, Schedule: &rec.Schedule, Seed: 1})
+		got := eventsOfType(&res.Schedule, dara.SELECT_EVENT)
+		if len(got) != len(want) {
+			t.Fatalf("%v: logged %d select events, want %d", mode, len(got), len(want))
+		}
+		if mode != Replay {
+			continue
+		}
+		for i := range want {
+			if got[i].P != want[i].P || got[i].Select.Chosen != want[i].Select.Chosen {
+				t.Errorf("select event %d: replayed P%d case %d, want P%d case %d", i, got[i].P, got[i].Select.Chosen, want[i].P, want[i].Select.Chosen)
+			}
+		}
+	}
+}
+
+func TestVirtualTime(t *testing.T) {
+	c := newClusterTest(t, sleepProgram)
+	defer c.close()
+
+	res := c.run(Config{Mode: Explore, Seed: 1})
+	timers := eventsOfType(&res.Schedule, dara.TIMER_EVENT)
+	if len(timers) < 2 {
+		t.Fatalf("logged %d timer events, want one for every process", len(timers))
+	}
+	for _, e := range res.Schedule.LogEvents {
+		if e.Type == dara.END_EVENT && e.Now < int64(time.Hour) {
+			t.Errorf("process %d ended at virtual time %v, want at least an hour", e.P, time.Duration(e.Now))
+		}
+	}
+}
+
+func TestTimers(t *testing.T) {
+	c := newClusterTest(t, tickerProgram)
+	defer c.close()
+
+	res := c.run(Config{Mode: Explore, Seed: 1})
+	for pid := 1; pid <= 2; pid++ {
+		armed := make(map[int64]int)
+		cancelled := 0
+		for _, e := range eventsOfType(&res.Schedule, dara.TIMER_EVENT) {
+			if e.P != pid || e.SyscallInfo.NumArgs < 4 {
+				continue
+			}
+			switch e.SyscallInfo.Args[3].Integer {
+			case dara.TIMER_ARMED:
+				armed[e.SyscallInfo.Args[0].Integer64]++
+			case dara.TIMER_CANCELLED:
+				cancelled++
+			}
+		}
+		rearmed := 0
+		for _, n := range armed {
+			if n > rearmed {
+				rearmed = n
+			}
+		}
+		// The ticker is armed once, and again every time it ticks. The
+		// ticker, the stopped timer and the reset timer are cancelled.
+		if rearmed < 4 {
+			t.Errorf("process %d: the ticker was armed %d times, want at least 4", pid, rearmed)
+		}
+		if cancelled != 3 {
+			t.Errorf("process %d: cancelled %d timers, want 3", pid, cancelled)
+		}
+	}
+
+	// The goroutine blocked on the channel of a timer is woken up by the
+	// timer on replay too.
+	rep := c.run(Config{Mode: Replay, Schedule: &res.Schedule})
+	if got, want := len(firedTimers(&rep.Schedule)), len(firedTimers(&res.Schedule)); got != want {
+		t.Errorf("replay fired %d timers, want %d", got, want)
+	}
+}
+
+// firedTimers returns the timer events of s that fired a timer.
+func firedTimers(s *dara.Schedule) []dara.Event {
+	var fired []dara.Event
+	for _, e := range eventsOfType(s, dara.TIMER_EVENT) {
+		if _, op, _ := e.TimerOp(); op == dara.TIMER_FIRED {
+			fired = append(fired, e)
+		}
+	}
+	return fired
+}
+
+func TestTimerReplay(t *testing.T) {
+	c := newClusterTest(t, timerProgram)
+	defer c.close()
+
+	rec := c.run(Config{Mode: Record})
+	want := firedTimers(&rec.Schedule)
+	// Three sleeps, the time.After and the sleep of the other goroutine
+	// in every process.
+	if len(want) != 10 {
+		t.Fatalf("record: logged %d fired timers, want 10", len(want))
+	}
+	for _, e := range want {
+		if e.SyscallInfo.NumArgs != 6 || e.SyscallInfo.Args[4].Integer64 == 0 {
+			t.Errorf("record: P%d timer %d readied no goroutine", e.P, e.SyscallInfo.Args[0].Integer64)
+		}
+	}
+
+	rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+	got := firedTimers(&rep.Schedule)
+	if len(got) != len(want) {
+		t.Fatalf("replay fired %d timers, recorded %d", len(got), len(want))
+	}
+	for i := range want {
+		w, g := want[i].SyscallInfo.Args, got[i].SyscallInfo.Args
+		if got[i].P != want[i].P || g[0] != w[0] || g[1] != w[1] || g[5] != w[5] {
+			t.Errorf("fired timer %d: replayed P%d timer %d due %d readying %#x, recorded P%d timer %d due %d readying %#x",
+				i, got[i].P, g[0].Integer64, g[1].Integer64, g[5].Integer64, want[i].P, w[0].Integer64, w[1].Integer64, w[5].Integer64)
+		}
+	}
+}
+
+func TestGC(t *testing.T) {
+	c := newClusterTest(t, gcProgram)
+	defer c.close()
+
+	rec := c.run(Config{Mode: Record})
+	rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+	checkSameSchedule(t, &rep.Schedule, &rec.Schedule)
+
+	// Without Dara garbage is collected too.
+	out, err := exec.Command(c.bin).CombinedOutput()
+	if err == nil {
+		err = gcProgram.check(1, string(out))
+	}
+	if err != nil {
+		t.Errorf("running without Dara: %v\n%s", err, out)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	c := newClusterTest(t, seedProgram)
+	defer c.close()
+
+	rec := c.run(Config{Mode: Record, Seed: 7})
+	want := eventsOfType(&rec.Schedule, dara.INIT_EVENT)
+	if len(want) != 2 {
+		t.Fatalf("record: logged %d init events, want 2", len(want))
+	}
+	for _, e := range want {
+		if seed, ok := e.InitSeed(); !ok || seed != dara.ProcSeed(7, e.P) {
+			t.Errorf("record: P%d was seeded with %d, want %d", e.P, seed, dara.ProcSeed(7, e.P))
+		}
+	}
+
+	rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+	got := eventsOfType(&rep.Schedule, dara.INIT_EVENT)
+	if len(got) != len(want) {
+		t.Fatalf("replay logged %d init events, recorded %d", len(got), len(want))
+	}
+	for i := range want {
+		ws, _ := want[i].InitSeed()
+		if gs, _ := got[i].InitSeed(); got[i].P != want[i].P || gs != ws {
+			t.Errorf("init event %d: replayed P%d seeded with %d, recorded P%d seeded with %d", i, got[i].P, gs, want[i].P, ws)
+		}
+	}
+}
+
+func TestSyscallPoints(t *testing.T) {
+	c := newClusterTest(t, mutexProgram)
+	defer c.close()
+
+	plain := c.run(Config{Mode: Record})
+	rec := c.run(Config{Mode: Record, SyscallPoints: dara.SYSPOINT_MUTEX_LOCK})
+	want := schedEvents(&rec.Schedule)
+	// Every one of the 10 locks of each process is a scheduling point
+	// of its own.
+	if n := len(schedEvents(&plain.Schedule)); len(want) < n+20 {
+		t.Errorf("record: %d scheduling events with mutex locks as scheduling points, %d without", len(want), n)
+	}
+
+	rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+	checkSameSchedule(t, &rep.Schedule, &rec.Schedule)
+	for _, e := range eventsOfType(&rep.Schedule, dara.INIT_EVENT) {
+		if points, _ := e.InitSyscallPoints(); points != dara.SYSPOINT_MUTEX_LOCK {
+			t.Errorf("replay: P%d ran with syscall points %#x, recorded %#x", e.P, points, dara.SYSPOINT_MUTEX_LOCK)
+		}
+	}
+	checkSameEvents(t, &rep.Schedule, &rec.Schedule)
+}
+
+// injectedFaults returns the faults logged in s.
+func injectedFaults(s *dara.Schedule) []dara.Event {
+	var evs []dara.Event
+	for _, e := range eventsOfType(s, dara.SYSCALL_EVENT) {
+		if e.Fault.Call != 0 {
+			evs = append(evs, e)
+		}
+	}
+	return evs
+}
+
+func TestFaults(t *testing.T) {
+	c := newClusterTest(t, faultProgram)
+	defer c.close()
+
+	faults := []dara.Fault{
+		{Syscall: dara.DSYS_WRITE, Call: 1, Errno: int(syscall.ENOSPC)},
+		{Syscall: dara.DSYS_WRITE, Call: 2, Short: 2},
+	}
+	rec := c.run(Config{Mode: Record, Faults: map[int][]dara.Fault{1: faults, 2: faults}})
+	want := injectedFaults(&rec.Schedule)
+	if len(want) != 4 {
+		t.Fatalf("record: logged %d faults, want 4", len(want))
+	}
+
+	// The replay has to inject the recorded faults on its own.
+	rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+	got := injectedFaults(&rep.Schedule)
+	if len(got) != len(want) {
+		t.Fatalf("replay injected %d faults, recorded %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].P != want[i].P || got[i].Fault != want[i].Fault {
+			t.Errorf("fault %d: replayed P%d %+v, recorded P%d %+v", i, got[i].P, got[i].Fault, want[i].P, want[i].Fault)
+		}
+	}
+	checkSameEvents(t, &rep.Schedule, &rec.Schedule)
+}
+
+func TestPartitions(t *testing.T) {
+	c := newClusterTest(t, pingProgram)
+	defer c.close()
+
+	partitions := []Partition{{Side: []int{1}, Action: dara.NET_DELAY}}
+	for seed := int64(1); seed <= 3; seed++ {
+		rec := c.run(Config{Mode: Explore, Seed: seed, Partitions: partitions})
+		want := eventsOfType(&rec.Schedule, dara.NET_EVENT)
+		if len(want) == 0 {
+			continue
+		}
+		// The links across a partition are cut and healed both ways.
+		if len(want)%2 != 0 {
+			t.Errorf("seed %d: %d net events, want them in pairs", seed, len(want))
+		}
+		for i, e := range want {
+			remote, action, ok := e.NetLink()
+			if !ok || e.P+remote != 3 || action != dara.NET_DELIVER && action != dara.NET_DELAY {
+				t.Errorf("seed %d: net event %d sets the link from P%d to P%d to %s", seed, i, e.P, remote, dara.NetActionString(action))
+			}
+		}
+
+		rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+		got := eventsOfType(&rep.Schedule, dara.NET_EVENT)
+		if len(got) != len(want) {
+			t.Fatalf("seed %d: replay set %d links, explore set %d", seed, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].P != want[i].P || got[i].SyscallInfo != want[i].SyscallInfo {
+				t.Errorf("seed %d: net event %d replayed as %+v, explored as %+v", seed, i, got[i], want[i])
+			}
+		}
+		return
+	}
+	t.Fatal("no partition was installed")
+}
+
+// heldMessages returns what the MESSAGE_EVENTs of s did, without the
+// names of the connections, whose ports differ from run to run.
+func heldMessages(s *dara.Schedule) []dara.HeldMessage {
+	var ms []dara.HeldMessage
+	for _, e := range eventsOfType(s, dara.MESSAGE_EVENT) {
+		m, ok := e.HeldMessage()
+		if ok {
+			m.Conn = ""
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+func TestHoldMessages(t *testing.T) {
+	c := newClusterTest(t, pingPongProgram)
+	defer c.close()
+	addr := c.path("addr")
+
+	for _, mode := range []Mode{Record, Explore} {
+		os.Remove(addr)
+		rec := c.run(Config{Mode: mode, Seed: 1, HoldMessages: true})
+		for _, e := range eventsOfType(&rec.Schedule, dara.INIT_EVENT) {
+			if !e.InitHoldMessages() {
+				t.Errorf("%v: process %d did not log that it holds messages", mode, e.P)
+			}
+		}
+		want := heldMessages(&rec.Schedule)
+		var held, written int
+		for _, m := range want {
+			if m.Op == dara.MESSAGE_HELD {
+				held++
+			} else {
+				written += m.Len
+			}
+		}
+		// The ping and the pong, each of them delivered in full, or
+		// flushed when their connection was closed.
+		if held != 2 || written != 8 {
+			t.Errorf("%v: held %d messages and wrote %d bytes, want 2 and 8", mode, held, written)
+		}
+
+		os.Remove(addr)
+		rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+		got := heldMessages(&rep.Schedule)
+		if len(got) != len(want) {
+			t.Fatalf("%v: replay logged %d message events, want %d", mode, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%v: message event %d replayed as %+v, want %+v", mode, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestVirtualNet(t *testing.T) {
+	c := newClusterTest(t, pingPongProgram)
+	defer c.close()
+	addr := c.path("addr")
+	// The first ephemeral port of process 1.
+	want := fmt.Sprintf("127.0.0.1:%d", dara.EphemeralPort(1, 0))
+
+	for _, mode := range []Mode{Record, Explore} {
+		os.Remove(addr)
+		rec := c.run(Config{Mode: mode, Seed: 1, VirtualNet: true})
+		for _, e := range eventsOfType(&rec.Schedule, dara.INIT_EVENT) {
+			if !e.InitVirtualNet() {
+				t.Errorf("%v: process %d did not log that it is on the virtual network", mode, e.P)
+			}
+		}
+		if b, err := ioutil.ReadFile(addr); err != nil || string(b) != want {
+			t.Errorf("%v: listened on %q, %v, want %q", mode, b, err, want)
+		}
+
+		os.Remove(addr)
+		rep := c.run(Config{Mode: Replay, Schedule: &rec.Schedule})
+		// The connections are made again on replay, on the virtual
+		// network as well.
+		for _, e := range eventsOfType(&rep.Schedule, dara.INIT_EVENT) {
+			if !e.InitVirtualNet() {
+				t.Errorf("%v: process %d is not on the virtual network on replay", mode, e.P)
+			}
+		}
+		if b, err := ioutil.ReadFile(addr); err != nil || string(b) != want {
+			t.Errorf("%v: replay listened on %q, %v, want %q", mode, b, err, want)
+		}
+		got, exp := schedEvents(&rep.Schedule), schedEvents(&rec.Schedule)
+		if len(got) != len(exp) {
+			t.Errorf("%v: replay made %d scheduling decisions, want %d", mode, len(got), len(exp))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package sched
+
+import (
+	"dara"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// SharedMem is the memory shared between the global scheduler and the
+// runtimes it controls. It is backed by a file which every runtime
+// finds open on dara.DARAFD.
+type SharedMem struct {
+	file   *os.File
+	mem    []byte
+	Header *dara.SharedHeader
+}
+
+// CreateSharedMem opens the file at path, creating it if necessary,
+// sizes it for numProcs DaraProcs, maps it and writes a fresh header.
+// Any previous contents of the file are discarded.
+func CreateSharedMem(path string, numProcs int) (*SharedMem, error) {
+	if numProcs <= 0 {
+		return nil, fmt.Errorf("dara/sched: need at least one process, got %d", numProcs)
+	}
+	hdr := dara.NewSharedHeader(numProcs)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// Truncating to zero first zeroes every DaraProc, the file stays
+	// sparse so the untouched parts of the log cost nothing.
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(int64(hdr.MapSize())); err != nil {
+		f.Close()
+		return nil, err
+	}
+	mem, err := syscall.Mmap(int(f.Fd()), 0, int(hdr.MapSize()), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("dara/sched: mmap %s: %v", path, err)
+	}
+	m := &SharedMem{file: f, mem: mem}
+	m.Header = (*dara.SharedHeader)(unsafe.Pointer(&mem[0]))
+	*m.Header = hdr
+	return m, nil
+}
+
+// File returns the file backing the shared memory.
+func (m *SharedMem) File() *os.File {
+	return m.file
+}
+
+// Proc returns the DaraProc of DARAPID pid.
+func (m *SharedMem) Proc(pid int) *dara.DaraProc {
+	if !m.Header.ValidPid(pid) {
+		panic(fmt.Sprintf("dara/sched: DARAPID %d out of range [1,%d]", pid, m.Header.NumProcs))
+	}
+	return (*dara.DaraProc)(unsafe.Pointer(&m.mem[m.Header.ProcOffset(pid)]))
+}
+
+// Close unmaps the shared memory and closes the backing file.
+func (m *SharedMem) Close() error {
+	err := syscall.Munmap(m.mem)
+	if cerr := m.file.Close(); err == nil {
+		err = cerr
+	}
+	m.mem = nil
+	m.Header = nil
+	return err
+}
+
+const (
+	_FUTEX_WAIT = 0
+	_FUTEX_WAKE = 1
+)
+
+// futexWait sleeps while *addr == val, for at most d.
+func futexWait(addr *uint32, val uint32, d time.Duration) {
+	ts := syscall.NsecToTimespec(int64(d))
+	syscall.Syscall6(syscall.SYS_FUTEX, uintptr(unsafe.Pointer(addr)), _FUTEX_WAIT, uintptr(val), uintptr(unsafe.Pointer(&ts)), 0, 0)
+}
+
+// futexWake wakes up a runtime sleeping on addr.
+func futexWake(addr *uint32) {
+	syscall.Syscall6(syscall.SYS_FUTEX, uintptr(unsafe.Pointer(addr)), _FUTEX_WAKE, 1, 0, 0, 0)
+}
+
+// tryLock takes the lock word at addr if it is free.
+func tryLock(addr *uint32) bool {
+	return atomic.CompareAndSwapUint32(addr, dara.UNLOCKED, dara.LOCKED)
+}
+
+// errGone and errTimeout are returned by lockWord when it gives up.
+var (
+	errGone    = errors.New("dara/sched: the other side went away")
+	errTimeout = errors.New("dara/sched: timed out waiting for the lock")
+)
+
+// lockWord takes the lock word at addr, following the same spin then
+// sleep policy as the runtime. idle is called every time the wait is
+// interrupted, by a wake up or every pollInterval. lockWord gives up and
+// returns errGone once idle returns false, and errTimeout once deadline
+// has passed.
+func lockWord(addr *uint32, deadline time.Time, idle func() bool) error {
+	for i := 0; i < 100; i++ {
+		if tryLock(addr) {
+			return nil
+		}
+	}
+	for atomic.SwapUint32(addr, dara.CONTENDED) != dara.UNLOCKED {
+		// The other side may have let go of the lock just before it
+		// went away or the deadline passed.
+		if !idle() {
+			if atomic.SwapUint32(addr, dara.CONTENDED) == dara.UNLOCKED {
+				return nil
+			}
+			return errGone
+		}
+		if time.Now().After(deadline) {
+			if atomic.SwapUint32(addr, dara.CONTENDED) == dara.UNLOCKED {
+				return nil
+			}
+			return errTimeout
+		}
+		futexWait(addr, dara.CONTENDED, pollInterval)
+	}
+	return nil
+}
+
+// unlockWord releases the lock word at addr and wakes up the runtime if
+// it is asleep waiting for it.
+func unlockWord(addr *uint32) {
+	if atomic.SwapUint32(addr, dara.UNLOCKED) == dara.CONTENDED {
+		futexWake(addr)
+	}
+}
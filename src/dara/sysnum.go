@@ -73,5 +73,100 @@ const (
     CTX_DONE
 	CTX_CANCEL
 	DSYS_TIMER
+	DSYS_INIT
+	DSYS_MESSAGE
+	//The calls that set up and use sockets other than a connected
+	//stream, reported by package net with the network as a STRING
+	//argument, the local and the peer address as SOCKADDR arguments,
+	//and the number of bytes moved and the error, if any, as results.
+	DSYS_DIAL
+	DSYS_ACCEPT
+	DSYS_NET_READFROM
+	DSYS_NET_WRITETO
+	DSYS_LISTEN_UDP
+	DSYS_LISTEN_UNIX
 )
 
+//Classes of syscalls that can be made scheduling points. The runtime
+//takes the classes to use, or'ed together, from DARA_SYSCALL_POINTS. A
+//goroutine about to make a syscall of one of them hands control to the
+//global scheduler first, which may run another goroutine or process
+//before it lets the syscall proceed.
+const (
+	SYSPOINT_FILE_WRITE = 1 << iota
+	SYSPOINT_FSYNC
+	SYSPOINT_NET_WRITE
+	SYSPOINT_MUTEX_LOCK
+	numSyscallPoints = iota
+)
+
+var syscallPointNames = [numSyscallPoints]string{
+	"file-write",
+	"fsync",
+	"net-write",
+	"mutex-lock",
+}
+
+//SyscallPoint returns the class of syscall points the syscall
+//syscallID belongs to, 0 if it is never a scheduling point
+func SyscallPoint(syscallID int) int {
+	switch syscallID {
+	case DSYS_WRITE, DSYS_PWRITE64:
+		return SYSPOINT_FILE_WRITE
+	case DSYS_FSYNC:
+		return SYSPOINT_FSYNC
+	case DSYS_NET_WRITE, DSYS_NET_WRITETO:
+		return SYSPOINT_NET_WRITE
+	case MUX_LOCK:
+		return SYSPOINT_MUTEX_LOCK
+	}
+	return 0
+}
+
+//ParseSyscallPoints returns the classes of syscall points named in the
+//comma separated list s, such as "file-write,mutex-lock". If a name is
+//unknown it is returned as bad.
+func ParseSyscallPoints(s string) (points int, bad string) {
+	for len(s) > 0 {
+		i := 0
+		for i < len(s) && s[i] != ',' {
+			i++
+		}
+		name := s[:i]
+		if i < len(s) {
+			i++
+		}
+		s = s[i:]
+		if name == "" {
+			continue
+		}
+		found := false
+		for i, n := range syscallPointNames {
+			if n == name {
+				points |= 1 << uint(i)
+				found = true
+			}
+		}
+		if !found {
+			return 0, name
+		}
+	}
+	return points, ""
+}
+
+//SyscallPointsString returns the names of the classes in points, in
+//the form ParseSyscallPoints reads
+func SyscallPointsString(points int) string {
+	s := ""
+	for i, n := range syscallPointNames {
+		if points&(1<<uint(i)) == 0 {
+			continue
+		}
+		if s != "" {
+			s += ","
+		}
+		s += n
+	}
+	return s
+}
+
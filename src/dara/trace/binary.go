@@ -0,0 +1,241 @@
+package trace
+
+import (
+	"bufio"
+	"dara"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxRecord bounds the payload of a single record so that a corrupt
+// length can not make a reader allocate without bound.
+const maxRecord = 64 << 20
+
+// Writer writes a binary trace. Records are buffered, call Flush once
+// done.
+type Writer struct {
+	w   *bufio.Writer
+	enc encoder
+	err error
+}
+
+// NewWriter writes the magic, the format version and h to w and returns
+// a Writer for the rest of the trace. h.Version is set to Version.
+func NewWriter(w io.Writer, h *Header) (*Writer, error) {
+	tw := &Writer{w: bufio.NewWriter(w)}
+	tw.w.WriteString(Magic)
+	var b [binary.MaxVarintLen64]byte
+	tw.w.Write(b[:binary.PutUvarint(b[:], Version)])
+	h.Version = Version
+	tw.enc.header(h)
+	if err := tw.flushRecord(KindHeader); err != nil {
+		return nil, err
+	}
+	return tw, nil
+}
+
+// flushRecord writes what has been encoded so far as a record of kind k.
+func (w *Writer) flushRecord(k Kind) error {
+	if w.err != nil {
+		return w.err
+	}
+	payload := w.enc.buf
+	w.enc.buf = w.enc.buf[:0]
+	if err := w.enc.err; err != nil {
+		w.enc.err = nil
+		return err
+	}
+	if len(payload) > maxRecord {
+		return fmt.Errorf("trace: %v record of %d bytes is too large", k, len(payload))
+	}
+	var b [1 + binary.MaxVarintLen64]byte
+	b[0] = byte(k)
+	n := 1 + binary.PutUvarint(b[1:], uint64(len(payload)))
+	w.w.Write(b[:n])
+	if _, err := w.w.Write(payload); err != nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// WriteEvent appends an event to the trace.
+func (w *Writer) WriteEvent(e *dara.Event) error {
+	w.enc.event(e)
+	return w.flushRecord(KindEvent)
+}
+
+// WriteCoverage appends a coverage event to the trace.
+func (w *Writer) WriteCoverage(c *dara.CoverageEvent) error {
+	w.enc.coverage(c)
+	return w.flushRecord(KindCoverage)
+}
+
+// WritePropCheck appends a property check to the trace.
+func (w *Writer) WritePropCheck(p *dara.PropCheckEvent) error {
+	w.enc.propCheck(p)
+	return w.flushRecord(KindPropCheck)
+}
+
+// WriteSchedule appends every event, coverage event and property check
+// of s to the trace.
+func (w *Writer) WriteSchedule(s *dara.Schedule) error {
+	for i := range s.LogEvents {
+		if err := w.WriteEvent(&s.LogEvents[i]); err != nil {
+			return err
+		}
+	}
+	for i := range s.CovEvents {
+		if err := w.WriteCoverage(&s.CovEvents[i]); err != nil {
+			return err
+		}
+	}
+	for i := range s.PropEvents {
+		if err := w.WritePropCheck(&s.PropEvents[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes any buffered records to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.w.Flush()
+	return w.err
+}
+
+// Reader reads a binary trace.
+type Reader struct {
+	r       *bufio.Reader
+	h       *Header
+	buf     []byte
+	version int
+}
+
+// NewReader reads the magic, version and header of a binary trace
+// from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	tr := &Reader{r: bufio.NewReader(r)}
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(tr.r, magic); err != nil {
+		return nil, fmt.Errorf("trace: reading magic: %v", err)
+	}
+	if string(magic) != Magic {
+		return nil, errors.New("trace: not a binary Dara trace")
+	}
+	v, err := binary.ReadUvarint(tr.r)
+	if err != nil {
+		return nil, fmt.Errorf("trace: reading version: %v", err)
+	}
+	if v == 0 || v > Version {
+		return nil, fmt.Errorf("trace: unsupported format version %d, this reader supports up to %d", v, Version)
+	}
+	tr.version = int(v)
+	k, payload, err := tr.record()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k != KindHeader {
+		return nil, fmt.Errorf("trace: first record is %v, not the header", k)
+	}
+	d := decoder{buf: payload, version: tr.version}
+	tr.h = d.header()
+	if d.err != nil {
+		return nil, d.err
+	}
+	return tr, nil
+}
+
+// Header returns the header of the trace.
+func (r *Reader) Header() *Header {
+	return r.h
+}
+
+// record reads the next raw record. It returns io.EOF only if the trace
+// ends cleanly between two records.
+func (r *Reader) record() (Kind, []byte, error) {
+	k, err := r.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, nil, noEOF(err)
+	}
+	if n > maxRecord {
+		return 0, nil, fmt.Errorf("trace: %v record of %d bytes is too large", Kind(k), n)
+	}
+	if uint64(cap(r.buf)) < n {
+		r.buf = make([]byte, n)
+	}
+	r.buf = r.buf[:n]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return 0, nil, noEOF(err)
+	}
+	return Kind(k), r.buf, nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Next returns the next record of the trace, or io.EOF at the end of it.
+// Records of unknown kinds are skipped.
+func (r *Reader) Next() (*Record, error) {
+	for {
+		k, payload, err := r.record()
+		if err != nil {
+			return nil, err
+		}
+		d := decoder{buf: payload, version: r.version}
+		rec := &Record{Kind: k}
+		switch k {
+		case KindEvent:
+			rec.Event = d.event()
+		case KindCoverage:
+			rec.Coverage = d.coverage()
+		case KindPropCheck:
+			rec.PropCheck = d.propCheck()
+		case KindHeader:
+			return nil, errors.New("trace: more than one header")
+		default:
+			continue
+		}
+		if d.err != nil {
+			return nil, fmt.Errorf("trace: %v record: %v", k, d.err)
+		}
+		return rec, nil
+	}
+}
+
+// ReadSchedule reads the rest of the trace into a schedule.
+func (r *Reader) ReadSchedule() (*dara.Schedule, error) {
+	s := new(dara.Schedule)
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch rec.Kind {
+		case KindEvent:
+			s.LogEvents = append(s.LogEvents, *rec.Event)
+		case KindCoverage:
+			s.CovEvents = append(s.CovEvents, *rec.Coverage)
+		case KindPropCheck:
+			s.PropEvents = append(s.PropEvents, *rec.PropCheck)
+		}
+	}
+}
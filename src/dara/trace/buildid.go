@@ -0,0 +1,79 @@
+package trace
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+var (
+	elfGoNote       = []byte("Go\x00\x00")
+	goBuildIDPrefix = []byte("\xff Go build ID: \"")
+	goBuildIDEnd    = []byte("\"\n \xff")
+)
+
+// The ELF note type of the Go build ID, see cmd/link.
+const elfGoBuildIDTag = 4
+
+// How far into a binary to look for the build ID when it has no ELF
+// note, the linker puts it near the start of the text segment.
+const buildIDSearch = 32 << 10
+
+// ReadBuildID returns the Go build ID of the binary at path, as printed
+// by go tool buildid.
+func ReadBuildID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if ef, err := elf.NewFile(f); err == nil {
+		if id, ok := elfBuildID(ef); ok {
+			return id, nil
+		}
+	}
+	buf := make([]byte, buildIDSearch)
+	n, err := f.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	buf = buf[:n]
+	i := bytes.Index(buf, goBuildIDPrefix)
+	if i < 0 {
+		return "", fmt.Errorf("trace: %s: no Go build ID", path)
+	}
+	buf = buf[i+len(goBuildIDPrefix):]
+	j := bytes.Index(buf, goBuildIDEnd)
+	if j < 0 {
+		return "", fmt.Errorf("trace: %s: truncated Go build ID", path)
+	}
+	// The ID is quoted, undo any escaping.
+	id, err := strconv.Unquote("\"" + string(buf[:j]) + "\"")
+	if err != nil {
+		return "", fmt.Errorf("trace: %s: malformed Go build ID", path)
+	}
+	return id, nil
+}
+
+// elfBuildID reads the build ID from the Go note of an ELF binary.
+func elfBuildID(f *elf.File) (string, bool) {
+	s := f.Section(".note.go.buildid")
+	if s == nil {
+		return "", false
+	}
+	data, err := s.Data()
+	if err != nil || len(data) < 16 {
+		return "", false
+	}
+	order := f.ByteOrder
+	namesz := order.Uint32(data)
+	descsz := order.Uint32(data[4:])
+	tag := order.Uint32(data[8:])
+	if namesz != 4 || tag != elfGoBuildIDTag || !bytes.Equal(data[12:16], elfGoNote) || uint64(descsz) > uint64(len(data)-16) {
+		return "", false
+	}
+	return string(data[16 : 16+descsz]), true
+}
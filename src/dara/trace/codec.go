@@ -0,0 +1,637 @@
+package trace
+
+import (
+	"dara"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var errCorrupt = errors.New("trace: corrupt record")
+
+// encoder appends the binary encoding of values to buf.
+type encoder struct {
+	buf []byte
+	err error
+}
+
+func (e *encoder) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (e *encoder) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutVarint(b[:], v)]...)
+}
+
+func (e *encoder) int(v int) { e.varint(int64(v)) }
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) float32(v float32) { e.uvarint(uint64(math.Float32bits(v))) }
+func (e *encoder) float64(v float64) { e.uvarint(math.Float64bits(v)) }
+
+func (e *encoder) bytes(b []byte) {
+	e.uvarint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// length writes the length of a slice or map, telling nil apart from
+// empty so that both read back as they were.
+func (e *encoder) length(n int, isNil bool) {
+	if isNil {
+		e.uvarint(0)
+	} else {
+		e.uvarint(uint64(n) + 1)
+	}
+}
+
+// fixed writes a fixed size byte array without its trailing zeros.
+func (e *encoder) fixed(b []byte) {
+	e.bytes(b[:trimmedLen(b)])
+}
+
+func trimmedLen(b []byte) int {
+	n := len(b)
+	for n > 0 && b[n-1] == 0 {
+		n--
+	}
+	return n
+}
+
+// decoder reads values back from buf. The first error sticks, every
+// later read returns a zero value.
+type decoder struct {
+	buf []byte
+	err error
+	// version is the format version of the trace being read.
+	version int
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.buf = nil
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail(errCorrupt)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail(errCorrupt)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) int() int { return int(d.varint()) }
+
+func (d *decoder) bool() bool {
+	if len(d.buf) < 1 {
+		d.fail(errCorrupt)
+		return false
+	}
+	v := d.buf[0]
+	d.buf = d.buf[1:]
+	return v != 0
+}
+
+func (d *decoder) float32() float32 { return math.Float32frombits(uint32(d.uvarint())) }
+func (d *decoder) float64() float64 { return math.Float64frombits(d.uvarint()) }
+
+// raw returns the next n bytes, which alias buf.
+func (d *decoder) raw(n uint64) []byte {
+	if n > uint64(len(d.buf)) {
+		d.fail(errCorrupt)
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) bytes() []byte {
+	b := d.raw(d.uvarint())
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func (d *decoder) string() string { return string(d.raw(d.uvarint())) }
+
+// length reads a length written by encoder.length. It returns -1 for nil.
+// The length is checked against the bytes left, as every element takes
+// at least one byte.
+func (d *decoder) length() int {
+	n := d.uvarint()
+	if n == 0 {
+		return -1
+	}
+	if n-1 > uint64(len(d.buf)) {
+		d.fail(errCorrupt)
+		return -1
+	}
+	return int(n - 1)
+}
+
+func (d *decoder) fixed(dst []byte) {
+	b := d.raw(d.uvarint())
+	if len(b) > len(dst) {
+		d.fail(errCorrupt)
+		return
+	}
+	copy(dst, b)
+}
+
+// Value tags, the type of every logged value is stored with it.
+const (
+	tagNil byte = iota
+	tagBool
+	tagInt
+	tagInt8
+	tagInt16
+	tagInt32
+	tagInt64
+	tagUint
+	tagUint8
+	tagUint16
+	tagUint32
+	tagUint64
+	tagUintptr
+	tagFloat32
+	tagFloat64
+	tagString
+	tagBytes
+	tagSlice
+	tagMap
+)
+
+// value writes a value logged with DaraLog or attached to a failed
+// property.
+func (e *encoder) value(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.buf = append(e.buf, tagNil)
+	case bool:
+		e.buf = append(e.buf, tagBool)
+		e.bool(v)
+	case int:
+		e.buf = append(e.buf, tagInt)
+		e.varint(int64(v))
+	case int8:
+		e.buf = append(e.buf, tagInt8)
+		e.varint(int64(v))
+	case int16:
+		e.buf = append(e.buf, tagInt16)
+		e.varint(int64(v))
+	case int32:
+		e.buf = append(e.buf, tagInt32)
+		e.varint(int64(v))
+	case int64:
+		e.buf = append(e.buf, tagInt64)
+		e.varint(v)
+	case uint:
+		e.buf = append(e.buf, tagUint)
+		e.uvarint(uint64(v))
+	case uint8:
+		e.buf = append(e.buf, tagUint8)
+		e.uvarint(uint64(v))
+	case uint16:
+		e.buf = append(e.buf, tagUint16)
+		e.uvarint(uint64(v))
+	case uint32:
+		e.buf = append(e.buf, tagUint32)
+		e.uvarint(uint64(v))
+	case uint64:
+		e.buf = append(e.buf, tagUint64)
+		e.uvarint(v)
+	case uintptr:
+		e.buf = append(e.buf, tagUintptr)
+		e.uvarint(uint64(v))
+	case float32:
+		e.buf = append(e.buf, tagFloat32)
+		e.float32(v)
+	case float64:
+		e.buf = append(e.buf, tagFloat64)
+		e.float64(v)
+	case string:
+		e.buf = append(e.buf, tagString)
+		e.string(v)
+	case []byte:
+		e.buf = append(e.buf, tagBytes)
+		e.length(len(v), v == nil)
+		e.buf = append(e.buf, v...)
+	case []interface{}:
+		e.buf = append(e.buf, tagSlice)
+		e.length(len(v), v == nil)
+		for _, x := range v {
+			e.value(x)
+		}
+	case map[string]interface{}:
+		e.buf = append(e.buf, tagMap)
+		e.valueMap(v)
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("trace: unsupported value type %T", v)
+		}
+	}
+}
+
+func (e *encoder) valueMap(m map[string]interface{}) {
+	e.length(len(m), m == nil)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.string(k)
+		e.value(m[k])
+	}
+}
+
+func (d *decoder) value() interface{} {
+	if len(d.buf) < 1 {
+		d.fail(errCorrupt)
+		return nil
+	}
+	tag := d.buf[0]
+	d.buf = d.buf[1:]
+	switch tag {
+	case tagNil:
+		return nil
+	case tagBool:
+		return d.bool()
+	case tagInt:
+		return int(d.varint())
+	case tagInt8:
+		return int8(d.varint())
+	case tagInt16:
+		return int16(d.varint())
+	case tagInt32:
+		return int32(d.varint())
+	case tagInt64:
+		return d.varint()
+	case tagUint:
+		return uint(d.uvarint())
+	case tagUint8:
+		return uint8(d.uvarint())
+	case tagUint16:
+		return uint16(d.uvarint())
+	case tagUint32:
+		return uint32(d.uvarint())
+	case tagUint64:
+		return d.uvarint()
+	case tagUintptr:
+		return uintptr(d.uvarint())
+	case tagFloat32:
+		return d.float32()
+	case tagFloat64:
+		return d.float64()
+	case tagString:
+		return d.string()
+	case tagBytes:
+		n := d.length()
+		if n < 0 {
+			return []byte(nil)
+		}
+		return append([]byte{}, d.raw(uint64(n))...)
+	case tagSlice:
+		n := d.length()
+		if n < 0 {
+			return []interface{}(nil)
+		}
+		s := make([]interface{}, n)
+		for i := range s {
+			s[i] = d.value()
+		}
+		return s
+	case tagMap:
+		return d.valueMap()
+	}
+	d.fail(fmt.Errorf("trace: unknown value tag %d", tag))
+	return nil
+}
+
+func (d *decoder) valueMap() map[string]interface{} {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		k := d.string()
+		m[k] = d.value()
+	}
+	return m
+}
+
+func (e *encoder) header(h *Header) {
+	e.int(h.Version)
+	e.length(len(h.Procs), h.Procs == nil)
+	for _, p := range h.Procs {
+		e.int(p.PID)
+		e.string(p.Path)
+		e.string(p.BuildID)
+	}
+}
+
+func (d *decoder) header() *Header {
+	h := &Header{Version: d.int()}
+	if n := d.length(); n >= 0 {
+		h.Procs = make([]Proc, n)
+		for i := range h.Procs {
+			p := &h.Procs[i]
+			p.PID = d.int()
+			p.Path = d.string()
+			p.BuildID = d.string()
+		}
+	}
+	return h
+}
+
+func (e *encoder) routine(r *dara.RoutineInfo) {
+	e.uvarint(uint64(r.Status))
+	e.int(r.Gid)
+	e.uvarint(uint64(r.Gpc))
+	e.int(r.RoutineCount)
+	e.fixed(r.FuncInfo[:])
+	e.uvarint(r.LogicalID)
+}
+
+func (d *decoder) routine(r *dara.RoutineInfo) {
+	r.Status = uint32(d.uvarint())
+	r.Gid = d.int()
+	r.Gpc = uintptr(d.uvarint())
+	r.RoutineCount = d.int()
+	d.fixed(r.FuncInfo[:])
+	if d.version >= 5 {
+		r.LogicalID = d.uvarint()
+	}
+}
+
+func (e *encoder) generalType(t *dara.GeneralType) {
+	e.int(int(t.Type))
+	e.int(t.Integer)
+	e.bool(t.Bool)
+	e.float32(t.Float)
+	e.varint(t.Integer64)
+	e.string(t.String)
+	e.varint(int64(t.Unsupported))
+}
+
+func (d *decoder) generalType(t *dara.GeneralType) {
+	t.Type = dara.TypeNum(d.int())
+	t.Integer = d.int()
+	t.Bool = d.bool()
+	t.Float = d.float32()
+	t.Integer64 = d.varint()
+	t.String = d.string()
+	t.Unsupported = rune(d.varint())
+}
+
+// generalTypes writes the arguments or returns of a syscall, up to the
+// last one which is not zero.
+func (e *encoder) generalTypes(ts []dara.GeneralType) {
+	n := len(ts)
+	for n > 0 && ts[n-1] == (dara.GeneralType{}) {
+		n--
+	}
+	e.uvarint(uint64(n))
+	for i := 0; i < n; i++ {
+		e.generalType(&ts[i])
+	}
+}
+
+func (d *decoder) generalTypes(ts []dara.GeneralType) {
+	n := d.uvarint()
+	if n > uint64(len(ts)) {
+		d.fail(errCorrupt)
+		return
+	}
+	for i := 0; i < int(n); i++ {
+		d.generalType(&ts[i])
+	}
+}
+
+func (e *encoder) syscall(s *dara.GeneralSyscall) {
+	e.int(s.SyscallNum)
+	e.int(s.NumArgs)
+	e.int(s.NumRets)
+	e.generalTypes(s.Args[:])
+	e.generalTypes(s.Rets[:])
+}
+
+func (d *decoder) syscall(s *dara.GeneralSyscall) {
+	s.SyscallNum = d.int()
+	s.NumArgs = d.int()
+	s.NumRets = d.int()
+	d.generalTypes(s.Args[:])
+	d.generalTypes(s.Rets[:])
+}
+
+func (e *encoder) event(ev *dara.Event) {
+	e.int(ev.Type)
+	e.int(ev.P)
+	e.routine(&ev.G)
+	e.int(ev.Epoch)
+	e.string(ev.LE.LogID)
+	e.length(len(ev.LE.Vars), ev.LE.Vars == nil)
+	for _, v := range ev.LE.Vars {
+		e.string(v.VarName)
+		e.value(v.Value)
+		e.string(v.Type)
+	}
+	e.syscall(&ev.SyscallInfo)
+	e.string(ev.Msg.Body)
+	e.selectInfo(&ev.Select)
+	e.message(&ev.Msg)
+	e.clock(ev.Clock)
+	e.varint(ev.Now)
+	e.int(ev.Fault.Syscall)
+	e.int(ev.Fault.Call)
+	e.int(ev.Fault.Errno)
+	e.int(ev.Fault.Short)
+}
+
+func (d *decoder) event() *dara.Event {
+	ev := new(dara.Event)
+	ev.Type = d.int()
+	ev.P = d.int()
+	d.routine(&ev.G)
+	ev.Epoch = d.int()
+	ev.LE.LogID = d.string()
+	if n := d.length(); n >= 0 {
+		ev.LE.Vars = make([]dara.NameValuePair, n)
+		for i := range ev.LE.Vars {
+			v := &ev.LE.Vars[i]
+			v.VarName = d.string()
+			v.Value = d.value()
+			v.Type = d.string()
+		}
+	}
+	d.syscall(&ev.SyscallInfo)
+	ev.Msg.Body = d.string()
+	if d.version >= 2 {
+		d.selectInfo(&ev.Select)
+	}
+	if d.version >= 3 {
+		d.message(&ev.Msg)
+	}
+	if d.version >= 4 {
+		ev.Clock = d.clock()
+	}
+	if d.version >= 6 {
+		ev.Now = d.varint()
+	}
+	if d.version >= 7 {
+		ev.Fault.Syscall = d.int()
+		ev.Fault.Call = d.int()
+		ev.Fault.Errno = d.int()
+		ev.Fault.Short = d.int()
+	}
+	return ev
+}
+
+func (e *encoder) selectInfo(s *dara.SelectInfo) {
+	e.length(len(s.Ready), s.Ready == nil)
+	for _, c := range s.Ready {
+		e.int(c.Index)
+		e.uvarint(uint64(c.PC))
+	}
+	e.int(s.Chosen)
+}
+
+func (d *decoder) selectInfo(s *dara.SelectInfo) {
+	if n := d.length(); n >= 0 {
+		s.Ready = make([]dara.SelectCase, n)
+		for i := range s.Ready {
+			s.Ready[i].Index = d.int()
+			s.Ready[i].PC = uintptr(d.uvarint())
+		}
+	}
+	s.Chosen = d.int()
+}
+
+// message encodes everything but the Body of m, which predates the
+// rest.
+func (e *encoder) message(m *dara.Message) {
+	e.uvarint(uint64(m.Chan.Pc))
+	e.int(m.Chan.Index)
+	e.int(m.Len)
+	e.int(m.Cap)
+	e.bool(m.Closed)
+	e.routine(&m.Partner)
+}
+
+func (d *decoder) message(m *dara.Message) {
+	m.Chan.Pc = uintptr(d.uvarint())
+	m.Chan.Index = d.int()
+	m.Len = d.int()
+	m.Cap = d.int()
+	m.Closed = d.bool()
+	d.routine(&m.Partner)
+}
+
+func (e *encoder) clock(c []dara.ClockEntry) {
+	e.length(len(c), c == nil)
+	for _, ce := range c {
+		e.int(ce.P)
+		e.int(ce.Gid)
+		e.uvarint(ce.Tick)
+	}
+}
+
+func (d *decoder) clock() []dara.ClockEntry {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	c := make([]dara.ClockEntry, n)
+	for i := range c {
+		c[i].P = d.int()
+		c[i].Gid = d.int()
+		c[i].Tick = d.uvarint()
+	}
+	return c
+}
+
+func (e *encoder) coverage(c *dara.CoverageEvent) {
+	e.length(len(c.CoverageInfo), c.CoverageInfo == nil)
+	keys := make([]string, 0, len(c.CoverageInfo))
+	for k := range c.CoverageInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.string(k)
+		e.uvarint(c.CoverageInfo[k])
+	}
+	e.float64(c.SnapshotCoverage)
+	e.float64(c.TotalCoverage)
+	e.int(c.EventIndex)
+}
+
+func (d *decoder) coverage() *dara.CoverageEvent {
+	c := new(dara.CoverageEvent)
+	if n := d.length(); n >= 0 {
+		c.CoverageInfo = make(map[string]uint64, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			k := d.string()
+			c.CoverageInfo[k] = d.uvarint()
+		}
+	}
+	c.SnapshotCoverage = d.float64()
+	c.TotalCoverage = d.float64()
+	c.EventIndex = d.int()
+	return c
+}
+
+func (e *encoder) propCheck(p *dara.PropCheckEvent) {
+	e.length(len(p.PropFailures), p.PropFailures == nil)
+	for _, f := range p.PropFailures {
+		e.string(f.Name)
+		e.valueMap(f.Context)
+	}
+	e.int(p.EventIndex)
+}
+
+func (d *decoder) propCheck() *dara.PropCheckEvent {
+	p := new(dara.PropCheckEvent)
+	if n := d.length(); n >= 0 {
+		p.PropFailures = make([]dara.FailedPropertyEvent, n)
+		for i := range p.PropFailures {
+			f := &p.PropFailures[i]
+			f.Name = d.string()
+			f.Context = d.valueMap()
+		}
+	}
+	p.EventIndex = d.int()
+	return p
+}
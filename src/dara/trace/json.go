@@ -0,0 +1,580 @@
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"dara"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// jsonTrace is the JSON form of a trace. Fixed size byte arrays are
+// stored as strings without their trailing zeros, and values are stored
+// along with their type.
+type jsonTrace struct {
+	Format     string
+	Header     *Header
+	Events     []jsonEvent
+	Coverage   []dara.CoverageEvent
+	PropChecks []jsonPropCheck
+}
+
+const jsonFormat = "dara-trace"
+
+type jsonRoutine struct {
+	Status       uint32
+	Gid          int
+	Gpc          uintptr
+	RoutineCount int
+	LogicalID    uint64
+	FuncInfo     string
+}
+
+type jsonVar struct {
+	VarName string
+	Value   *jsonValue
+	Type    string
+}
+
+type jsonGeneralType struct {
+	Type        dara.TypeNum
+	Integer     int
+	Bool        bool
+	Float       jsonFloat
+	Integer64   int64
+	String      string
+	Unsupported rune
+}
+
+type jsonSyscall struct {
+	SyscallNum int
+	NumArgs    int
+	NumRets    int
+	Args       []jsonGeneralType
+	Rets       []jsonGeneralType
+}
+
+type jsonEvent struct {
+	Type        int
+	P           int
+	G           jsonRoutine
+	Epoch       int
+	LogID       string
+	Vars        []jsonVar
+	SyscallInfo jsonSyscall
+	Msg         string
+	Select      *dara.SelectInfo  `json:",omitempty"`
+	Channel     *jsonChannel      `json:",omitempty"`
+	Clock       []dara.ClockEntry `json:",omitempty"`
+	Now         int64             `json:",omitempty"`
+	Fault       *dara.Fault       `json:",omitempty"`
+}
+
+// jsonChannel is everything but the Body of a dara.Message.
+type jsonChannel struct {
+	Chan    dara.ChanID
+	Len     int
+	Cap     int
+	Closed  bool
+	Partner jsonRoutine
+}
+
+type jsonFailure struct {
+	Name    string
+	Context []jsonPair
+}
+
+type jsonPropCheck struct {
+	PropFailures []jsonFailure
+	EventIndex   int
+}
+
+// jsonPair is an entry of a map, maps are stored as lists sorted by key
+// so that nil and empty maps both survive.
+type jsonPair struct {
+	Key   string
+	Value *jsonValue
+}
+
+// jsonValue is a value along with its Go type. Value holds a JSON
+// number for numeric types, apart from floats which are not finite.
+type jsonValue struct {
+	Type  string
+	Value json.RawMessage `json:",omitempty"`
+}
+
+// jsonFloat is a float64 which also encodes NaN and the infinities.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	return marshalFloat(float64(f), 64)
+}
+
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalFloat(data, 64)
+	*f = jsonFloat(v)
+	return err
+}
+
+func marshalFloat(f float64, bits int) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		// Keep the exact bits of NaNs.
+		if bits == 32 {
+			return json.Marshal("bits:" + strconv.FormatUint(uint64(math.Float32bits(float32(f))), 16))
+		}
+		return json.Marshal("bits:" + strconv.FormatUint(math.Float64bits(f), 16))
+	}
+	return []byte(strconv.FormatFloat(f, 'g', -1, bits)), nil
+}
+
+func unmarshalFloat(data []byte, bits int) (float64, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		if len(s) < 5 || s[:5] != "bits:" {
+			return 0, fmt.Errorf("trace: bad float %q", s)
+		}
+		u, err := strconv.ParseUint(s[5:], 16, bits)
+		if err != nil {
+			return 0, err
+		}
+		if bits == 32 {
+			return float64(math.Float32frombits(uint32(u))), nil
+		}
+		return math.Float64frombits(u), nil
+	}
+	return strconv.ParseFloat(string(data), bits)
+}
+
+func toJSONValue(v interface{}) (*jsonValue, error) {
+	var (
+		typ  string
+		data []byte
+		err  error
+	)
+	switch v := v.(type) {
+	case nil:
+		return &jsonValue{Type: "nil"}, nil
+	case bool:
+		typ = "bool"
+		data, err = json.Marshal(v)
+	case int:
+		typ, data = "int", []byte(strconv.FormatInt(int64(v), 10))
+	case int8:
+		typ, data = "int8", []byte(strconv.FormatInt(int64(v), 10))
+	case int16:
+		typ, data = "int16", []byte(strconv.FormatInt(int64(v), 10))
+	case int32:
+		typ, data = "int32", []byte(strconv.FormatInt(int64(v), 10))
+	case int64:
+		typ, data = "int64", []byte(strconv.FormatInt(v, 10))
+	case uint:
+		typ, data = "uint", []byte(strconv.FormatUint(uint64(v), 10))
+	case uint8:
+		typ, data = "uint8", []byte(strconv.FormatUint(uint64(v), 10))
+	case uint16:
+		typ, data = "uint16", []byte(strconv.FormatUint(uint64(v), 10))
+	case uint32:
+		typ, data = "uint32", []byte(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		typ, data = "uint64", []byte(strconv.FormatUint(v, 10))
+	case uintptr:
+		typ, data = "uintptr", []byte(strconv.FormatUint(uint64(v), 10))
+	case float32:
+		typ = "float32"
+		data, err = marshalFloat(float64(v), 32)
+	case float64:
+		typ = "float64"
+		data, err = marshalFloat(v, 64)
+	case string:
+		typ = "string"
+		data, err = json.Marshal(v)
+	case []byte:
+		typ = "[]byte"
+		data, err = json.Marshal(v)
+	case []interface{}:
+		typ = "[]interface{}"
+		var s []*jsonValue
+		if v != nil {
+			s = make([]*jsonValue, len(v))
+		}
+		for i, x := range v {
+			if s[i], err = toJSONValue(x); err != nil {
+				return nil, err
+			}
+		}
+		data, err = json.Marshal(s)
+	case map[string]interface{}:
+		typ = "map[string]interface{}"
+		var pairs []jsonPair
+		if pairs, err = toJSONPairs(v); err == nil {
+			data, err = json.Marshal(pairs)
+		}
+	default:
+		return nil, fmt.Errorf("trace: unsupported value type %T", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &jsonValue{Type: typ, Value: data}, nil
+}
+
+func toJSONPairs(m map[string]interface{}) ([]jsonPair, error) {
+	if m == nil {
+		return nil, nil
+	}
+	pairs := make([]jsonPair, 0, len(m))
+	for k, v := range m {
+		jv, err := toJSONValue(v)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, jsonPair{k, jv})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	return pairs, nil
+}
+
+func (jv *jsonValue) value() (interface{}, error) {
+	if jv == nil {
+		return nil, nil
+	}
+	var (
+		i   int64
+		u   uint64
+		err error
+	)
+	switch jv.Type {
+	case "int", "int8", "int16", "int32", "int64":
+		i, err = strconv.ParseInt(string(jv.Value), 10, 64)
+	case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr":
+		u, err = strconv.ParseUint(string(jv.Value), 10, 64)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch jv.Type {
+	case "nil":
+		return nil, nil
+	case "bool":
+		var b bool
+		err := json.Unmarshal(jv.Value, &b)
+		return b, err
+	case "int":
+		return int(i), nil
+	case "int8":
+		return int8(i), nil
+	case "int16":
+		return int16(i), nil
+	case "int32":
+		return int32(i), nil
+	case "int64":
+		return i, nil
+	case "uint":
+		return uint(u), nil
+	case "uint8":
+		return uint8(u), nil
+	case "uint16":
+		return uint16(u), nil
+	case "uint32":
+		return uint32(u), nil
+	case "uint64":
+		return u, nil
+	case "uintptr":
+		return uintptr(u), nil
+	case "float32":
+		f, err := unmarshalFloat(jv.Value, 32)
+		return float32(f), err
+	case "float64":
+		return unmarshalFloat(jv.Value, 64)
+	case "string":
+		var s string
+		err := json.Unmarshal(jv.Value, &s)
+		return s, err
+	case "[]byte":
+		var b []byte
+		err := json.Unmarshal(jv.Value, &b)
+		return b, err
+	case "[]interface{}":
+		var s []*jsonValue
+		if err := json.Unmarshal(jv.Value, &s); err != nil {
+			return nil, err
+		}
+		if s == nil {
+			return []interface{}(nil), nil
+		}
+		vs := make([]interface{}, len(s))
+		for i, x := range s {
+			if vs[i], err = x.value(); err != nil {
+				return nil, err
+			}
+		}
+		return vs, nil
+	case "map[string]interface{}":
+		var pairs []jsonPair
+		if err := json.Unmarshal(jv.Value, &pairs); err != nil {
+			return nil, err
+		}
+		return fromJSONPairs(pairs)
+	}
+	return nil, fmt.Errorf("trace: unknown value type %q", jv.Type)
+}
+
+func fromJSONPairs(pairs []jsonPair) (map[string]interface{}, error) {
+	if pairs == nil {
+		return nil, nil
+	}
+	m := make(map[string]interface{}, len(pairs))
+	for _, p := range pairs {
+		v, err := p.Value.value()
+		if err != nil {
+			return nil, err
+		}
+		m[p.Key] = v
+	}
+	return m, nil
+}
+
+func fixedString(b []byte) string {
+	return string(b[:trimmedLen(b)])
+}
+
+func toJSONGeneralTypes(ts []dara.GeneralType) []jsonGeneralType {
+	n := len(ts)
+	for n > 0 && ts[n-1] == (dara.GeneralType{}) {
+		n--
+	}
+	js := make([]jsonGeneralType, n)
+	for i := range js {
+		t := &ts[i]
+		js[i] = jsonGeneralType{t.Type, t.Integer, t.Bool, jsonFloat(t.Float), t.Integer64, t.String, t.Unsupported}
+	}
+	return js
+}
+
+func fromJSONGeneralTypes(dst []dara.GeneralType, js []jsonGeneralType) error {
+	if len(js) > len(dst) {
+		return fmt.Errorf("trace: %d syscall values, at most %d fit", len(js), len(dst))
+	}
+	for i, j := range js {
+		dst[i] = dara.GeneralType{Type: j.Type, Integer: j.Integer, Bool: j.Bool, Float: float32(j.Float), Integer64: j.Integer64, String: j.String, Unsupported: j.Unsupported}
+	}
+	return nil
+}
+
+func toJSONRoutine(r *dara.RoutineInfo) jsonRoutine {
+	return jsonRoutine{
+		Status:       r.Status,
+		Gid:          r.Gid,
+		Gpc:          r.Gpc,
+		RoutineCount: r.RoutineCount,
+		LogicalID:    r.LogicalID,
+		FuncInfo:     fixedString(r.FuncInfo[:]),
+	}
+}
+
+func fromJSONRoutine(r *dara.RoutineInfo, jr *jsonRoutine) error {
+	*r = dara.RoutineInfo{Status: jr.Status, Gid: jr.Gid, Gpc: jr.Gpc, RoutineCount: jr.RoutineCount, LogicalID: jr.LogicalID}
+	if len(jr.FuncInfo) > len(r.FuncInfo) {
+		return fmt.Errorf("trace: FuncInfo of %d bytes does not fit", len(jr.FuncInfo))
+	}
+	copy(r.FuncInfo[:], jr.FuncInfo)
+	return nil
+}
+
+func toJSONEvent(e *dara.Event) (jsonEvent, error) {
+	je := jsonEvent{
+		Type:  e.Type,
+		P:     e.P,
+		G:     toJSONRoutine(&e.G),
+		Epoch: e.Epoch,
+		LogID: e.LE.LogID,
+		SyscallInfo: jsonSyscall{
+			SyscallNum: e.SyscallInfo.SyscallNum,
+			NumArgs:    e.SyscallInfo.NumArgs,
+			NumRets:    e.SyscallInfo.NumRets,
+			Args:       toJSONGeneralTypes(e.SyscallInfo.Args[:]),
+			Rets:       toJSONGeneralTypes(e.SyscallInfo.Rets[:]),
+		},
+		Msg: e.Msg.Body,
+	}
+	if e.Select.Ready != nil || e.Select.Chosen != 0 {
+		sel := e.Select
+		je.Select = &sel
+	}
+	if m := &e.Msg; m.Chan != (dara.ChanID{}) || m.Len != 0 || m.Cap != 0 || m.Closed || m.Partner != (dara.RoutineInfo{}) {
+		je.Channel = &jsonChannel{Chan: m.Chan, Len: m.Len, Cap: m.Cap, Closed: m.Closed, Partner: toJSONRoutine(&m.Partner)}
+	}
+	je.Clock = e.Clock
+	je.Now = e.Now
+	if e.Fault.Call != 0 {
+		f := e.Fault
+		je.Fault = &f
+	}
+	if e.LE.Vars != nil {
+		je.Vars = make([]jsonVar, len(e.LE.Vars))
+	}
+	for i, v := range e.LE.Vars {
+		jv, err := toJSONValue(v.Value)
+		if err != nil {
+			return je, err
+		}
+		je.Vars[i] = jsonVar{v.VarName, jv, v.Type}
+	}
+	return je, nil
+}
+
+func fromJSONEvent(je *jsonEvent) (dara.Event, error) {
+	e := dara.Event{
+		Type:  je.Type,
+		P:     je.P,
+		Epoch: je.Epoch,
+		Msg:   dara.Message{Body: je.Msg},
+	}
+	if je.Select != nil {
+		e.Select = *je.Select
+	}
+	if err := fromJSONRoutine(&e.G, &je.G); err != nil {
+		return e, err
+	}
+	e.Clock = je.Clock
+	e.Now = je.Now
+	if je.Fault != nil {
+		e.Fault = *je.Fault
+	}
+	if jc := je.Channel; jc != nil {
+		e.Msg.Chan = jc.Chan
+		e.Msg.Len = jc.Len
+		e.Msg.Cap = jc.Cap
+		e.Msg.Closed = jc.Closed
+		if err := fromJSONRoutine(&e.Msg.Partner, &jc.Partner); err != nil {
+			return e, err
+		}
+	}
+	e.LE.LogID = je.LogID
+	if je.Vars != nil {
+		e.LE.Vars = make([]dara.NameValuePair, len(je.Vars))
+	}
+	for i, jv := range je.Vars {
+		v, err := jv.Value.value()
+		if err != nil {
+			return e, err
+		}
+		e.LE.Vars[i] = dara.NameValuePair{VarName: jv.VarName, Value: v, Type: jv.Type}
+	}
+	s := &e.SyscallInfo
+	s.SyscallNum = je.SyscallInfo.SyscallNum
+	s.NumArgs = je.SyscallInfo.NumArgs
+	s.NumRets = je.SyscallInfo.NumRets
+	if err := fromJSONGeneralTypes(s.Args[:], je.SyscallInfo.Args); err != nil {
+		return e, err
+	}
+	if err := fromJSONGeneralTypes(s.Rets[:], je.SyscallInfo.Rets); err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+// WriteJSON writes h and s to w in the JSON form. h.Version is set to
+// Version.
+func WriteJSON(w io.Writer, h *Header, s *dara.Schedule) error {
+	h.Version = Version
+	jt := jsonTrace{Format: jsonFormat, Header: h, Coverage: s.CovEvents}
+	for i := range s.LogEvents {
+		je, err := toJSONEvent(&s.LogEvents[i])
+		if err != nil {
+			return err
+		}
+		jt.Events = append(jt.Events, je)
+	}
+	for _, p := range s.PropEvents {
+		jp := jsonPropCheck{EventIndex: p.EventIndex}
+		if p.PropFailures != nil {
+			jp.PropFailures = make([]jsonFailure, len(p.PropFailures))
+		}
+		for i, f := range p.PropFailures {
+			ctx, err := toJSONPairs(f.Context)
+			if err != nil {
+				return err
+			}
+			jp.PropFailures[i] = jsonFailure{f.Name, ctx}
+		}
+		jt.PropChecks = append(jt.PropChecks, jp)
+	}
+	data, err := json.MarshalIndent(&jt, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadJSON reads a trace in the JSON form.
+func ReadJSON(r io.Reader) (*Header, *dara.Schedule, error) {
+	var jt jsonTrace
+	if err := json.NewDecoder(r).Decode(&jt); err != nil {
+		return nil, nil, err
+	}
+	if jt.Format != jsonFormat || jt.Header == nil {
+		return nil, nil, fmt.Errorf("trace: not a JSON Dara trace")
+	}
+	if v := jt.Header.Version; v <= 0 || v > Version {
+		return nil, nil, fmt.Errorf("trace: unsupported format version %d, this reader supports up to %d", v, Version)
+	}
+	s := &dara.Schedule{CovEvents: jt.Coverage}
+	for i := range jt.Events {
+		e, err := fromJSONEvent(&jt.Events[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		s.LogEvents = append(s.LogEvents, e)
+	}
+	for _, jp := range jt.PropChecks {
+		p := dara.PropCheckEvent{EventIndex: jp.EventIndex}
+		if jp.PropFailures != nil {
+			p.PropFailures = make([]dara.FailedPropertyEvent, len(jp.PropFailures))
+		}
+		for i, f := range jp.PropFailures {
+			ctx, err := fromJSONPairs(f.Context)
+			if err != nil {
+				return nil, nil, err
+			}
+			p.PropFailures[i] = dara.FailedPropertyEvent{Name: f.Name, Context: ctx}
+		}
+		s.PropEvents = append(s.PropEvents, p)
+	}
+	return jt.Header, s, nil
+}
+
+// Write writes h and s to w in the binary form.
+func Write(w io.Writer, h *Header, s *dara.Schedule) error {
+	tw, err := NewWriter(w, h)
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteSchedule(s); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// Read reads a trace in either form.
+func Read(r io.Reader) (*Header, *dara.Schedule, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(Magic))
+	if err == nil && bytes.Equal(magic, []byte(Magic)) {
+		tr, err := NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, err := tr.ReadSchedule()
+		return tr.Header(), s, err
+	}
+	return ReadJSON(br)
+}
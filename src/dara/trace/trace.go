@@ -0,0 +1,91 @@
+// Package trace reads and writes recorded Dara executions.
+//
+// A trace holds a Header describing the processes that were run followed
+// by the events, coverage and property checks collected by the global
+// scheduler, in the order they were collected. Traces come in two forms.
+//
+// The binary form is meant for archiving and streaming. It starts with
+// the 8 byte magic "DARATRC\n" and the format version as a uvarint,
+// followed by a sequence of records. Each record is a one byte kind, the
+// length of its payload as a uvarint and the payload. The first record is
+// always the header. Readers skip records whose kind they do not know,
+// and refuse traces written by a newer version of the format. Integers
+// are varint encoded, floats are stored by their IEEE 754 bits and
+// strings and byte arrays are length prefixed, so every trace reads back
+// exactly as it was written.
+//
+// The JSON form holds the same information as a single object and is
+// meant to be read and edited by people. It round-trips exactly as long
+// as every string in the trace is valid UTF-8.
+package trace
+
+import (
+	"dara"
+	"fmt"
+)
+
+// Version is the version of the format written by this package. It is
+// bumped every time the encoding of a record changes.
+//
+// Version 2 adds the select information of events, version 3 the
+// channel information of messages, version 4 the vector clocks of
+// events, version 5 the logical IDs of goroutines, version 6 the
+// virtual time of events and version 7 the faults injected into
+// syscalls.
+const Version = 7
+
+// Magic starts every binary trace.
+const Magic = "DARATRC\n"
+
+// Header describes the execution a trace was recorded from.
+type Header struct {
+	// Version is the format version the trace was written with. It is
+	// filled in by the writers.
+	Version int
+	// Procs describes the processes of the cluster, in DARAPID order.
+	Procs []Proc
+}
+
+// Proc describes one process of a recorded cluster.
+type Proc struct {
+	// PID is the DARAPID of the process.
+	PID int
+	// Path is the binary that was run.
+	Path string
+	// BuildID is the Go build ID of the binary, see ReadBuildID. A
+	// schedule only replays against the binary it was recorded from.
+	BuildID string
+}
+
+// Kind identifies the type of a record.
+type Kind byte
+
+const (
+	KindHeader Kind = iota + 1
+	KindEvent
+	KindCoverage
+	KindPropCheck
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindHeader:
+		return "header"
+	case KindEvent:
+		return "event"
+	case KindCoverage:
+		return "coverage"
+	case KindPropCheck:
+		return "propcheck"
+	}
+	return fmt.Sprintf("kind(%d)", byte(k))
+}
+
+// Record is a single record of a trace, one of Event, Coverage and
+// PropCheck is set according to Kind.
+type Record struct {
+	Kind      Kind
+	Event     *dara.Event
+	Coverage  *dara.CoverageEvent
+	PropCheck *dara.PropCheckEvent
+}
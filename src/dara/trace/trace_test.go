@@ -0,0 +1,283 @@
+package trace
+
+import (
+	"bytes"
+	"dara"
+	"internal/testenv"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testHeader() *Header {
+	return &Header{
+		Procs: []Proc{
+			{PID: 1, Path: "/tmp/server", BuildID: "abc/def"},
+			{PID: 2, Path: "/tmp/client", BuildID: "ghi/jkl"},
+		},
+	}
+}
+
+func testSchedule() *dara.Schedule {
+	g := dara.RoutineInfo{Status: uint32(dara.Runnable), Gid: 7, Gpc: 0x4520ad, RoutineCount: 2, LogicalID: dara.ChildID(dara.ROOTID, 1)}
+	copy(g.FuncInfo[:], "main.worker")
+	var call dara.GeneralSyscall
+	call.SyscallNum = 3
+	call.NumArgs = 3
+	call.NumRets = 2
+	call.Args[0] = dara.GeneralType{Type: dara.STRING}
+	call.Args[0].String = "/etc/hosts"
+	call.Args[1] = dara.GeneralType{Type: dara.INTEGER, Integer: -1}
+	call.Args[2] = dara.GeneralType{Type: dara.STRING, String: "/" + strings.Repeat("long/path/", 100)}
+	call.Rets[0] = dara.GeneralType{Type: dara.FLOAT, Float: 0.1}
+	call.Rets[1] = dara.GeneralType{Type: dara.INTEGER64, Integer64: math.MinInt64, Bool: true, Unsupported: 'x'}
+	return &dara.Schedule{
+		LogEvents: []dara.Event{
+			{Type: dara.INIT_EVENT, P: 1, G: g},
+			{Type: dara.SCHED_EVENT, P: 1, G: g, Epoch: 3, Clock: []dara.ClockEntry{{P: 1, Gid: 7, Tick: 3}, {P: 2, Gid: 1, Tick: 1 << 40}}, Now: 1500 * 1e6},
+			{Type: dara.LOG_EVENT, P: 2, G: g, LE: dara.LogEntry{
+				LogID: "main.go:12",
+				Vars: []dara.NameValuePair{
+					{VarName: "n", Value: 42, Type: "int"},
+					{VarName: "f32", Value: float32(1.5), Type: "float32"},
+					{VarName: "f64", Value: math.Pi, Type: "float64"},
+					{VarName: "nan", Value: math.NaN(), Type: "float64"},
+					{VarName: "inf", Value: math.Inf(-1), Type: "float64"},
+					{VarName: "u", Value: uint64(math.MaxUint64), Type: "uint64"},
+					{VarName: "i8", Value: int8(-8), Type: "int8"},
+					{VarName: "p", Value: uintptr(0xdead), Type: "uintptr"},
+					{VarName: "ok", Value: true, Type: "bool"},
+					{VarName: "s", Value: "héllo", Type: "string"},
+					{VarName: "b", Value: []byte{0, 1, 2}, Type: "[]byte"},
+					{VarName: "nilb", Value: []byte(nil), Type: "[]byte"},
+					{VarName: "none", Value: nil, Type: ""},
+					{VarName: "list", Value: []interface{}{1, "two", []interface{}{int32(3)}, []interface{}(nil)}, Type: "[]int"},
+					{VarName: "m", Value: map[string]interface{}{"a": 1, "b": map[string]interface{}{}}, Type: "map"},
+				},
+			}},
+			{Type: dara.LOG_EVENT, P: 2, LE: dara.LogEntry{LogID: "empty", Vars: []dara.NameValuePair{}}},
+			{Type: dara.SYSCALL_EVENT, P: 2, G: g, SyscallInfo: call},
+			{Type: dara.SYSCALL_EVENT, P: 1, G: g, SyscallInfo: dara.GeneralSyscall{SyscallNum: dara.DSYS_WRITE}, Fault: dara.Fault{Syscall: dara.DSYS_WRITE, Call: 3, Errno: 28}},
+			{Type: dara.SEND_EVENT, P: 1, G: g, Msg: dara.Message{
+				Chan:    dara.ChanID{Pc: 0x4521c0, Index: 3},
+				Partner: g,
+			}},
+			{Type: dara.REC_EVENT, P: 1, Msg: dara.Message{Chan: dara.ChanID{Pc: 0x4521c0}, Len: 1, Cap: 4, Closed: true}},
+			{Type: dara.SELECT_EVENT, P: 1, G: g, Select: dara.SelectInfo{
+				Ready:  []dara.SelectCase{{Index: 0, PC: 0x4520ad}, {Index: 2, PC: 0x4520f1}},
+				Chosen: 2,
+			}},
+			dara.NetEvent(2, 1, dara.NET_DROP),
+			{Type: dara.MESSAGE_EVENT, P: 1, G: g, Msg: dara.Message{Body: "ping\x00"}, SyscallInfo: dara.GeneralSyscall{
+				SyscallNum: dara.DSYS_MESSAGE,
+				NumArgs:    5,
+				Args: [10]dara.GeneralType{
+					{Type: dara.INTEGER64, Integer64: 1},
+					{Type: dara.INTEGER, Integer: 2},
+					{Type: dara.STRING, String: "127.0.0.1:4000>127.0.0.1:9000"},
+					{Type: dara.INTEGER, Integer: dara.MESSAGE_HELD},
+					{Type: dara.INTEGER, Integer: 5},
+				},
+			}},
+			{Type: dara.END_EVENT, P: 1, Msg: dara.Message{Body: "bye"}},
+		},
+		CovEvents: []dara.CoverageEvent{
+			{CoverageInfo: map[string]uint64{"a.go:1": 3, "a.go:2": 1}, SnapshotCoverage: 0.5, TotalCoverage: 0.75, EventIndex: 1},
+			{CoverageInfo: map[string]uint64{}, EventIndex: 2},
+			{EventIndex: 3},
+		},
+		PropEvents: []dara.PropCheckEvent{
+			dara.CreatePropCheckEvent([]dara.FailedPropertyEvent{
+				{Name: "OneLeader", Context: map[string]interface{}{"leaders": []interface{}{1, 2}, "term": int64(4)}},
+				{Name: "NoContext"},
+			}, 5),
+			dara.CreatePropCheckEvent(nil, 6),
+		},
+	}
+}
+
+// sameSchedule compares schedules with NaNs compared by bits.
+func sameSchedule(t *testing.T, got, want *dara.Schedule) {
+	t.Helper()
+	if !reflect.DeepEqual(nanFree(got), nanFree(want)) {
+		t.Errorf("schedule did not round-trip\ngot  %#v\nwant %#v", got, want)
+	}
+}
+
+// nanFree replaces float NaNs in logged values by their bits, as NaN is
+// not equal to itself.
+func nanFree(s *dara.Schedule) *dara.Schedule {
+	c := *s
+	c.LogEvents = append([]dara.Event(nil), s.LogEvents...)
+	for i := range c.LogEvents {
+		vars := c.LogEvents[i].LE.Vars
+		if vars == nil {
+			continue
+		}
+		vars = append([]dara.NameValuePair{}, vars...)
+		for j := range vars {
+			if f, ok := vars[j].Value.(float64); ok && f != f {
+				vars[j].Value = math.Float64bits(f)
+			}
+		}
+		c.LogEvents[i].LE.Vars = vars
+	}
+	return &c
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	want := testSchedule()
+	if err := Write(&buf, testHeader(), want); err != nil {
+		t.Fatal(err)
+	}
+	h, got, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHeader := testHeader()
+	wantHeader.Version = Version
+	if !reflect.DeepEqual(h, wantHeader) {
+		t.Errorf("header = %+v, want %+v", h, wantHeader)
+	}
+	sameSchedule(t, got, want)
+
+	// Writing what was read gives the same bytes.
+	var again bytes.Buffer
+	if err := Write(&again, h, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Error("re-encoding a trace changed it")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	want := testSchedule()
+	if err := WriteJSON(&buf, testHeader(), want); err != nil {
+		t.Fatal(err)
+	}
+	h, got, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version != Version || len(h.Procs) != 2 || h.Procs[1].BuildID != "ghi/jkl" {
+		t.Errorf("header = %+v", h)
+	}
+	sameSchedule(t, got, want)
+}
+
+func TestStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, testHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := testSchedule()
+	if err := w.WriteEvent(&s.LogEvents[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteCoverage(&s.CovEvents[0]); err != nil {
+		t.Fatal(err)
+	}
+	// An unsupported value fails its record but leaves the trace usable.
+	bad := dara.Event{LE: dara.LogEntry{Vars: []dara.NameValuePair{{Value: struct{}{}}}}}
+	if err := w.WriteEvent(&bad); err == nil {
+		t.Error("wrote an event with an unsupported value")
+	}
+	if err := w.WriteEvent(&s.LogEvents[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []Kind
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		kinds = append(kinds, rec.Kind)
+	}
+	if want := []Kind{KindEvent, KindCoverage, KindEvent}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("records = %v, want %v", kinds, want)
+	}
+}
+
+func TestBadTraces(t *testing.T) {
+	// Write the trace record by record to learn where records end.
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, testHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	boundary := map[int]bool{buf.Len(): true}
+	s := testSchedule()
+	for i := range s.LogEvents {
+		if err := w.WriteEvent(&s.LogEvents[i]); err != nil {
+			t.Fatal(err)
+		}
+		w.Flush()
+		boundary[buf.Len()] = true
+	}
+	good := buf.Bytes()
+
+	// Every truncation is an error, apart from those between records.
+	for n := 0; n < len(good); n++ {
+		_, _, err := Read(bytes.NewReader(good[:n]))
+		if boundary[n] && err != nil {
+			t.Errorf("reading %d bytes ending a record: %v", n, err)
+		}
+		if !boundary[n] && err == nil {
+			t.Errorf("reading %d bytes, in the middle of a record, succeeded", n)
+		}
+	}
+
+	// A newer version is refused.
+	newer := append([]byte{}, good...)
+	newer[len(Magic)] = Version + 1
+	if _, err := NewReader(bytes.NewReader(newer)); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("reading a newer trace: %v", err)
+	}
+
+	// Unknown records are skipped.
+	withUnknown := append(append([]byte{}, good...), 200, 2, 'h', 'i')
+	_, got, err := Read(bytes.NewReader(withUnknown))
+	if err != nil {
+		t.Fatalf("reading a trace with an unknown record: %v", err)
+	}
+	sameSchedule(t, got, &dara.Schedule{LogEvents: s.LogEvents})
+}
+
+func TestReadBuildID(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	id, err := ReadBuildID(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(testenv.GoToolPath(t), "tool", "buildid", exe).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.TrimSpace(string(out)); id != want {
+		t.Errorf("ReadBuildID = %q, go tool buildid says %q", id, want)
+	}
+}
@@ -1,5 +1,7 @@
 package dara
 
+import "unsafe"
+
 type TypeNum int
 
 const (
@@ -34,16 +36,285 @@ const (
 	CRASH_EVENT
 	DELETEVAR_EVENT
 	TIMER_EVENT
+	SELECT_EVENT
+	NET_EVENT
+	MESSAGE_EVENT
+)
+
+//What happened to a timer, logged as the fourth argument of a
+//TIMER_EVENT after the timer's ID, the virtual time it is due at and
+//its period. The timers a process has ARMED and which have not FIRED
+//or been CANCELLED are the ones the global scheduler may fire, a
+//periodic timer is ARMED again with its next due time every time it
+//fires. A FIRED timer is followed by the goid and the logical ID of
+//the goroutine it readied, or 0 if it readied none.
+const (
+	TIMER_ARMED = iota
+	TIMER_CANCELLED
+	TIMER_FIRED
+)
+
+//TimerOp returns the ID of the timer a TIMER_EVENT is about and what
+//happened to it. Timers were only ever armed by the events of older
+//runtimes, which leave the operation out.
+func (e *Event) TimerOp() (id int64, op int, ok bool) {
+	if e.Type != TIMER_EVENT || e.SyscallInfo.NumArgs < 2 {
+		return 0, 0, false
+	}
+	op = TIMER_ARMED
+	if e.SyscallInfo.NumArgs > 3 {
+		op = e.SyscallInfo.Args[3].Integer
+	}
+	return e.SyscallInfo.Args[0].Integer64, op, true
+}
+
+//InitSeed returns the DARA_SEED the process of an INIT_EVENT was
+//seeded with. Older runtimes did not log it, they always ran with 0.
+func (e *Event) InitSeed() (seed int64, ok bool) {
+	if e.Type != INIT_EVENT || e.SyscallInfo.NumArgs < 1 {
+		return 0, false
+	}
+	return e.SyscallInfo.Args[0].Integer64, true
+}
+
+//InitHoldMessages reports whether the process of an INIT_EVENT ran
+//with DARA_HOLD_MESSAGES. Older runtimes did not log it, they never
+//held messages.
+func (e *Event) InitHoldMessages() bool {
+	if e.Type != INIT_EVENT || e.SyscallInfo.NumArgs < 3 {
+		return false
+	}
+	return e.SyscallInfo.Args[2].Bool
+}
+
+//InitVirtualNet reports whether the process of an INIT_EVENT ran with
+//DARA_VIRTUAL_NET. Older runtimes did not log it, they always used the
+//network of the kernel.
+func (e *Event) InitVirtualNet() bool {
+	if e.Type != INIT_EVENT || e.SyscallInfo.NumArgs < 4 {
+		return false
+	}
+	return e.SyscallInfo.Args[3].Bool
+}
+
+//What happened to a message one Dara process wrote to a connection to
+//another, logged by MESSAGE_EVENTs. A runtime run with
+//DARA_HOLD_MESSAGES does not write the message to the connection
+//straight away, it HELD it for the global scheduler to deliver with
+//CMD_DELIVER_MESSAGE. A delivery writes as much of the message as the
+//connection takes, the rest stays held. The messages still held when
+//their connection is closed are FLUSHED by the runtime itself. The
+//messages held on a connection are delivered in the order they were
+//written.
+const (
+	MESSAGE_HELD = iota
+	MESSAGE_DELIVERED
+	MESSAGE_FLUSHED
 )
 
+//HeldMessage describes a MESSAGE_EVENT. The bytes of a HELD message
+//are the Body of the Msg of the event.
+type HeldMessage struct {
+	//ID counts the messages held by the sending process from 1
+	ID int64
+	//Dst is the DARAPID of the process the message is sent to
+	Dst int
+	//Conn names the connection, by its local and remote address
+	//joined with a '>'
+	Conn string
+	//Op is one of the MESSAGE_ constants
+	Op int
+	//Len is the number of bytes held, delivered or flushed
+	Len int
+}
+
+//HeldMessage returns what a MESSAGE_EVENT did to which message
+func (e *Event) HeldMessage() (HeldMessage, bool) {
+	if e.Type != MESSAGE_EVENT || e.SyscallInfo.NumArgs < 5 {
+		return HeldMessage{}, false
+	}
+	args := &e.SyscallInfo.Args
+	return HeldMessage{
+		ID:   args[0].Integer64,
+		Dst:  args[1].Integer,
+		Conn: args[2].String,
+		Op:   args[3].Integer,
+		Len:  args[4].Integer,
+	}, true
+}
+
+//InitSyscallPoints returns the DARA_SYSCALL_POINTS the process of an
+//INIT_EVENT ran with. Older runtimes did not log it, they had none.
+func (e *Event) InitSyscallPoints() (points int, ok bool) {
+	if e.Type != INIT_EVENT || e.SyscallInfo.NumArgs < 2 {
+		return 0, false
+	}
+	return e.SyscallInfo.Args[1].Integer, true
+}
+
+//What happens to the data a Dara process exchanges with another one
+//over a connection, set by the global scheduler in DaraProc.Links.
+//NET_DROP loses it: writes report success without sending anything and
+//reads discard what they get. NET_DELAY holds a goroutine about to
+//write, or which has read something, at a scheduling point until the
+//link is no longer delayed. NET_DUPLICATE sends every write twice.
+//NET_RESET fails reads and writes with ECONNRESET and shuts the
+//connection down.
+const (
+	NET_DELIVER = iota
+	NET_DROP
+	NET_DELAY
+	NET_DUPLICATE
+	NET_RESET
+	numNetActions
+)
+
+var netActionStrings = [...]string{
+	NET_DELIVER:   "deliver",
+	NET_DROP:      "drop",
+	NET_DELAY:     "delay",
+	NET_DUPLICATE: "duplicate",
+	NET_RESET:     "reset",
+}
+
+//NetActionString returns the name of the NET_ action a
+func NetActionString(a int) string {
+	if a < 0 || a >= numNetActions {
+		return "unknown"
+	}
+	return netActionStrings[a]
+}
+
+//ParseNetAction returns the NET_ action named s
+func ParseNetAction(s string) (int, bool) {
+	for a, name := range netActionStrings {
+		if name == s {
+			return a, true
+		}
+	}
+	return 0, false
+}
+
+//NetEvent returns the NET_EVENT the global scheduler logs when it sets
+//the link from the process local to the process remote to action.
+//Unlike every other event it is not logged by a runtime, it goes
+//straight into the schedule.
+func NetEvent(local, remote, action int) Event {
+	e := Event{Type: NET_EVENT, P: local}
+	e.SyscallInfo.NumArgs = 2
+	e.SyscallInfo.Args[0] = GeneralType{Type: INTEGER, Integer: remote}
+	e.SyscallInfo.Args[1] = GeneralType{Type: INTEGER, Integer: action}
+	return e
+}
+
+//NetLink returns the link a NET_EVENT set and what it set it to
+func (e *Event) NetLink() (remote, action int, ok bool) {
+	if e.Type != NET_EVENT || e.SyscallInfo.NumArgs < 2 {
+		return 0, 0, false
+	}
+	return e.SyscallInfo.Args[0].Integer, e.SyscallInfo.Args[1].Integer, true
+}
+
+//SharedHeader is stored at offset 0 of the shared memory mapped at
+//DARAFD. The global scheduler fills it in before launching any
+//runtime, and each runtime reads it to check that both sides agree on
+//the layout of shared memory, to find out how many DaraProcs follow
+//the header and where its own one lives. Magic and Version must stay
+//the first two fields so that any version can be identified.
+type SharedHeader struct {
+	//Magic is always SHMMAGIC
+	Magic uint64
+	//Version is the SHMVERSION the scheduler was built with
+	Version uint64
+	//Sizes of the shared structures as seen by the scheduler
+	DaraProcSize       uint64
+	EncEventSize       uint64
+	RoutineInfoSize    uint64
+	GeneralSyscallSize uint64
+	//Features is the set of FEATURE_ bits the scheduler requires
+	Features uint64
+	//NumProcs is the number of DaraProc slots in shared memory. Valid
+	//DARAPIDs run from 1 to NumProcs inclusive.
+	NumProcs int
+	//ProcStride is the distance in bytes between the start of two
+	//consecutive DaraProc slots. It must be at least the size of a
+	//DaraProc, any extra is padding.
+	ProcStride uintptr
+	//WallClock is the Unix time in nanoseconds that a virtual time of
+	//0 corresponds to, see DaraProc.Now
+	WallClock int64
+}
+
+//NewSharedHeader returns the header describing numProcs DaraProcs laid
+//out the way this package defines them, with every supported feature
+//requested.
+func NewSharedHeader(numProcs int) SharedHeader {
+	return SharedHeader{
+		Magic:              SHMMAGIC,
+		Version:            SHMVERSION,
+		DaraProcSize:       uint64(unsafe.Sizeof(DaraProc{})),
+		EncEventSize:       uint64(unsafe.Sizeof(EncEvent{})),
+		RoutineInfoSize:    uint64(unsafe.Sizeof(RoutineInfo{})),
+		GeneralSyscallSize: uint64(unsafe.Sizeof(EncGeneralSyscall{})),
+		Features:           FEATURES,
+		NumProcs:           numProcs,
+		ProcStride:         DARAPROCSIZE,
+		WallClock:          VIRTUALEPOCH,
+	}
+}
+
+//Mismatch compares the header against the layout this package was
+//built with. It returns the name of the first field that disagrees
+//along with the local and the header's value, or an empty name if the
+//header describes a compatible layout. For Features the values are
+//the supported bits and the requested bits.
+func (h *SharedHeader) Mismatch() (field string, local, shared uint64) {
+	want := NewSharedHeader(h.NumProcs)
+	switch {
+	case h.Magic != want.Magic:
+		return "Magic", want.Magic, h.Magic
+	case h.Version != want.Version:
+		return "Version", want.Version, h.Version
+	case h.DaraProcSize != want.DaraProcSize:
+		return "DaraProcSize", want.DaraProcSize, h.DaraProcSize
+	case h.EncEventSize != want.EncEventSize:
+		return "EncEventSize", want.EncEventSize, h.EncEventSize
+	case h.RoutineInfoSize != want.RoutineInfoSize:
+		return "RoutineInfoSize", want.RoutineInfoSize, h.RoutineInfoSize
+	case h.GeneralSyscallSize != want.GeneralSyscallSize:
+		return "GeneralSyscallSize", want.GeneralSyscallSize, h.GeneralSyscallSize
+	case h.Features&^FEATURES != 0:
+		return "Features", FEATURES, h.Features
+	case uint64(h.ProcStride) < want.DaraProcSize:
+		return "ProcStride", want.DaraProcSize, uint64(h.ProcStride)
+	}
+	return "", 0, 0
+}
+
+//ProcOffset returns the offset into shared memory of the DaraProc
+//belonging to DARAPID pid.
+func (h *SharedHeader) ProcOffset(pid int) uintptr {
+	return HEADERSIZE + uintptr(pid-1)*h.ProcStride
+}
+
+//ValidPid reports whether pid names one of the DaraProc slots
+//described by the header.
+func (h *SharedHeader) ValidPid(pid int) bool {
+	return pid >= 1 && pid <= h.NumProcs
+}
 
+//MapSize returns the number of bytes that must be mapped to cover the
+//header and every DaraProc slot.
+func (h *SharedHeader) MapSize() uintptr {
+	return HEADERSIZE + uintptr(h.NumProcs)*h.ProcStride
+}
 
 //DaraProc is used to communicate control and data information between
 //a single instrumented go runtime and the global scheduler. One of
 //these structures is mapped into shared memory for each process that
-//is launched during an execution. If there are more runtimes active
-//Than DaraProcs the additional runtimes will not be controlled by the
-//global scheduler, or Segfault immediately 
+//is launched during an execution. The number of DaraProcs is set by
+//the global scheduler in the SharedHeader, a runtime whose DARAPID
+//does not name one of them refuses to start.
 type DaraProc struct {
 
 	//Lock is used to control the execution of a process. A process
@@ -52,26 +323,34 @@ type DaraProc struct {
 	//the state of the DaraProc
 	Lock uint32
 
-	//SyscallLock is used to control the reporting of the syscalls.
-	SyscallLock uint32
+	//CmdSignal is incremented by the global scheduler every time it
+	//issues a command, and ReplySignal by the runtime every time it
+	//replies. Each side sleeps on the other's word with futex while it
+	//waits, instead of spinning on Lock.
+	CmdSignal   uint32
+	ReplySignal uint32
 
 	//PID is the Linux Process ID. Since we are limited to GOMAXPROCS=1
 	//there is only 1 system thread/process ID.
 	PID uint64
 
-	//Run is a deprecated var with multiple purposes. Procs set their
-	//Run to -1 when they Are done running (in replay mode) to let the
-	//scheduler know they are done. The global scheduler sets this
-	//value to 2 to let the runtime know its replay, and 3 for record
-	//1 is used to denote the first event, and 0 indicates this
-	//variable has not been initialized Originally Run was intended to
-	//report the id of the goroutine that was executed, but that was
-	//not always the same so the program counter  was needed, now
-	//RunningRoutine is used to report this. The global scheduler sets
-	// this to -4 to inform the local schedulers that replay is ended
-	Run int
-	// Syscall number at which the running routine is blocked on. -1 means that
-	// there is no syscall on which the daraproc is blocked
+	//Cmd is the last command issued by the global scheduler, see
+	//protocol.go for how the command channel is used
+	Cmd Command
+	//CmdSeq is incremented by the global scheduler with every command
+	CmdSeq uint64
+	//CmdArg is the argument of commands that need one, the timer id
+	//for CMD_FIRE_TIMER
+	CmdArg int64
+	//Reply is the runtime's answer to the last command
+	Reply Reply
+	//ReplySeq is the CmdSeq of the last command the runtime answered
+	ReplySeq uint64
+	//State is the runtime's position in the command state machine
+	State ProcState
+	//Syscall is the syscall the goroutine which answered the last
+	//command is about to make, if it stopped at a syscall point. -1
+	//means that it stopped anywhere else
 	Syscall int
 	//RunningRoutine is the goroutine scheduled, running, or ran, for
 	//any single replayed event in a schedule. In Record, the
@@ -87,10 +366,159 @@ type DaraProc struct {
 	Routines [MAXGOROUTINES]RoutineInfo
 	//TODO document
 	Epoch int
-	LogIndex int
+	//Select lists the ready cases of the select the runtime asks the
+	//global scheduler to choose from with REPLY_SELECT
+	Select EncSelectInfo
+	//Now is the virtual clock of the process in nanoseconds. The
+	//runtime returns it from time.Now, time.Since, the deadlines of
+	//timers and everything else a program can observe time through,
+	//and it only moves when the global scheduler moves it: before
+	//issuing a command, or by firing a timer, which advances it to the
+	//time the timer was due at. It never goes backwards.
+	Now int64
+	//Log is a ring of events written by the runtime and read by the
+	//global scheduler. LogHead is the number of events the runtime has
+	//written and LogTail the number the global scheduler has read, both
+	//only ever grow and the event numbered i lives in
+	//Log[i%MAXLOGENTRIES]. The runtime stores LogHead atomically once an
+	//event is complete, and never writes an event the global scheduler
+	//has not read. When the ring is full it futex wakes Lock and sleeps
+	//on LogTail, so the global scheduler drains the ring whenever it is
+	//woken up while waiting for Lock, and futex wakes LogTail after
+	//storing it.
+	LogHead uint32
+	LogTail uint32
 	Log [MAXLOGENTRIES]EncEvent
+	//Arena holds the variable length data of the events in Log, which
+	//refer to it with ArenaRefs. It is used as a ring in the same way
+	//as Log: ArenaHead is the number of bytes the runtime has
+	//allocated and ArenaTail the number the global scheduler has freed.
+	//Data never wraps around the end of Arena, the runtime skips the
+	//rest of it instead. Once the global scheduler has read an event it
+	//frees the Arena up to the event's ArenaEnd, storing ArenaTail
+	//before it stores LogTail.
+	ArenaHead uint32
+	ArenaTail uint32
+	Arena [ARENASIZE]byte
     CoverageIndex int
     Coverage [MAXBLOCKS]CovInfo
+	//CoverageNames holds the block IDs of Coverage, the first
+	//CoverageNamesLen bytes of it are in use. The runtime starts over
+	//once the global scheduler has collected the coverage.
+	CoverageNamesLen uint32
+	CoverageNames [COVERAGENAMESSIZE]byte
+	//NetClocks holds the clocks of the latest writes of the process
+	//to each of its connections. A runtime reading from a connection
+	//joins the clock the other end of it published here.
+	NetClocks [MAXNETCLOCKS]NetClock
+	//Faults are the faults the global scheduler injects into the
+	//syscalls of the process, the first NumFaults of them are in use.
+	//The global scheduler fills them in before the process starts.
+	NumFaults int
+	Faults [MAXFAULTS]Fault
+	//Addrs are the local addresses of the connections of the process,
+	//the first NumAddrs of them are in use. The runtime writes an
+	//address before it stores NumAddrs atomically, and never changes
+	//it afterwards. A runtime finds the Dara process at the other end
+	//of a connection by looking for the remote address of the
+	//connection in the Addrs of the other processes.
+	NumAddrs uint32
+	Addrs [MAXNETADDRS]NetAddr
+	//Links holds what happens to the data the process exchanges with
+	//each other process, Links[pid-1] for the process with DARAPID pid.
+	//It is one of the NET_ actions, NET_DELIVER unless the global
+	//scheduler cut the link. The global scheduler may change it while
+	//the process runs, so the runtime loads it atomically.
+	Links [MAXNETPEERS]uint32
+	//Listeners are the listeners of the process on the virtual
+	//network, and Conns the connections it dialed, see VConn.
+	Listeners [MAXVLISTENERS]VListener
+	Conns [MAXVCONNS]VConn
+	//NetSeq counts the changes made to the listeners and connections
+	//of the process on the virtual network by whoever is at their
+	//other end, which the runtime finds out about on CMD_POLL_NET.
+	NetSeq uint32
+	//NetWaiters is the number of goroutines of the process waiting on
+	//the virtual network
+	NetWaiters uint32
+}
+
+//NetAddr is the address of one end of a connection, as returned by
+//the String method of a net.Addr
+type NetAddr struct {
+	Len int
+	Addr [MAXADDRLEN]byte
+}
+
+//Fault is a failure the global scheduler injects into a syscall of an
+//instrumented os or net function. The function does not make the
+//syscall and fails with Errno instead, or if Errno is 0 it cuts the
+//read or write down to Short bytes.
+type Fault struct {
+	//Syscall is the DSYS number of the syscall to fail
+	Syscall int
+	//Call is which of the calls of Syscall the process makes fails,
+	//counting from 1. An Event without a fault has a Call of 0.
+	Call int
+	Errno int
+	Short int
+}
+
+//NetClock is the clock of the latest write to the connection Conn,
+//named by its local and remote address. Seq is odd while the runtime
+//updates the entry, readers retry if it was odd or changed while they
+//copied it.
+type NetClock struct {
+	Seq uint32
+	ConnLen int
+	Conn [MAXCONNLEN]byte
+	Clock VectorClock
+}
+
+//ArenaRef refers to Len bytes of variable length data starting at
+//offset Off of the Arena of a DaraProc.
+type ArenaRef struct {
+	Off uint32
+	Len uint32
+}
+
+//Logical IDs of goroutines are derived from the logical ID of the
+//goroutine that spawned them and the number of goroutines it spawned
+//before, so they do not depend on goids or on how many goroutines the
+//rest of the program has started. The goroutines which exist when Dara
+//starts are the children of ROOTID, in the order of their goids, and
+//the goroutines of the runtime itself the children of SYSTEMID, in the
+//order they are started. Timer goroutines are the children of TIMERID
+//instead, they are only started where timers are installed, which
+//replay does without.
+const (
+	ROOTID uint64 = 0
+	SYSTEMID uint64 = ^uint64(0)
+	TIMERID uint64 = ^uint64(0) - 1
+)
+
+//ChildID returns the logical ID of the goroutine spawned by the
+//goroutine with logical ID parent after it had spawned index others.
+//It is the FNV-1a hash of the two.
+func ChildID(parent, index uint64) uint64 {
+	h := uint64(14695981039346656037)
+	for _, v := range [2]uint64{parent, index} {
+		for i := uint(0); i < 64; i += 8 {
+			h ^= (v >> i) & 0xff
+			h *= 1099511628211
+		}
+	}
+	return h
+}
+
+//ProcSeed returns the DARA_SEED of the process with DARAPID pid in a
+//cluster seeded with seed. A seed of 0 seeds every process with 0, the
+//seed of runtimes started without a DARA_SEED.
+func ProcSeed(seed int64, pid int) int64 {
+	if seed == 0 {
+		return 0
+	}
+	return int64(ChildID(uint64(seed), uint64(pid)))
 }
 
 //RoutineInfo contains data specific to a single goroutine
@@ -108,6 +536,9 @@ type RoutineInfo struct {
         //pc prior to this goroutine. (Gpc,Routinecount) is a unique id
         //for a goroutine on a given processor.
         RoutineCount int
+        //LogicalID identifies the goroutine across runs, see ChildID.
+        //Replay matches goroutines by it.
+        LogicalID uint64
         //A textual description of the function this goroutine was forked
         //from.In the future it can be removed.
         FuncInfo [64]byte
@@ -119,13 +550,24 @@ type EncEvent struct {
 	G RoutineInfo
 	Epoch int
 	ELE EncLogEntry
-	SyscallInfo GeneralSyscall
+	SyscallInfo EncGeneralSyscall
 	EM EncodedMessage
+	Select EncSelectInfo
+	//Clock is the vector clock of G once the event happened, encoded
+	//with VectorClock.Encode
+	Clock ArenaRef
+	//Now is the virtual time of the process when the event happened
+	Now int64
+	//Fault is the fault injected into the syscall of a SYSCALL_EVENT
+	Fault Fault
+	//ArenaEnd is the ArenaHead of the DaraProc once the data of the
+	//event has been written to the Arena
+	ArenaEnd uint32
 }
 
 type EncLogEntry struct {
 	Length int
-	LogID [VARBUFLEN]byte
+	LogID ArenaRef
 	Vars [MAXLOGVARIABLES] EncNameValuePair
 }
 
@@ -135,7 +577,7 @@ type GeneralType struct {
 	Bool bool
 	Float float32
 	Integer64 int64
-	String [256]byte
+	String string
 	Unsupported rune
 }
 
@@ -147,19 +589,48 @@ type GeneralSyscall struct {
 	Rets [10]GeneralType
 }
 
-//TODO fill out this structure
+//EncGeneralType is the form of a GeneralType in shared memory, String
+//is stored in the Arena
+type EncGeneralType struct {
+	Type TypeNum
+	Integer int
+	Bool bool
+	Float float32
+	Integer64 int64
+	String ArenaRef
+	Unsupported rune
+}
+
+//EncGeneralSyscall is the form of a GeneralSyscall in shared memory
+type EncGeneralSyscall struct {
+	SyscallNum int
+	NumArgs int
+	NumRets int
+	Args [10]EncGeneralType
+	Rets [10]EncGeneralType
+}
+
+//EncodedMessage is the form of a Message in shared memory, Body is
+//stored in the Arena
 type EncodedMessage struct {
-	Body [VARBUFLEN]byte
+	Body ArenaRef
+	Chan ChanID
+	Len int
+	Cap int
+	Closed bool
+	Partner RoutineInfo
 }
 
 type EncNameValuePair struct {
-	VarName [VARBUFLEN]byte
-	Value [VARBUFLEN]byte
-	Type [VARBUFLEN]byte
+	VarName ArenaRef
+	Value ArenaRef
+	Type ArenaRef
 }
 
+//CovInfo is the count of a single block, BlockID refers to
+//CoverageNames rather than to the Arena
 type CovInfo struct {
-    BlockID [BLOCKIDLEN]byte
+    BlockID ArenaRef
     Count uint64
 }
 
@@ -171,6 +642,39 @@ type Event struct {
 	LE LogEntry
 	SyscallInfo GeneralSyscall
 	Msg Message
+	Select SelectInfo
+	//Clock is the vector clock of G once the event happened
+	Clock []ClockEntry
+	//Now is the virtual time of the process when the event happened
+	Now int64
+	//Fault is the fault injected into the syscall of a SYSCALL_EVENT
+	Fault Fault
+}
+
+//SelectCase is a case of a select statement that was ready to proceed
+type SelectCase struct {
+	//Index is the position of the case in the select statement
+	Index int
+	//PC is the call site of the case
+	PC uintptr
+}
+
+//SelectInfo describes a select statement which had more than one
+//ready case, it is logged with a SELECT_EVENT
+type SelectInfo struct {
+	//Ready lists the ready cases in the order they appear in the
+	//statement
+	Ready []SelectCase
+	//Chosen is the Index of the case that was taken
+	Chosen int
+}
+
+//EncSelectInfo is the form of a SelectInfo in shared memory. Only the
+//first MAXSELECTCASES ready cases of a select are considered.
+type EncSelectInfo struct {
+	NumReady int
+	Ready [MAXSELECTCASES]SelectCase
+	Chosen int
 }
 
 type CoverageEvent struct {
@@ -205,8 +709,31 @@ type NameValuePair struct {
 	Type string
 }
 
+//Message is a message passed over a channel. It is logged with a
+//SEND_EVENT or a REC_EVENT once the send or receive has completed.
 type Message struct {
 	Body string
+	//Chan is the channel the message went through
+	Chan ChanID
+	//Len is the number of elements left in the buffer of the channel
+	//once the operation completed, Cap is its capacity
+	Len int
+	Cap int
+	//Closed is set on a receive which got the zero value of a closed
+	//channel
+	Closed bool
+	//Partner is the goroutine at the other end of a handoff on an
+	//unbuffered channel, its Gid is 0 for any other operation
+	Partner RoutineInfo
+}
+
+//ChanID identifies a channel across runs. Like (Gpc, RoutineCount)
+//for goroutines it is made of the program counter the channel was
+//made at and a count of how many channels were made at the same pc
+//before it. Channels made before Dara is initialised have a zero ChanID.
+type ChanID struct {
+	Pc uintptr
+	Index int
 }
 
 
@@ -235,28 +762,6 @@ func GetDaraProcStatus(status uint32) DaraProcStatus {
 }
 
 
-// Status is used to control between Global and Local Schedulers
-type DaraNodeStatus int
-
-const (
-	// THe global scheduler has asked us the local scheduler
-	// to record events of interest and report them to the global scheduler
-	RECORD DaraNodeStatus = -3
-	INIT = -1
-	// The local scheduler has finished its execution
-	FINISH = -100
-	// The global scheduler has told the local scheduler to kill its execution.
-	KILL = -4
-	// The local scheduler needs to do polling on the net. It can't do anything else for now :/
-	NET_BLOCK = -6
-	// The local scheduler has woken up from a network block. This value notifies
-	// the global scheduler that it wants the lock back
-	NET_WAKEUP = -7
-	// The global scheduler has specified the next GoRoutine that must be executed
-	// This value is only used during replay and explore
-	ROUTINE_SCHEDULE = -5
-)
-
 //Type which encapsulates a single schedule
 type Schedule struct {
 	LogEvents  []Event
@@ -0,0 +1,135 @@
+package dara_test
+
+import (
+	"dara"
+	"testing"
+)
+
+func TestChildID(t *testing.T) {
+	if dara.ChildID(dara.ROOTID, 1) != dara.ChildID(dara.ROOTID, 1) {
+		t.Fatal("ChildID is not deterministic")
+	}
+	// The first children of a few generations, and their siblings, all
+	// get IDs of their own.
+	seen := make(map[uint64]bool)
+	for _, parent := range []uint64{dara.ROOTID, dara.SYSTEMID, dara.TIMERID, dara.ChildID(dara.ROOTID, 1), dara.ChildID(dara.ChildID(dara.ROOTID, 1), 0)} {
+		for i := uint64(0); i < 100; i++ {
+			id := dara.ChildID(parent, i)
+			if id == dara.ROOTID || id == dara.SYSTEMID || id == dara.TIMERID {
+				t.Errorf("ChildID(%#x, %d) is a reserved ID", parent, i)
+			}
+			if seen[id] {
+				t.Errorf("ChildID(%#x, %d) collides with an earlier ID", parent, i)
+			}
+			seen[id] = true
+		}
+	}
+	if dara.ChildID(1, 2) == dara.ChildID(2, 1) {
+		t.Error("ChildID does not tell parent and index apart")
+	}
+}
+
+func TestProcSeed(t *testing.T) {
+	for pid := 1; pid <= 3; pid++ {
+		if s := dara.ProcSeed(0, pid); s != 0 {
+			t.Errorf("ProcSeed(0, %d) = %d, want 0", pid, s)
+		}
+	}
+	seen := make(map[int64]bool)
+	for _, seed := range []int64{1, 2, -1} {
+		for pid := 1; pid <= 3; pid++ {
+			s := dara.ProcSeed(seed, pid)
+			if s != dara.ProcSeed(seed, pid) {
+				t.Fatalf("ProcSeed(%d, %d) is not deterministic", seed, pid)
+			}
+			if seen[s] {
+				t.Errorf("ProcSeed(%d, %d) = %d, the seed of another process", seed, pid, s)
+			}
+			seen[s] = true
+		}
+	}
+}
+
+func TestParseSyscallPoints(t *testing.T) {
+	tests := []struct {
+		in     string
+		points int
+		bad    string
+	}{
+		{"", 0, ""},
+		{"fsync", dara.SYSPOINT_FSYNC, ""},
+		{"file-write,mutex-lock", dara.SYSPOINT_FILE_WRITE | dara.SYSPOINT_MUTEX_LOCK, ""},
+		{"net-write,,fsync,", dara.SYSPOINT_NET_WRITE | dara.SYSPOINT_FSYNC, ""},
+		{"fsync,disk", 0, "disk"},
+	}
+	for _, tt := range tests {
+		points, bad := dara.ParseSyscallPoints(tt.in)
+		if points != tt.points || bad != tt.bad {
+			t.Errorf("ParseSyscallPoints(%q) = %#x, %q, want %#x, %q", tt.in, points, bad, tt.points, tt.bad)
+		}
+	}
+	all := dara.SYSPOINT_FILE_WRITE | dara.SYSPOINT_FSYNC | dara.SYSPOINT_NET_WRITE | dara.SYSPOINT_MUTEX_LOCK
+	if points, _ := dara.ParseSyscallPoints(dara.SyscallPointsString(all)); points != all {
+		t.Errorf("SyscallPointsString(%#x) = %q, which parses as %#x", all, dara.SyscallPointsString(all), points)
+	}
+	if dara.SyscallPoint(dara.MUX_LOCK) != dara.SYSPOINT_MUTEX_LOCK || dara.SyscallPoint(dara.DSYS_NET_WRITETO) != dara.SYSPOINT_NET_WRITE || dara.SyscallPoint(dara.DSYS_READ) != 0 {
+		t.Error("SyscallPoint puts syscalls in the wrong classes")
+	}
+}
+
+func TestNetEvent(t *testing.T) {
+	for a := dara.NET_DELIVER; a <= dara.NET_RESET; a++ {
+		e := dara.NetEvent(2, 3, a)
+		remote, action, ok := e.NetLink()
+		if !ok || e.P != 2 || remote != 3 || action != a {
+			t.Errorf("NetEvent(2, 3, %d) is P%d link to %d set to %d (%v)", a, e.P, remote, action, ok)
+		}
+		if got, ok := dara.ParseNetAction(dara.NetActionString(a)); !ok || got != a {
+			t.Errorf("ParseNetAction(%q) = %d, %v, want %d", dara.NetActionString(a), got, ok, a)
+		}
+	}
+	if _, ok := dara.ParseNetAction("unknown"); ok {
+		t.Error("ParseNetAction accepted an unknown action")
+	}
+	e := dara.Event{Type: dara.TIMER_EVENT}
+	if _, _, ok := e.NetLink(); ok {
+		t.Error("NetLink accepted a TIMER_EVENT")
+	}
+}
+
+func TestHeldMessage(t *testing.T) {
+	e := dara.Event{Type: dara.MESSAGE_EVENT, P: 1, Msg: dara.Message{Body: "ping"}}
+	e.SyscallInfo = dara.GeneralSyscall{SyscallNum: dara.DSYS_MESSAGE, NumArgs: 5}
+	e.SyscallInfo.Args[0] = dara.GeneralType{Type: dara.INTEGER64, Integer64: 3}
+	e.SyscallInfo.Args[1] = dara.GeneralType{Type: dara.INTEGER, Integer: 2}
+	e.SyscallInfo.Args[2] = dara.GeneralType{Type: dara.STRING, String: "127.0.0.1:4000>127.0.0.1:9000"}
+	e.SyscallInfo.Args[3] = dara.GeneralType{Type: dara.INTEGER, Integer: dara.MESSAGE_DELIVERED}
+	e.SyscallInfo.Args[4] = dara.GeneralType{Type: dara.INTEGER, Integer: 4}
+	want := dara.HeldMessage{ID: 3, Dst: 2, Conn: "127.0.0.1:4000>127.0.0.1:9000", Op: dara.MESSAGE_DELIVERED, Len: 4}
+	if m, ok := e.HeldMessage(); !ok || m != want {
+		t.Errorf("HeldMessage() = %+v, %v, want %+v", m, ok, want)
+	}
+	e.Type = dara.SYSCALL_EVENT
+	if _, ok := e.HeldMessage(); ok {
+		t.Error("HeldMessage accepted a SYSCALL_EVENT")
+	}
+}
+
+func TestEphemeralPort(t *testing.T) {
+	seen := make(map[int]int)
+	for pid := 1; pid <= dara.MAXNETPEERS; pid++ {
+		for k := 0; k < dara.VNETPORTSPAN; k++ {
+			port := dara.EphemeralPort(pid, k)
+			if port < dara.VNETPORTBASE || port > 65535 {
+				t.Fatalf("EphemeralPort(%d, %d) = %d, out of range", pid, k, port)
+			}
+			if other, ok := seen[port]; ok && other != pid {
+				t.Fatalf("EphemeralPort(%d, %d) = %d, a port of process %d", pid, k, port, other)
+			}
+			seen[port] = pid
+		}
+	}
+	if dara.EphemeralPort(1, dara.VNETPORTSPAN) != dara.EphemeralPort(1, 0) {
+		t.Error("the ephemeral ports of a process do not wrap around")
+	}
+}
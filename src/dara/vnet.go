@@ -0,0 +1,107 @@
+package dara
+
+//The virtual network carries the TCP connections of the Dara processes
+//of a cluster run with DARA_VIRTUAL_NET through shared memory instead
+//of the kernel. Every process of the cluster lives on the same virtual
+//host: a port is bound by at most one listener of the whole cluster,
+//and a connection to any address with that port reaches it. Two runs
+//of a cluster never share a port, however many of them run at once.
+//
+//A connection lives in the Conns of the process which dialed it, and
+//waits in the Backlog of its listener until it is accepted. Each
+//direction of a connection is a VPipe, written by one end and read by
+//the other. Whoever changes a listener or a connection of another
+//process bumps the NetSeq of that process. The goroutines waiting on
+//the virtual network only notice once the runtime polls it, which a
+//recording runtime does whenever it looks for a goroutine to run and
+//any other runtime only does on CMD_POLL_NET, so it is up to the
+//global scheduler when the data written by one process reaches
+//another.
+
+//States of a VListener or VConn. A FREE one may be taken by the
+//process it belongs to.
+const (
+	VNET_FREE = iota
+	VNET_OPEN
+)
+
+//The two ends of a VConn, and the pipe each of them writes to
+const (
+	VNET_CLIENT = iota
+	VNET_SERVER
+)
+
+//What the operations of the virtual network return. A read which
+//returns VNET_EOF reached the end of the data of the other end, the
+//other errors stand for the error of the same name the kernel would
+//return, VNET_CLOSED for an operation on a closed connection or
+//listener.
+const (
+	VNET_OK = iota
+	VNET_EOF
+	VNET_TIMEOUT
+	VNET_CLOSED
+	VNET_REFUSED
+	VNET_ADDRINUSE
+	VNET_NOBUFS
+	VNET_PIPE
+)
+
+//VConnRef names the connection Conns[Conn] of the process with
+//DARAPID Pid
+type VConnRef struct {
+	Pid int
+	Conn int
+}
+
+//VListener is a listener on the virtual network
+type VListener struct {
+	State uint32
+	Port int
+	//Backlog holds the connections dialed to the listener which have
+	//not been accepted. BacklogHead counts the connections dialed and
+	//BacklogTail those accepted, the connection numbered i lives in
+	//Backlog[i%VNETBACKLOG].
+	BacklogHead uint32
+	BacklogTail uint32
+	Backlog [VNETBACKLOG]VConnRef
+}
+
+//VConn is a connection on the virtual network
+type VConn struct {
+	State uint32
+	//Ends counts the ends of the connection which have not been
+	//closed, the connection is FREE again once both have
+	Ends uint32
+	//Server is the DARAPID of the process which listens
+	Server int
+	ClientPort int
+	ServerPort int
+	//Pipes[VNET_CLIENT] carries what the client writes to the server,
+	//Pipes[VNET_SERVER] the other way round
+	Pipes [2]VPipe
+}
+
+//VPipe is one direction of a VConn. Head counts the bytes written and
+//Tail the bytes read, both only ever grow and the byte numbered i
+//lives in Buf[i%VNETPIPESIZE]. The writer stores Head atomically once
+//the bytes are in Buf, the reader stores Tail once it has copied them
+//out.
+type VPipe struct {
+	Head uint32
+	Tail uint32
+	//Closed is set once the writer will write no more, the reader
+	//reads VNET_EOF once it has read everything
+	Closed uint32
+	//Gone is set once the reader will read no more, the writes of the
+	//writer fail
+	Gone uint32
+	Buf [VNETPIPESIZE]byte
+}
+
+//EphemeralPort returns the k-th ephemeral port of the process with
+//DARAPID pid
+func EphemeralPort(pid, k int) int {
+	span := 65536 - VNETPORTBASE
+	return VNETPORTBASE + ((pid-1)*VNETPORTSPAN+k%VNETPORTSPAN)%span
+}
@@ -72,7 +72,7 @@ func New(src Source) *Rand {
 func (r *Rand) Seed(seed int64) {
 	//@DARA INJECT
     if runtime.DaraInitialised {
-	    seed = 0
+	    seed = runtime.DaraSeed
     }
 	//@DARA /INJECT
 	if lk, ok := r.src.(*lockedSource); ok {
@@ -377,10 +377,24 @@ func ExpFloat64() float64 { return globalRand.ExpFloat64() }
 type lockedSource struct {
 	lk  sync.Mutex
 	src Source64
+	//@DARA INJECT
+	daraSeeded bool
+}
+
+//daraSeed reseeds the source with the DARA_SEED of the process the
+//first time it is used under Dara, the default Source is seeded before
+//Dara is initialised. r.lk must be held.
+func (r *lockedSource) daraSeed() {
+	if !r.daraSeeded && runtime.DaraInitialised {
+		r.daraSeeded = true
+		r.src.Seed(runtime.DaraSeed)
+	}
 }
+//@DARA /INJECT
 
 func (r *lockedSource) Int63() (n int64) {
 	r.lk.Lock()
+	r.daraSeed()
 	n = r.src.Int63()
 	r.lk.Unlock()
 	return
@@ -388,6 +402,7 @@ func (r *lockedSource) Int63() (n int64) {
 
 func (r *lockedSource) Uint64() (n uint64) {
 	r.lk.Lock()
+	r.daraSeed()
 	n = r.src.Uint64()
 	r.lk.Unlock()
 	return
@@ -395,6 +410,7 @@ func (r *lockedSource) Uint64() (n uint64) {
 
 func (r *lockedSource) Seed(seed int64) {
 	r.lk.Lock()
+	r.daraSeeded = runtime.DaraInitialised
 	r.src.Seed(seed)
 	r.lk.Unlock()
 }
@@ -402,6 +418,7 @@ func (r *lockedSource) Seed(seed int64) {
 // seedPos implements Seed for a lockedSource without a race condition.
 func (r *lockedSource) seedPos(seed int64, readPos *int8) {
 	r.lk.Lock()
+	r.daraSeeded = runtime.DaraInitialised
 	r.src.Seed(seed)
 	*readPos = 0
 	r.lk.Unlock()
@@ -410,6 +427,7 @@ func (r *lockedSource) seedPos(seed int64, readPos *int8) {
 // read implements Read for a lockedSource without a race condition.
 func (r *lockedSource) read(p []byte, readVal *int64, readPos *int8) (n int, err error) {
 	r.lk.Lock()
+	r.daraSeed()
 	n, err = read(p, r.src.Int63, readVal, readPos)
 	r.lk.Unlock()
 	return
@@ -205,7 +205,7 @@ func seedrand(x int32) int32 {
 func (rng *rngSource) Seed(seed int64) {
 	//@DARA Inject
     if runtime.DaraInitialised {
-	    seed = 0
+	    seed = runtime.DaraSeed
     }
 	// \@DaraInject
 	rng.tap = 0
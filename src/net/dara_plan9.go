@@ -0,0 +1,39 @@
+package net
+
+import (
+	"dara"
+	"syscall"
+)
+
+// daraFault reports whether the Dara global scheduler injects a fault
+// into the syscall syscallID of c. Dara does not run on Plan 9, no
+// fault is ever injected.
+func (c *conn) daraFault(op string, syscallID int, n int) (int, error) {
+	return n, nil
+}
+
+// daraConn publishes the local address of c to other Dara processes.
+// Dara does not run on Plan 9, there is nobody to publish it to.
+func (c *conn) daraConn() {}
+
+// daraLink returns what happens to the data c exchanges with the Dara
+// process at its other end. Dara does not run on Plan 9, it is always
+// delivered.
+func (c *conn) daraLink() int {
+	return dara.NET_DELIVER
+}
+
+// daraReset shuts c down like a reset from the other end would. Dara
+// does not run on Plan 9, no link is ever reset.
+func (c *conn) daraReset(op string) error {
+	return &OpError{Op: op, Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: syscall.EPLAN9}
+}
+
+// daraWrite writes b to c. Dara does not run on Plan 9, nothing is held
+// for the global scheduler to deliver.
+func (c *conn) daraWrite(b []byte) (int, error) {
+	return c.fd.Write(b)
+}
+
+// daraFlush writes out what is held of c before c is closed.
+func (c *conn) daraFlush() {}
@@ -0,0 +1,195 @@
+// +build darwin dragonfly freebsd linux nacl netbsd openbsd solaris windows
+
+package net
+
+import (
+	"dara"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// daraInject reports whether the Dara global scheduler injects a fault
+// into the syscall syscallID, which is about to be made by op to read
+// or write n bytes. It returns the error op fails with instead, or the
+// number of bytes to read or write, which is less than n if the call is
+// cut short.
+func daraInject(op string, syscallID int, n int) (int, error) {
+	errno, short, ok := runtime.DaraFault(syscallID)
+	if !ok {
+		return n, nil
+	}
+	if errno != 0 {
+		return 0, os.NewSyscallError(op, syscall.Errno(errno))
+	}
+	if short >= 0 && short < n {
+		return short, nil
+	}
+	return n, nil
+}
+
+// daraFault is daraInject for the syscall syscallID of c, the error op
+// fails with is an OpError.
+func (c *conn) daraFault(op string, syscallID int, n int) (int, error) {
+	m, err := daraInject(op, syscallID, n)
+	if err != nil {
+		return 0, &OpError{Op: op, Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return m, nil
+}
+
+// daraAddrString returns a as a string, or "" if there is no address.
+func daraAddrString(a Addr) string {
+	if a == nil {
+		return ""
+	}
+	// A nil *UDPAddr and the like.
+	if s := a.String(); s != "<nil>" {
+		return s
+	}
+	return ""
+}
+
+// daraReport reports the syscall syscallID to the Dara global
+// scheduler: it was made on network between the local address laddr
+// and the peer address raddr, moved n bytes and returned err.
+func daraReport(syscallID int, network string, laddr, raddr Addr, n int, err error) {
+	if !runtime.Is_dara_profiling_on() {
+		return
+	}
+	local, peer := daraAddrString(laddr), daraAddrString(raddr)
+	runtime.Dara_Debug_Print(func() {
+		println("[NET] : syscall", syscallID, network, local, peer, n)
+	})
+	argInfo1 := dara.GeneralType{Type: dara.STRING, String: network}
+	argInfo2 := dara.GeneralType{Type: dara.SOCKADDR, String: local}
+	argInfo3 := dara.GeneralType{Type: dara.SOCKADDR, String: peer}
+	retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: n}
+	retInfo2 := dara.GeneralType{Type: dara.ERROR}
+	if err != nil {
+		retInfo2.String = err.Error()
+	}
+	syscallInfo := dara.GeneralSyscall{syscallID, 3, 2, [10]dara.GeneralType{argInfo1, argInfo2, argInfo3}, [10]dara.GeneralType{retInfo1, retInfo2}}
+	runtime.Report_Syscall_To_Scheduler(syscallID, syscallInfo)
+}
+
+// daraOpen makes the socket of a dial, listen or accept, the syscall
+// syscallID made by op on network, with open, unless the Dara global
+// scheduler injects a fault into it, and reports it.
+func daraOpen(op string, syscallID int, network string, laddr, raddr Addr, open func() (*netFD, error)) (*netFD, error) {
+	_, err := daraInject(op, syscallID, 0)
+	var fd *netFD
+	if err == nil {
+		fd, err = open()
+	}
+	if err != nil {
+		daraReport(syscallID, network, laddr, raddr, 0, err)
+		return nil, err
+	}
+	daraReport(syscallID, network, fd.laddr, fd.raddr, 0, nil)
+	return fd, nil
+}
+
+// daraDropped reports whether the datagram that arrived from addr is
+// lost, because the global scheduler dropped or reset the link to the
+// Dara process at addr.
+func daraDropped(addr Addr) bool {
+	if runtime.DaraProcessID() <= 0 || daraAddrString(addr) == "" {
+		return false
+	}
+	link := runtime.DaraNetLink(addr.String())
+	return link == dara.NET_DROP || link == dara.NET_RESET
+}
+
+// daraReadFrom reads a datagram of c into b, unless the Dara global
+// scheduler injects a fault into the read. The datagrams that arrive
+// over a dropped link are lost.
+func (c *conn) daraReadFrom(b []byte) (int, syscall.Sockaddr, error) {
+	m, err := daraInject("recvfrom", dara.DSYS_NET_READFROM, len(b))
+	if err != nil {
+		return 0, nil, err
+	}
+	n, sa, err := c.fd.readFrom(b[:m])
+	for err == nil && daraDropped(c.fd.addrFunc()(sa)) {
+		n, sa, err = c.fd.readFrom(b[:m])
+	}
+	return n, sa, err
+}
+
+// daraWriteTo writes the datagram b of c to addr, at sa, unless the
+// Dara global scheduler injects a fault into the write. A dropped link
+// loses the datagram, and so does a reset link, there is no connection
+// to reset. A duplicating link sends it twice.
+func (c *conn) daraWriteTo(b []byte, addr Addr, sa syscall.Sockaddr) (int, error) {
+	runtime.DaraSyscallPoint(dara.DSYS_NET_WRITETO)
+	link := dara.NET_DELIVER
+	if runtime.DaraProcessID() > 0 {
+		link = runtime.DaraNetLink(addr.String())
+	}
+	if link == dara.NET_DROP || link == dara.NET_RESET {
+		return len(b), nil
+	}
+	m, err := daraInject("sendto", dara.DSYS_NET_WRITETO, len(b))
+	if err != nil {
+		return 0, err
+	}
+	n, err := c.fd.writeTo(b[:m], sa)
+	if link == dara.NET_DUPLICATE && err == nil {
+		c.fd.writeTo(b[:n], sa)
+	}
+	return n, err
+}
+
+// daraConn publishes the local address of c, which lets the Dara
+// process at the other end of c find out who it is connected to.
+func (c *conn) daraConn() {
+	if runtime.DaraProcessID() > 0 && c.fd.laddr != nil {
+		runtime.DaraNetConn(c.fd.laddr.String())
+	}
+}
+
+// daraLink returns what happens to the data c exchanges with the Dara
+// process at its other end, one of the dara.NET_ actions the global
+// scheduler set for the link between the two.
+func (c *conn) daraLink() int {
+	if runtime.DaraProcessID() <= 0 || c.fd.raddr == nil {
+		return dara.NET_DELIVER
+	}
+	return runtime.DaraNetLink(c.fd.raddr.String())
+}
+
+// daraReset shuts c down like a reset from the other end would and
+// returns the error op fails with.
+func (c *conn) daraReset(op string) error {
+	c.fd.shutdown(syscall.SHUT_RDWR)
+	err := os.NewSyscallError(op, syscall.ECONNRESET)
+	return &OpError{Op: op, Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+}
+
+// daraWrite writes b to c, unless the global scheduler holds the
+// messages between Dara processes and c leads to another one, in which
+// case b is held for the global scheduler to deliver. Nothing is held
+// on the virtual network.
+func (c *conn) daraWrite(b []byte) (int, error) {
+	if runtime.DaraProcessID() > 0 && c.fd.laddr != nil && c.fd.raddr != nil && !c.fd.daraVirtual() &&
+		runtime.DaraHoldMessage(uintptr(c.fd.pfd.Sysfd), c.fd.laddr.String(), c.fd.raddr.String(), b) {
+		return len(b), nil
+	}
+	return c.fd.Write(b)
+}
+
+// daraFlush writes out what is held of c before c is closed.
+func (c *conn) daraFlush() {
+	if runtime.DaraProcessID() > 0 {
+		runtime.DaraFlushMessages(uintptr(c.fd.pfd.Sysfd))
+	}
+}
+
+// daraListen publishes the address of the listener fd, which lets
+// other Dara processes find out that they are connected to us before
+// we accept the connection.
+func daraListen(fd *netFD) {
+	if runtime.DaraProcessID() > 0 && fd.laddr != nil {
+		runtime.DaraNetConn(fd.laddr.String())
+	}
+}
@@ -0,0 +1,35 @@
+// +build plan9 windows
+
+package net
+
+import "time"
+
+// daraListenTCP listens on laddr on the virtual network between Dara
+// processes. Dara does not run here, there is no virtual network.
+func daraListenTCP(network string, laddr *TCPAddr) (*TCPListener, bool, error) {
+	return nil, false, nil
+}
+
+// daraDialTCP connects to raddr on the virtual network between Dara
+// processes. Dara does not run here, there is no virtual network.
+func daraDialTCP(network string, laddr, raddr *TCPAddr) (*TCPConn, bool, error) {
+	return nil, false, nil
+}
+
+// daraVirtual reports whether fd is on the virtual network. Dara does
+// not run here, it never is.
+func (fd *netFD) daraVirtual() bool {
+	return false
+}
+
+// daraSetDeadline sets the deadline of the reads ('r'), writes ('w')
+// or both ('r'+'w') of fd to t.
+func (fd *netFD) daraSetDeadline(t time.Time, mode int) error {
+	switch mode {
+	case 'r':
+		return fd.pfd.SetReadDeadline(t)
+	case 'w':
+		return fd.pfd.SetWriteDeadline(t)
+	}
+	return fd.pfd.SetDeadline(t)
+}
@@ -0,0 +1,166 @@
+// +build darwin dragonfly freebsd linux nacl netbsd openbsd solaris
+
+package net
+
+import (
+	"dara"
+	"internal/poll"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// With DARA_VIRTUAL_NET every TCP listener and connection of a Dara
+// process lives on the virtual network the runtime keeps in shared
+// memory, see dara/vnet.go, rather than on a socket. A netFD on the
+// virtual network has no file descriptor, its reads, writes and
+// deadlines go to the runtime.
+
+// daraVNet is the listener or connection of a netFD on the virtual
+// network.
+type daraVNet struct {
+	// h is the handle of the listener or connection in the runtime,
+	// 0 once it is closed
+	h int
+}
+
+var daraLoopback = IPv4(127, 0, 0, 1)
+
+// daraVNetError returns the error of the dara.VNET_ error code errno.
+func daraVNetError(errno int) error {
+	switch errno {
+	case dara.VNET_OK:
+		return nil
+	case dara.VNET_EOF:
+		return io.EOF
+	case dara.VNET_TIMEOUT:
+		return poll.ErrTimeout
+	case dara.VNET_CLOSED:
+		return poll.ErrNetClosing
+	case dara.VNET_REFUSED:
+		return syscall.ECONNREFUSED
+	case dara.VNET_ADDRINUSE:
+		return syscall.EADDRINUSE
+	case dara.VNET_NOBUFS:
+		return syscall.ENOBUFS
+	}
+	return syscall.EPIPE
+}
+
+// newDaraVNetFD returns a netFD for the listener or connection h on
+// the virtual network.
+func newDaraVNetFD(net string, h int) *netFD {
+	return &netFD{
+		pfd:         poll.FD{Sysfd: -1, IsStream: true, ZeroReadIsEOF: true},
+		family:      syscall.AF_INET,
+		sotype:      syscall.SOCK_STREAM,
+		isConnected: true,
+		net:         net,
+		vnet:        &daraVNet{h: h},
+	}
+}
+
+// daraListenTCP listens on laddr on the virtual network. It reports
+// whether the listener belongs on the virtual network, if it does not
+// the caller listens on a socket instead.
+func daraListenTCP(network string, laddr *TCPAddr) (*TCPListener, bool, error) {
+	if !runtime.DaraVirtualNet() {
+		return nil, false, nil
+	}
+	ip, port := IPv4zero, 0
+	if laddr != nil {
+		if laddr.IP != nil {
+			ip = laddr.IP
+		}
+		port = laddr.Port
+	}
+	h, port, errno := runtime.DaraVNetListen(port)
+	if errno != dara.VNET_OK {
+		return nil, true, os.NewSyscallError("listen", daraVNetError(errno))
+	}
+	fd := newDaraVNetFD(network, h)
+	fd.setAddr(&TCPAddr{IP: ip, Port: port}, nil)
+	return &TCPListener{fd}, true, nil
+}
+
+// daraDialTCP connects to raddr on the virtual network from an
+// ephemeral port. It reports whether the connection belongs on the
+// virtual network, if it does not the caller dials a socket instead.
+func daraDialTCP(network string, laddr, raddr *TCPAddr) (*TCPConn, bool, error) {
+	if !runtime.DaraVirtualNet() {
+		return nil, false, nil
+	}
+	h, port, errno := runtime.DaraVNetDial(raddr.Port)
+	if errno != dara.VNET_OK {
+		return nil, true, os.NewSyscallError("connect", daraVNetError(errno))
+	}
+	ip := raddr.IP
+	if ip == nil || ip.IsUnspecified() {
+		ip = daraLoopback
+	}
+	fd := newDaraVNetFD(network, h)
+	fd.setAddr(&TCPAddr{IP: daraLoopback, Port: port}, &TCPAddr{IP: ip, Port: raddr.Port})
+	return newTCPConn(fd), true, nil
+}
+
+// daraVirtual reports whether fd is on the virtual network.
+func (fd *netFD) daraVirtual() bool {
+	return fd.vnet != nil
+}
+
+func (fd *netFD) daraClose() error {
+	runtime.SetFinalizer(fd, nil)
+	h := fd.vnet.h
+	fd.vnet.h = 0
+	return daraVNetError(runtime.DaraVNetClose(h))
+}
+
+func (fd *netFD) daraShutdown(how int) error {
+	read := how == syscall.SHUT_RD || how == syscall.SHUT_RDWR
+	write := how == syscall.SHUT_WR || how == syscall.SHUT_RDWR
+	return daraVNetError(runtime.DaraVNetShutdown(fd.vnet.h, read, write))
+}
+
+func (fd *netFD) daraRead(p []byte) (int, error) {
+	n, errno := runtime.DaraVNetRead(fd.vnet.h, p)
+	return n, daraVNetError(errno)
+}
+
+func (fd *netFD) daraWrite(p []byte) (int, error) {
+	n, errno := runtime.DaraVNetWrite(fd.vnet.h, p)
+	return n, daraVNetError(errno)
+}
+
+func (fd *netFD) daraAccept() (*netFD, error) {
+	h, local, remote, errno := runtime.DaraVNetAccept(fd.vnet.h)
+	if errno != dara.VNET_OK {
+		return nil, daraVNetError(errno)
+	}
+	netfd := newDaraVNetFD(fd.net, h)
+	netfd.setAddr(&TCPAddr{IP: daraLoopback, Port: local}, &TCPAddr{IP: daraLoopback, Port: remote})
+	return netfd, nil
+}
+
+// daraSetDeadline sets the deadline of the reads ('r'), writes ('w')
+// or both ('r'+'w') of fd to t, on the virtual network or on its
+// socket.
+func (fd *netFD) daraSetDeadline(t time.Time, mode int) error {
+	if fd.vnet == nil {
+		switch mode {
+		case 'r':
+			return fd.pfd.SetReadDeadline(t)
+		case 'w':
+			return fd.pfd.SetWriteDeadline(t)
+		}
+		return fd.pfd.SetDeadline(t)
+	}
+	timeout := int64(-1)
+	if !t.IsZero() {
+		if timeout = int64(time.Until(t)); timeout < 0 {
+			timeout = 0
+		}
+	}
+	return daraVNetError(runtime.DaraVNetSetDeadline(fd.vnet.h, mode, timeout))
+}
@@ -26,6 +26,11 @@ type netFD struct {
 	net         string
 	laddr       Addr
 	raddr       Addr
+
+	// DARA Instrumentation
+	// vnet is set if the listener or connection is on the virtual
+	// network between Dara processes rather than on a socket
+	vnet *daraVNet
 }
 
 func newFD(sysfd, family, sotype int, net string) (*netFD, error) {
@@ -180,11 +185,19 @@ func (fd *netFD) connect(ctx context.Context, la, ra syscall.Sockaddr) (rsa sysc
 }
 
 func (fd *netFD) Close() error {
+	// DARA Instrumentation
+	if fd.vnet != nil {
+		return fd.daraClose()
+	}
 	runtime.SetFinalizer(fd, nil)
 	return fd.pfd.Close()
 }
 
 func (fd *netFD) shutdown(how int) error {
+	// DARA Instrumentation
+	if fd.vnet != nil {
+		return fd.daraShutdown(how)
+	}
 	err := fd.pfd.Shutdown(how)
 	runtime.KeepAlive(fd)
 	return wrapSyscallError("shutdown", err)
@@ -199,6 +212,10 @@ func (fd *netFD) closeWrite() error {
 }
 
 func (fd *netFD) Read(p []byte) (n int, err error) {
+	// DARA Instrumentation
+	if fd.vnet != nil {
+		return fd.daraRead(p)
+	}
 	n, err = fd.pfd.Read(p)
 	runtime.KeepAlive(fd)
 	return n, wrapSyscallError("read", err)
@@ -217,6 +234,10 @@ func (fd *netFD) readMsg(p []byte, oob []byte) (n, oobn, flags int, sa syscall.S
 }
 
 func (fd *netFD) Write(p []byte) (nn int, err error) {
+	// DARA Instrumentation
+	if fd.vnet != nil {
+		return fd.daraWrite(p)
+	}
 	nn, err = fd.pfd.Write(p)
 	runtime.KeepAlive(fd)
 	return nn, wrapSyscallError("write", err)
@@ -235,6 +256,10 @@ func (fd *netFD) writeMsg(p []byte, oob []byte, sa syscall.Sockaddr) (n int, oob
 }
 
 func (fd *netFD) accept() (netfd *netFD, err error) {
+	// DARA Instrumentation
+	if fd.vnet != nil {
+		return fd.daraAccept()
+	}
 	d, rsa, errcall, err := fd.pfd.Accept()
 	if err != nil {
 		if errcall != "" {
@@ -183,7 +183,7 @@ func (c *conn) Read(b []byte) (int, error) {
             })
 			str := c.fd.laddr.String() + c.fd.raddr.String()
 			argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-            copy(argInfo1.String[:], str)
+            argInfo1.String = str
 			argInfo2 := dara.GeneralType{Type: dara.ARRAY, Integer: len(b)}
 			retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: 0}
 			retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -192,10 +192,31 @@ func (c *conn) Read(b []byte) (int, error) {
 		}
 		return 0, syscall.EINVAL
 	}
-	n, err := c.fd.Read(b)
+	// DARA Instrumentation
+	m, err := c.daraFault("read", dara.DSYS_NET_READ, len(b))
+	if err != nil {
+		return 0, err
+	}
+	n, err := c.fd.Read(b[:m])
+	// DARA Instrumentation
+	for n > 0 {
+		link := c.daraLink()
+		if link == dara.NET_RESET {
+			return 0, c.daraReset("read")
+		}
+		if link != dara.NET_DROP {
+			break
+		}
+		// What arrives over a dropped link is lost
+		n, err = c.fd.Read(b[:m])
+	}
 	if err != nil && err != io.EOF {
 		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
 	}
+	if n > 0 && runtime.DaraProcessID() > 0 && c.fd.laddr != nil && c.fd.raddr != nil {
+		// The read happens after the write of the other end
+		runtime.DaraNetRead(c.fd.laddr.String(), c.fd.raddr.String())
+	}
 	// DARA Instrumentation
 	if runtime.Is_dara_profiling_on() {
         runtime.Dara_Debug_Print(func() {
@@ -206,7 +227,7 @@ func (c *conn) Read(b []byte) (int, error) {
         })
 		str := c.fd.laddr.String() + c.fd.raddr.String()
 		argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-        copy(argInfo1.String[:], str)
+        argInfo1.String = str
 		argInfo2 := dara.GeneralType{Type: dara.ARRAY, Integer: len(b)}
 		retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: n}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -231,7 +252,7 @@ func (c *conn) Write(b []byte) (int, error) {
             })
 			str := c.fd.laddr.String() + c.fd.raddr.String()
 			argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-            copy(argInfo1.String[:], str)
+            argInfo1.String = str
 			argInfo2 := dara.GeneralType{Type: dara.ARRAY, Integer: len(str)}
 			retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: 0}
 			retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -240,9 +261,27 @@ func (c *conn) Write(b []byte) (int, error) {
 		}
 		return 0, syscall.EINVAL
 	}
-	n, err := c.fd.Write(b)
+	// DARA Instrumentation
+	runtime.DaraSyscallPoint(dara.DSYS_NET_WRITE)
+	link := c.daraLink()
+	switch link {
+	case dara.NET_DROP:
+		return len(b), nil
+	case dara.NET_RESET:
+		return 0, c.daraReset("write")
+	}
+	m, err := c.daraFault("write", dara.DSYS_NET_WRITE, len(b))
+	if err != nil {
+		return 0, err
+	}
+	n, err := c.daraWrite(b[:m])
+	if link == dara.NET_DUPLICATE && err == nil {
+		c.daraWrite(b[:n])
+	}
 	if err != nil {
 		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	} else if n < len(b) {
+		err = io.ErrShortWrite
 	}
 	// DARA Instrumentation
 	if runtime.Is_dara_profiling_on() {
@@ -256,13 +295,16 @@ func (c *conn) Write(b []byte) (int, error) {
         })
 		str := c.fd.laddr.String() + c.fd.raddr.String()
 		argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-        copy(argInfo1.String[:], str)
+        argInfo1.String = str
 		argInfo2 := dara.GeneralType{Type: dara.ARRAY, Integer: len(str)}
 		retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: 0}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_NET_WRITE, 2, 2, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo1, retInfo2}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_NET_WRITE, syscallInfo)
 	}
+	if n > 0 && runtime.DaraProcessID() > 0 && c.fd.laddr != nil && c.fd.raddr != nil {
+		runtime.DaraNetWrite(c.fd.laddr.String(), c.fd.raddr.String())
+	}
 	return n, err
 }
 
@@ -278,7 +320,7 @@ func (c *conn) Close() error {
         })
 		str := c.fd.laddr.String() + c.fd.raddr.String()
 		argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-        copy(argInfo1.String[:], str)
+        argInfo1.String = str
 		retInfo1 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_NET_CLOSE, 1, 1, [10]dara.GeneralType{argInfo1}, [10]dara.GeneralType{retInfo1}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_NET_CLOSE, syscallInfo)
@@ -286,6 +328,11 @@ func (c *conn) Close() error {
 	if !c.ok() {
 		return syscall.EINVAL
 	}
+	// DARA Instrumentation
+	if _, err := c.daraFault("close", dara.DSYS_NET_CLOSE, 0); err != nil {
+		return err
+	}
+	c.daraFlush()
 	err := c.fd.Close()
 	if err != nil {
 		err = &OpError{Op: "close", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
@@ -327,9 +374,9 @@ func (c *conn) SetDeadline(t time.Time) error {
         })
 		str := c.fd.laddr.String() + c.fd.raddr.String()
 		argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-        copy(argInfo1.String[:], str)
+        argInfo1.String = str
 		argInfo2 := dara.GeneralType{Type: dara.TIME}
-        copy(argInfo2.String[:], t.String())
+        argInfo2.String = t.String()
 		retInfo1 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_NET_SETDEADLINE, 1, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo1}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_NET_SETDEADLINE, syscallInfo)
@@ -337,7 +384,8 @@ func (c *conn) SetDeadline(t time.Time) error {
 	if !c.ok() {
 		return syscall.EINVAL
 	}
-	if err := c.fd.pfd.SetDeadline(t); err != nil {
+	// DARA Instrumentation
+	if err := c.fd.daraSetDeadline(t, 'r'+'w'); err != nil {
 		return &OpError{Op: "set", Net: c.fd.net, Source: nil, Addr: c.fd.laddr, Err: err}
 	}
 	return nil
@@ -357,9 +405,9 @@ func (c *conn) SetReadDeadline(t time.Time) error {
         })
 		str := c.fd.laddr.String() + c.fd.raddr.String()
 		argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-        copy(argInfo1.String[:], str)
+        argInfo1.String = str
 		argInfo2 := dara.GeneralType{Type: dara.TIME}
-        copy(argInfo2.String[:], t.String())
+        argInfo2.String = t.String()
 		retInfo1 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_NET_SETREADDEADLINE, 1, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo1}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_NET_SETREADDEADLINE, syscallInfo)
@@ -367,7 +415,8 @@ func (c *conn) SetReadDeadline(t time.Time) error {
 	if !c.ok() {
 		return syscall.EINVAL
 	}
-	if err := c.fd.pfd.SetReadDeadline(t); err != nil {
+	// DARA Instrumentation
+	if err := c.fd.daraSetDeadline(t, 'r'); err != nil {
 		return &OpError{Op: "set", Net: c.fd.net, Source: nil, Addr: c.fd.laddr, Err: err}
 	}
 	return nil
@@ -387,9 +436,9 @@ func (c *conn) SetWriteDeadline(t time.Time) error {
         })
 		str := c.fd.laddr.String() + c.fd.raddr.String()
 		argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-        copy(argInfo1.String[:], str)
+        argInfo1.String = str
 		argInfo2 := dara.GeneralType{Type: dara.TIME}
-        copy(argInfo2.String[:], t.String())
+        argInfo2.String = t.String()
 		retInfo1 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_NET_SETWRITEDEADLINE, 1, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo1}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_NET_SETWRITEDEADLINE, syscallInfo)
@@ -397,7 +446,8 @@ func (c *conn) SetWriteDeadline(t time.Time) error {
 	if !c.ok() {
 		return syscall.EINVAL
 	}
-	if err := c.fd.pfd.SetWriteDeadline(t); err != nil {
+	// DARA Instrumentation
+	if err := c.fd.daraSetDeadline(t, 'w'); err != nil {
 		return &OpError{Op: "set", Net: c.fd.net, Source: nil, Addr: c.fd.laddr, Err: err}
 	}
 	return nil
@@ -416,7 +466,7 @@ func (c *conn) SetReadBuffer(bytes int) error {
         })
 		str := c.fd.laddr.String() + c.fd.raddr.String()
 		argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-        copy(argInfo1.String[:], str)
+        argInfo1.String = str
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer: bytes}
 		retInfo1 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_NET_SETREADBUFFER, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo1}}
@@ -444,7 +494,7 @@ func (c *conn) SetWriteBuffer(bytes int) error {
         })
 		str := c.fd.laddr.String() + c.fd.raddr.String()
 		argInfo1 := dara.GeneralType{Type: dara.CONNECTION, Integer: len(str)}
-        copy(argInfo1.String[:], str)
+        argInfo1.String = str
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer: bytes}
 		retInfo1 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_NET_SETWRITEBUFFER, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo1}}
@@ -193,6 +193,8 @@ func (c *TCPConn) SetNoDelay(noDelay bool) error {
 func newTCPConn(fd *netFD) *TCPConn {
 	c := &TCPConn{conn{fd}}
 	setNoDelay(c.fd, true)
+	// DARA Instrumentation
+	c.daraConn()
 	return c
 }
 
@@ -286,7 +288,8 @@ func (l *TCPListener) SetDeadline(t time.Time) error {
 	if !l.ok() {
 		return syscall.EINVAL
 	}
-	if err := l.fd.pfd.SetDeadline(t); err != nil {
+	// DARA Instrumentation
+	if err := l.fd.daraSetDeadline(t, 'r'+'w'); err != nil {
 		return &OpError{Op: "set", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
 	}
 	return nil
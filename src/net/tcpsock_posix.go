@@ -47,6 +47,10 @@ func (a *TCPAddr) toLocal(net string) sockaddr {
 }
 
 func (c *TCPConn) readFrom(r io.Reader) (int64, error) {
+	// DARA Instrumentation
+	if c.fd.daraVirtual() {
+		return genericReadFrom(c, r)
+	}
 	if n, err, handled := sendFile(c.fd, r); handled {
 		return n, err
 	}
@@ -57,10 +61,26 @@ func dialTCP(ctx context.Context, net string, laddr, raddr *TCPAddr) (*TCPConn,
 	if testHookDialTCP != nil {
 		return testHookDialTCP(ctx, net, laddr, raddr)
 	}
-	return doDialTCP(ctx, net, laddr, raddr)
+	// DARA Instrumentation
+	if _, err := daraInject("connect", dara.DSYS_DIAL, 0); err != nil {
+		daraReport(dara.DSYS_DIAL, net, laddr, raddr, 0, err)
+		return nil, err
+	}
+	c, err := doDialTCP(ctx, net, laddr, raddr)
+	// DARA Instrumentation
+	if err != nil {
+		daraReport(dara.DSYS_DIAL, net, laddr, raddr, 0, err)
+		return nil, err
+	}
+	daraReport(dara.DSYS_DIAL, net, c.fd.laddr, c.fd.raddr, 0, nil)
+	return c, nil
 }
 
 func doDialTCP(ctx context.Context, net string, laddr, raddr *TCPAddr) (*TCPConn, error) {
+	// DARA Instrumentation
+	if c, ok, err := daraDialTCP(net, laddr, raddr); ok {
+		return c, err
+	}
 	fd, err := internetSocket(ctx, net, laddr, raddr, syscall.SOCK_STREAM, 0, "dial")
 
 	// TCP has a rarely used mechanism called a 'simultaneous connection' in
@@ -135,7 +155,8 @@ func spuriousENOTAVAIL(err error) bool {
 func (ln *TCPListener) ok() bool { return ln != nil && ln.fd != nil }
 
 func (ln *TCPListener) accept() (*TCPConn, error) {
-	fd, err := ln.fd.accept()
+	// DARA Instrumentation
+	fd, err := daraOpen("accept", dara.DSYS_ACCEPT, ln.fd.net, ln.fd.laddr, nil, ln.fd.accept)
 	if err != nil {
 		return nil, err
 	}
@@ -160,16 +181,26 @@ func listenTCP(ctx context.Context, network string, laddr *TCPAddr) (*TCPListene
 		runtime.Dara_Debug_Print(func() { println("[LISTEN TCP]") })
 		argInfo1 := dara.GeneralType{Type: dara.CONTEXT, Unsupported: dara.UNSUPPORTEDVAL}
 		argInfo2 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo2.String[:], network)
+        argInfo2.String = network
 		argInfo3 := dara.GeneralType{Type: dara.POINTER, Unsupported: dara.UNSUPPORTEDVAL}
 		retInfo1 := dara.GeneralType{Type: dara.POINTER, Unsupported: dara.UNSUPPORTEDVAL}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_LISTEN_TCP, 3, 2, [10]dara.GeneralType{argInfo1, argInfo2, argInfo3}, [10]dara.GeneralType{retInfo1, retInfo2}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_LISTEN_TCP, syscallInfo)
 	}
+	// DARA Instrumentation
+	if ln, ok, err := daraListenTCP(network, laddr); ok {
+		if err != nil {
+			return nil, err
+		}
+		daraListen(ln.fd)
+		return ln, nil
+	}
 	fd, err := internetSocket(ctx, network, laddr, nil, syscall.SOCK_STREAM, 0, "listen")
 	if err != nil {
 		return nil, err
 	}
+	// DARA Instrumentation
+	daraListen(fd)
 	return &TCPListener{fd}, nil
 }
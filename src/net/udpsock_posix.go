@@ -8,6 +8,7 @@ package net
 
 import (
 	"context"
+	"dara"
 	"syscall"
 )
 
@@ -44,13 +45,16 @@ func (a *UDPAddr) toLocal(net string) sockaddr {
 
 func (c *UDPConn) readFrom(b []byte) (int, *UDPAddr, error) {
 	var addr *UDPAddr
-	n, sa, err := c.fd.readFrom(b)
+	// DARA Instrumentation
+	n, sa, err := c.daraReadFrom(b)
 	switch sa := sa.(type) {
 	case *syscall.SockaddrInet4:
 		addr = &UDPAddr{IP: sa.Addr[0:], Port: sa.Port}
 	case *syscall.SockaddrInet6:
 		addr = &UDPAddr{IP: sa.Addr[0:], Port: sa.Port, Zone: zoneCache.name(int(sa.ZoneId))}
 	}
+	// DARA Instrumentation
+	daraReport(dara.DSYS_NET_READFROM, c.fd.net, c.fd.laddr, addr, n, err)
 	return n, addr, err
 }
 
@@ -77,7 +81,10 @@ func (c *UDPConn) writeTo(b []byte, addr *UDPAddr) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	return c.fd.writeTo(b, sa)
+	// DARA Instrumentation
+	n, err := c.daraWriteTo(b, addr, sa)
+	daraReport(dara.DSYS_NET_WRITETO, c.fd.net, c.fd.laddr, addr, n, err)
+	return n, err
 }
 
 func (c *UDPConn) writeMsg(b, oob []byte, addr *UDPAddr) (n, oobn int, err error) {
@@ -95,18 +102,28 @@ func (c *UDPConn) writeMsg(b, oob []byte, addr *UDPAddr) (n, oobn int, err error
 }
 
 func dialUDP(ctx context.Context, net string, laddr, raddr *UDPAddr) (*UDPConn, error) {
-	fd, err := internetSocket(ctx, net, laddr, raddr, syscall.SOCK_DGRAM, 0, "dial")
+	// DARA Instrumentation
+	fd, err := daraOpen("connect", dara.DSYS_DIAL, net, laddr, raddr, func() (*netFD, error) {
+		return internetSocket(ctx, net, laddr, raddr, syscall.SOCK_DGRAM, 0, "dial")
+	})
 	if err != nil {
 		return nil, err
 	}
+	// DARA Instrumentation
+	daraListen(fd)
 	return newUDPConn(fd), nil
 }
 
 func listenUDP(ctx context.Context, network string, laddr *UDPAddr) (*UDPConn, error) {
-	fd, err := internetSocket(ctx, network, laddr, nil, syscall.SOCK_DGRAM, 0, "listen")
+	// DARA Instrumentation
+	fd, err := daraOpen("listen", dara.DSYS_LISTEN_UDP, network, laddr, nil, func() (*netFD, error) {
+		return internetSocket(ctx, network, laddr, nil, syscall.SOCK_DGRAM, 0, "listen")
+	})
 	if err != nil {
 		return nil, err
 	}
+	// DARA Instrumentation
+	daraListen(fd)
 	return newUDPConn(fd), nil
 }
 
@@ -8,6 +8,7 @@ package net
 
 import (
 	"context"
+	"dara"
 	"errors"
 	"os"
 	"syscall"
@@ -100,13 +101,16 @@ func (a *UnixAddr) toLocal(net string) sockaddr {
 
 func (c *UnixConn) readFrom(b []byte) (int, *UnixAddr, error) {
 	var addr *UnixAddr
-	n, sa, err := c.fd.readFrom(b)
+	// DARA Instrumentation
+	n, sa, err := c.daraReadFrom(b)
 	switch sa := sa.(type) {
 	case *syscall.SockaddrUnix:
 		if sa.Name != "" {
 			addr = &UnixAddr{Name: sa.Name, Net: sotypeToNet(c.fd.sotype)}
 		}
 	}
+	// DARA Instrumentation
+	daraReport(dara.DSYS_NET_READFROM, c.fd.net, c.fd.laddr, addr, n, err)
 	return n, addr, err
 }
 
@@ -133,7 +137,10 @@ func (c *UnixConn) writeTo(b []byte, addr *UnixAddr) (int, error) {
 		return 0, syscall.EAFNOSUPPORT
 	}
 	sa := &syscall.SockaddrUnix{Name: addr.Name}
-	return c.fd.writeTo(b, sa)
+	// DARA Instrumentation
+	n, err := c.daraWriteTo(b, addr, sa)
+	daraReport(dara.DSYS_NET_WRITETO, c.fd.net, c.fd.laddr, addr, n, err)
+	return n, err
 }
 
 func (c *UnixConn) writeMsg(b, oob []byte, addr *UnixAddr) (n, oobn int, err error) {
@@ -151,7 +158,10 @@ func (c *UnixConn) writeMsg(b, oob []byte, addr *UnixAddr) (n, oobn int, err err
 }
 
 func dialUnix(ctx context.Context, net string, laddr, raddr *UnixAddr) (*UnixConn, error) {
-	fd, err := unixSocket(ctx, net, laddr, raddr, "dial")
+	// DARA Instrumentation
+	fd, err := daraOpen("connect", dara.DSYS_DIAL, net, laddr, raddr, func() (*netFD, error) {
+		return unixSocket(ctx, net, laddr, raddr, "dial")
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +169,8 @@ func dialUnix(ctx context.Context, net string, laddr, raddr *UnixAddr) (*UnixCon
 }
 
 func (ln *UnixListener) accept() (*UnixConn, error) {
-	fd, err := ln.fd.accept()
+	// DARA Instrumentation
+	fd, err := daraOpen("accept", dara.DSYS_ACCEPT, ln.fd.net, ln.fd.laddr, nil, ln.fd.accept)
 	if err != nil {
 		return nil, err
 	}
@@ -207,17 +218,27 @@ func (l *UnixListener) SetUnlinkOnClose(unlink bool) {
 }
 
 func listenUnix(ctx context.Context, network string, laddr *UnixAddr) (*UnixListener, error) {
-	fd, err := unixSocket(ctx, network, laddr, nil, "listen")
+	// DARA Instrumentation
+	fd, err := daraOpen("listen", dara.DSYS_LISTEN_UNIX, network, laddr, nil, func() (*netFD, error) {
+		return unixSocket(ctx, network, laddr, nil, "listen")
+	})
 	if err != nil {
 		return nil, err
 	}
+	// DARA Instrumentation
+	daraListen(fd)
 	return &UnixListener{fd: fd, path: fd.laddr.String(), unlink: true}, nil
 }
 
 func listenUnixgram(ctx context.Context, network string, laddr *UnixAddr) (*UnixConn, error) {
-	fd, err := unixSocket(ctx, network, laddr, nil, "listen")
+	// DARA Instrumentation
+	fd, err := daraOpen("listen", dara.DSYS_LISTEN_UNIX, network, laddr, nil, func() (*netFD, error) {
+		return unixSocket(ctx, network, laddr, nil, "listen")
+	})
 	if err != nil {
 		return nil, err
 	}
+	// DARA Instrumentation
+	daraListen(fd)
 	return newUnixConn(fd), nil
 }
@@ -0,0 +1,27 @@
+// +build darwin dragonfly freebsd linux nacl netbsd openbsd solaris windows
+
+package os
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// daraFault reports whether the Dara global scheduler injects a fault
+// into the syscall syscallID, which is about to be made to read or
+// write n bytes. It returns the errno the call fails with instead, or
+// the number of bytes to read or write, which is less than n if the
+// call is cut short.
+func daraFault(syscallID int, n int) (int, error) {
+	errno, short, ok := runtime.DaraFault(syscallID)
+	if !ok {
+		return n, nil
+	}
+	if errno != 0 {
+		return 0, syscall.Errno(errno)
+	}
+	if short >= 0 && short < n {
+		return short, nil
+	}
+	return n, nil
+}
@@ -40,7 +40,7 @@ func (f *File) readdir(n int) (fi []FileInfo, err error) {
 				    println(n)
                 })
 				argInfo1 := dara.GeneralType{Type:dara.FILE}
-                copy(argInfo1.String[:], f.name)
+                argInfo1.String = f.name
 				argInfo2 := dara.GeneralType{Type:dara.INTEGER, Integer:n}
 				retInfo1 := dara.GeneralType{Type:dara.ARRAY, Integer: len(fi)}
 				retInfo2 := dara.GeneralType{Type:dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -65,7 +65,7 @@ func (f *File) readdir(n int) (fi []FileInfo, err error) {
 		    println(n)
         })
 		argInfo1 := dara.GeneralType{Type:dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type:dara.INTEGER, Integer:n}
 		retInfo1 := dara.GeneralType{Type:dara.ARRAY, Integer: len(fi)}
 		retInfo2 := dara.GeneralType{Type:dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -107,7 +107,7 @@ func (f *File) readdirnames(n int) (names []string, err error) {
 					    println(n)
                     })
 					argInfo1 := dara.GeneralType{Type: dara.FILE}
-                    copy(argInfo1.String[:], f.name)
+                    argInfo1.String = f.name
 					argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer:n}
 					retInfo1 := dara.GeneralType{Type: dara.ARRAY, Integer: len(names)}
 					retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -136,7 +136,7 @@ func (f *File) readdirnames(n int) (names []string, err error) {
 			    println(n)
             })
 			argInfo1 := dara.GeneralType{Type: dara.FILE}
-            copy(argInfo1.String[:], f.name)
+            argInfo1.String = f.name
 			argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer:n}
 			retInfo1 := dara.GeneralType{Type: dara.ARRAY, Integer: len(names)}
 			retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -154,7 +154,7 @@ func (f *File) readdirnames(n int) (names []string, err error) {
 		    println(n)
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer:n}
 		retInfo1 := dara.GeneralType{Type: dara.ARRAY, Integer: len(names)}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
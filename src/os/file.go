@@ -225,7 +225,7 @@ func Mkdir(name string, perm FileMode) error {
 		    println(perm)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], name)
+        argInfo1.String = name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer : int(perm)}
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported : dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_MKDIR, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
@@ -253,7 +253,7 @@ func Chdir(dir string) error {
 		    println(dir)
         })
 		argInfo := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo.String[:], dir)
+        argInfo.String = dir
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_CHDIR, 1, 1, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_CHDIR, syscallInfo)
@@ -20,6 +20,10 @@ func sigpipe() // implemented in package runtime
 func Readlink(name string) (string, error) {
 	for len := 128; ; len *= 2 {
 		b := make([]byte, len)
+		// DARA Instrumentation
+		if _, e := daraFault(dara.DSYS_READLINK, 0); e != nil {
+			return "", &PathError{"readlink", name, e}
+		}
 		n, e := fixCount(syscall.Readlink(fixLongPath(name), b))
 		if e != nil {
 			// DARA Instrumentation
@@ -29,7 +33,7 @@ func Readlink(name string) (string, error) {
 				    println(name)
                 })
 				argInfo := dara.GeneralType{Type: dara.STRING}
-                copy(argInfo.String[:], name)
+                argInfo.String = name
 				retInfo1 := dara.GeneralType{Type: dara.STRING}
 				retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported : dara.UNSUPPORTEDVAL}
 				syscallInfo := dara.GeneralSyscall{dara.DSYS_READLINK, 1, 2, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo1, retInfo2}}
@@ -45,9 +49,9 @@ func Readlink(name string) (string, error) {
 				    println(name)
                 })
 				argInfo := dara.GeneralType{Type: dara.STRING}
-                copy(argInfo.String[:], name)
+                argInfo.String = name
 				retInfo1 := dara.GeneralType{Type: dara.STRING}
-                copy(retInfo1.String[:], b[0:n])
+                retInfo1.String = string(b[0:n])
 				retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported : dara.UNSUPPORTEDVAL}
 				syscallInfo := dara.GeneralSyscall{dara.DSYS_READLINK, 1, 2, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo1, retInfo2}}
 				runtime.Report_Syscall_To_Scheduler(dara.DSYS_READLINK, syscallInfo)
@@ -84,12 +88,16 @@ func chmod(name string, mode FileMode) error {
 		    println(mode)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], name)
+        argInfo1.String = name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer: int(mode)}
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_CHMOD, 1, 2, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_CHMOD, syscallInfo)
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_CHMOD, 0); e != nil {
+		return &PathError{"chmod", name, e}
+	}
 	if e := syscall.Chmod(fixLongPath(name), syscallMode(mode)); e != nil {
 		return &PathError{"chmod", name, e}
 	}
@@ -107,7 +115,7 @@ func (f *File) chmod(mode FileMode) error {
 		    println(mode)
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer: int(mode)}
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_FCHMOD, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
@@ -116,6 +124,10 @@ func (f *File) chmod(mode FileMode) error {
 	if err := f.checkValid("chmod"); err != nil {
 		return err
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_FCHMOD, 0); e != nil {
+		return f.wrapErr("chmod", e)
+	}
 	if e := f.pfd.Fchmod(syscallMode(mode)); e != nil {
 		return f.wrapErr("chmod", e)
 	}
@@ -140,13 +152,17 @@ func Chown(name string, uid, gid int) error {
 		    println(gid)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], name)
+        argInfo1.String = name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer: int(uid)}
 		argInfo3 := dara.GeneralType{Type: dara.INTEGER, Integer: int(gid)}
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_CHOWN, 3, 1, [10]dara.GeneralType{argInfo1, argInfo2, argInfo3}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_CHOWN, syscallInfo)
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_CHOWN, 0); e != nil {
+		return &PathError{"chown", name, e}
+	}
 	if e := syscall.Chown(name, uid, gid); e != nil {
 		return &PathError{"chown", name, e}
 	}
@@ -171,13 +187,17 @@ func Lchown(name string, uid, gid int) error {
 		    println(gid)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], name)
+        argInfo1.String = name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer: int(uid)}
 		argInfo3 := dara.GeneralType{Type: dara.INTEGER, Integer: int(gid)}
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_LCHOWN, 3, 1, [10]dara.GeneralType{argInfo1, argInfo2, argInfo3}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_LCHOWN, syscallInfo)
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_LCHOWN, 0); e != nil {
+		return &PathError{"lchown", name, e}
+	}
 	if e := syscall.Lchown(name, uid, gid); e != nil {
 		return &PathError{"lchown", name, e}
 	}
@@ -201,7 +221,7 @@ func (f *File) Chown(uid, gid int) error {
 		    println(gid)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer: int(uid)}
 		argInfo3 := dara.GeneralType{Type: dara.INTEGER, Integer: int(gid)}
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -211,6 +231,10 @@ func (f *File) Chown(uid, gid int) error {
 	if err := f.checkValid("chown"); err != nil {
 		return err
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_FCHOWN, 0); e != nil {
+		return f.wrapErr("chown", e)
+	}
 	if e := f.pfd.Fchown(uid, gid); e != nil {
 		return f.wrapErr("chown", e)
 	}
@@ -230,7 +254,7 @@ func (f *File) Truncate(size int64) error {
 		    println(size)
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER64, Integer64: size}
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_FTRUNCATE, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
@@ -239,6 +263,10 @@ func (f *File) Truncate(size int64) error {
 	if err := f.checkValid("truncate"); err != nil {
 		return err
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_FTRUNCATE, 0); e != nil {
+		return f.wrapErr("truncate", e)
+	}
 	if e := f.pfd.Ftruncate(size); e != nil {
 		return f.wrapErr("truncate", e)
 	}
@@ -250,13 +278,14 @@ func (f *File) Truncate(size int64) error {
 // of recently written data to disk.
 func (f *File) Sync() error {
 	// DARA Instrumentation
+	runtime.DaraSyscallPoint(dara.DSYS_FSYNC)
 	if runtime.Is_dara_profiling_on() {
         runtime.Dara_Debug_Print(func() {
 		    print("[FSYNC] : ")
 		    println(f.file.name)
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_FSYNC, 1, 1, [10]dara.GeneralType{argInfo1}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_FSYNC, syscallInfo)
@@ -264,6 +293,10 @@ func (f *File) Sync() error {
 	if err := f.checkValid("sync"); err != nil {
 		return err
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_FSYNC, 0); e != nil {
+		return f.wrapErr("sync", e)
+	}
 	if e := f.pfd.Fsync(); e != nil {
 		return f.wrapErr("sync", e)
 	}
@@ -288,11 +321,11 @@ func Chtimes(name string, atime time.Time, mtime time.Time) error {
 		    println(mtime.String())
         })
 		argInfo1 := dara.GeneralType{Type:dara.STRING}
-        copy(argInfo1.String[:], name)
+        argInfo1.String = name
 		argInfo2 := dara.GeneralType{Type:dara.TIME}
-        copy(argInfo2.String[:], atime.String())
+        argInfo2.String = atime.String()
 		argInfo3 := dara.GeneralType{Type:dara.TIME}
-        copy(argInfo3.String[:], mtime.String())
+        argInfo3.String = mtime.String()
 		retInfo := dara.GeneralType{Type:dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_UTIMES, 3, 1, [10]dara.GeneralType{argInfo1, argInfo2, argInfo3}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_UTIMES, syscallInfo)
@@ -300,6 +333,10 @@ func Chtimes(name string, atime time.Time, mtime time.Time) error {
 	var utimes [2]syscall.Timespec
 	utimes[0] = syscall.NsecToTimespec(atime.UnixNano())
 	utimes[1] = syscall.NsecToTimespec(mtime.UnixNano())
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_UTIMES, 0); e != nil {
+		return &PathError{"chtimes", name, e}
+	}
 	if e := syscall.UtimesNano(fixLongPath(name), utimes[0:]); e != nil {
 		return &PathError{"chtimes", name, e}
 	}
@@ -317,7 +354,7 @@ func (f *File) Chdir() error {
 		    println(f.file.name)
         })
 		argInfo1 := dara.GeneralType{Type:dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		retInfo := dara.GeneralType{Type:dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_FCHDIR, 1, 1, [10]dara.GeneralType{argInfo1}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_FCHDIR, syscallInfo)
@@ -325,6 +362,10 @@ func (f *File) Chdir() error {
 	if err := f.checkValid("chdir"); err != nil {
 		return err
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_FCHDIR, 0); e != nil {
+		return f.wrapErr("chdir", e)
+	}
 	if e := f.pfd.Fchdir(); e != nil {
 		return f.wrapErr("chdir", e)
 	}
@@ -342,9 +383,9 @@ func (f *File) setDeadline(t time.Time) error {
 		    println(t.String())
         })
 		argInfo1 := dara.GeneralType{Type:dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type:dara.TIME}
-        copy(argInfo2.String[:], t.String())
+        argInfo2.String = t.String()
 		retInfo := dara.GeneralType{Type:dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_SETDEADLINE, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_SETDEADLINE, syscallInfo)
@@ -366,9 +407,9 @@ func (f *File) setReadDeadline(t time.Time) error {
 		    println(t.String())
         })
 		argInfo1 := dara.GeneralType{Type:dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type:dara.TIME}
-        copy(argInfo2.String[:], t.String())
+        argInfo2.String = t.String()
 		retInfo := dara.GeneralType{Type:dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_SETREADDEADLINE, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_SETREADDEADLINE, syscallInfo)
@@ -390,9 +431,9 @@ func (f *File) setWriteDeadline(t time.Time) error {
 		    println(t.String())
         })
 		argInfo1 := dara.GeneralType{Type:dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type:dara.TIME}
-        copy(argInfo2.String[:], t.String())
+        argInfo2.String = t.String()
 		retInfo := dara.GeneralType{Type:dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_SETWRITEDEADLINE, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_SETWRITEDEADLINE, syscallInfo)
@@ -43,13 +43,17 @@ func rename(oldname, newname string) error {
 		    println(newname)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], oldname)
+        argInfo1.String = oldname
 		argInfo2 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo2.String[:], newname)
+        argInfo2.String = newname
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_RENAME, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_RENAME, syscallInfo)
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_RENAME, 0); e != nil {
+		return &LinkError{"rename", oldname, newname, e}
+	}
 	err = syscall.Rename(oldname, newname)
 	if err != nil {
 		return &LinkError{"rename", oldname, newname, err}
@@ -189,7 +193,7 @@ func openFileNolog(name string, flag int, perm FileMode) (*File, error) {
 		    println(perm)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], name)
+        argInfo1.String = name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER, Integer: flag}
 		argInfo3 := dara.GeneralType{Type: dara.INTEGER, Integer: int(perm)}
 		retInfo1 := dara.GeneralType{Type: dara.POINTER, Unsupported: dara.UNSUPPORTEDVAL}
@@ -200,6 +204,10 @@ func openFileNolog(name string, flag int, perm FileMode) (*File, error) {
 	var r int
 	for {
 		var e error
+		// DARA Instrumentation
+		if _, e = daraFault(dara.DSYS_OPEN, 0); e != nil {
+			return nil, &PathError{"open", name, e}
+		}
 		r, e = syscall.Open(name, flag|syscall.O_CLOEXEC, syscallMode(perm))
 		if e == nil {
 			break
@@ -249,11 +257,15 @@ func (file *file) close() error {
 		    println(file.name)
         })
 		argInfo := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo.String[:], file.name)
+        argInfo.String = file.name
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_CLOSE, 1, 1, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_CLOSE, syscallInfo)
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_CLOSE, 0); e != nil {
+		return &PathError{"close", file.name, e}
+	}
 	var err error
 	if e := file.pfd.Close(); e != nil {
 		if e == poll.ErrFileClosing {
@@ -270,7 +282,12 @@ func (file *file) close() error {
 // read reads up to len(b) bytes from the File.
 // It returns the number of bytes read and an error, if any.
 func (f *File) read(b []byte) (n int, err error) {
-	n, err = f.pfd.Read(b)
+	// DARA Instrumentation
+	m, err := daraFault(dara.DSYS_READ, len(b))
+	if err != nil {
+		return 0, err
+	}
+	n, err = f.pfd.Read(b[:m])
 	runtime.KeepAlive(f)
 	// DARA Instrumentation
 	if runtime.Is_dara_profiling_on() {
@@ -279,7 +296,7 @@ func (f *File) read(b []byte) (n int, err error) {
 		    println(f.file.name)
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.ARRAY, Integer: len(b)}
 		retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: n}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -293,7 +310,12 @@ func (f *File) read(b []byte) (n int, err error) {
 // It returns the number of bytes read and the error, if any.
 // EOF is signaled by a zero count with err set to nil.
 func (f *File) pread(b []byte, off int64) (n int, err error) {
-	n, err = f.pfd.Pread(b, off)
+	// DARA Instrumentation
+	m, err := daraFault(dara.DSYS_PREAD64, len(b))
+	if err != nil {
+		return 0, err
+	}
+	n, err = f.pfd.Pread(b[:m], off)
 	runtime.KeepAlive(f)
 	// DARA Instrumentation
 	if runtime.Is_dara_profiling_on() {
@@ -304,7 +326,7 @@ func (f *File) pread(b []byte, off int64) (n int, err error) {
 		    println(off)
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.ARRAY, Integer: len(b)}
 		argInfo3 := dara.GeneralType{Type: dara.INTEGER64, Integer64: off}
 		retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: n}
@@ -318,7 +340,13 @@ func (f *File) pread(b []byte, off int64) (n int, err error) {
 // write writes len(b) bytes to the File.
 // It returns the number of bytes written and an error, if any.
 func (f *File) write(b []byte) (n int, err error) {
-	n, err = f.pfd.Write(b)
+	// DARA Instrumentation
+	runtime.DaraSyscallPoint(dara.DSYS_WRITE)
+	m, err := daraFault(dara.DSYS_WRITE, len(b))
+	if err != nil {
+		return 0, err
+	}
+	n, err = f.pfd.Write(b[:m])
 	runtime.KeepAlive(f)
 	// DARA Instrumentation
 	if runtime.Is_dara_profiling_on() {
@@ -329,7 +357,7 @@ func (f *File) write(b []byte) (n int, err error) {
 		    println(string(b[:len(b)]))
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.ARRAY, Integer: len(b)}
 		retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: n}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
@@ -343,6 +371,7 @@ func (f *File) write(b []byte) (n int, err error) {
 // It returns the number of bytes written and an error, if any.
 func (f *File) pwrite(b []byte, off int64) (n int, err error) {
     // DARA Instrumentation
+	runtime.DaraSyscallPoint(dara.DSYS_PWRITE64)
 	if runtime.Is_dara_profiling_on() {
         runtime.Dara_Debug_Print(func() {
 		    print("[PWRITE] : ")
@@ -353,7 +382,7 @@ func (f *File) pwrite(b []byte, off int64) (n int, err error) {
 		    println(off)
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.ARRAY, Integer: len(b)}
 		argInfo3 := dara.GeneralType{Type: dara.INTEGER64, Integer64: off}
 		retInfo1 := dara.GeneralType{Type: dara.INTEGER, Integer: n}
@@ -361,7 +390,12 @@ func (f *File) pwrite(b []byte, off int64) (n int, err error) {
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_PWRITE64, 3, 2, [10]dara.GeneralType{argInfo1, argInfo2, argInfo3}, [10]dara.GeneralType{retInfo1, retInfo2}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_PWRITE64, syscallInfo)
 	}
-	n, err = f.pfd.Pwrite(b, off)
+	// DARA Instrumentation
+	m, err := daraFault(dara.DSYS_PWRITE64, len(b))
+	if err != nil {
+		return 0, err
+	}
+	n, err = f.pfd.Pwrite(b[:m], off)
 	runtime.KeepAlive(f)
 	return n, err
 }
@@ -371,6 +405,10 @@ func (f *File) pwrite(b []byte, off int64) (n int, err error) {
 // relative to the current offset, and 2 means relative to the end.
 // It returns the new offset and an error, if any.
 func (f *File) seek(offset int64, whence int) (ret int64, err error) {
+	// DARA Instrumentation
+	if _, err = daraFault(dara.DSYS_LSEEK, 0); err != nil {
+		return 0, err
+	}
 	ret, err = f.pfd.Seek(offset, whence)
 	runtime.KeepAlive(f)
 	// DARA Instrumentation
@@ -384,7 +422,7 @@ func (f *File) seek(offset int64, whence int) (ret int64, err error) {
 		    println(whence)
         })
 		argInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(argInfo1.String[:], f.name)
+        argInfo1.String = f.name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER64, Integer64: offset}
 		argInfo3 := dara.GeneralType{Type: dara.INTEGER, Integer: whence}
 		retInfo1 := dara.GeneralType{Type: dara.INTEGER64, Integer64: ret}
@@ -408,12 +446,16 @@ func Truncate(name string, size int64) error {
 		    println(size)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], name)
+        argInfo1.String = name
 		argInfo2 := dara.GeneralType{Type: dara.INTEGER64, Integer64: size}
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_TRUNCATE, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_TRUNCATE, syscallInfo)
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_TRUNCATE, 0); e != nil {
+		return &PathError{"truncate", name, e}
+	}
 	if e := syscall.Truncate(name, size); e != nil {
 		return &PathError{"truncate", name, e}
 	}
@@ -466,7 +508,12 @@ func tempDir() string {
 // Link creates newname as a hard link to the oldname file.
 // If there is an error, it will be of type *LinkError.
 func Link(oldname, newname string) error {
-	e := syscall.Link(oldname, newname)
+	// DARA Instrumentation
+	_, e := daraFault(dara.DSYS_LINK, 0)
+	if e != nil {
+		return &LinkError{"link", oldname, newname, e}
+	}
+	e = syscall.Link(oldname, newname)
 	// DARA Instrumentation
 	if runtime.Is_dara_profiling_on() {
         runtime.Dara_Debug_Print(func() {
@@ -476,9 +523,9 @@ func Link(oldname, newname string) error {
 		    println(newname)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], oldname)
+        argInfo1.String = oldname
 		argInfo2 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo2.String[:], newname)
+        argInfo2.String = newname
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_LINK, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_LINK, syscallInfo)
@@ -492,7 +539,12 @@ func Link(oldname, newname string) error {
 // Symlink creates newname as a symbolic link to oldname.
 // If there is an error, it will be of type *LinkError.
 func Symlink(oldname, newname string) error {
-	e := syscall.Symlink(oldname, newname)
+	// DARA Instrumentation
+	_, e := daraFault(dara.DSYS_SYMLINK, 0)
+	if e != nil {
+		return &LinkError{"symlink", oldname, newname, e}
+	}
+	e = syscall.Symlink(oldname, newname)
 	// DARA Instrumentation
 	if runtime.Is_dara_profiling_on() {
         runtime.Dara_Debug_Print(func() {
@@ -502,9 +554,9 @@ func Symlink(oldname, newname string) error {
 		    println(newname)
         })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], oldname)
+        argInfo1.String = oldname
 		argInfo2 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo2.String[:], newname)
+        argInfo2.String = newname
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_SYMLINK, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_SYMLINK, syscallInfo)
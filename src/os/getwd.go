@@ -31,7 +31,7 @@ func Getwd() (dir string, err error) {
 		if runtime.Is_dara_profiling_on() {
 			runtime.Dara_Debug_Print(func() { println("[GETWD]") })
 			retInfo1 := dara.GeneralType{Type: dara.STRING}
-            copy(retInfo1.String[:], dir)
+            retInfo1.String = dir
 			retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 			syscallInfo := dara.GeneralSyscall{dara.DSYS_GETWD, 0, 2, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo1, retInfo2}}
 			runtime.Report_Syscall_To_Scheduler(dara.DSYS_GETWD, syscallInfo)
@@ -61,7 +61,7 @@ func Getwd() (dir string, err error) {
 			if runtime.Is_dara_profiling_on() {
 				runtime.Dara_Debug_Print(func() { println("[GETWD]") })
 				retInfo1 := dara.GeneralType{Type: dara.STRING}
-                copy(retInfo1.String[:], dir)
+                retInfo1.String = dir
 				retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 				syscallInfo := dara.GeneralSyscall{dara.DSYS_GETWD, 0, 2, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo1, retInfo2}}
 				runtime.Report_Syscall_To_Scheduler(dara.DSYS_GETWD, syscallInfo)
@@ -79,7 +79,7 @@ func Getwd() (dir string, err error) {
 			if runtime.Is_dara_profiling_on() {
 				runtime.Dara_Debug_Print(func() { println("[GETWD]") })
 				retInfo1 := dara.GeneralType{Type: dara.STRING}
-                copy(retInfo1.String[:], s)
+                retInfo1.String = s
 				retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 				syscallInfo := dara.GeneralSyscall{dara.DSYS_GETWD, 0, 2, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo1, retInfo2}}
 				runtime.Report_Syscall_To_Scheduler(dara.DSYS_GETWD, syscallInfo)
@@ -99,7 +99,7 @@ func Getwd() (dir string, err error) {
 			if runtime.Is_dara_profiling_on() {
 				runtime.Dara_Debug_Print(func() { println("[GETWD]") })
 				retInfo1 := dara.GeneralType{Type: dara.STRING}
-                copy(retInfo1.String[:], dir)
+                retInfo1.String = dir
 				retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 				syscallInfo := dara.GeneralSyscall{dara.DSYS_GETWD, 0, 2, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo1, retInfo2}}
 				runtime.Report_Syscall_To_Scheduler(dara.DSYS_GETWD, syscallInfo)
@@ -128,7 +128,7 @@ func Getwd() (dir string, err error) {
 		if runtime.Is_dara_profiling_on() {
 			runtime.Dara_Debug_Print(func() { println("[GETWD]") })
 			retInfo1 := dara.GeneralType{Type: dara.STRING}
-            copy(retInfo1.String[:], "/")
+            retInfo1.String = "/"
 			retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 			syscallInfo := dara.GeneralSyscall{dara.DSYS_GETWD, 0, 2, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo1, retInfo2}}
 			runtime.Report_Syscall_To_Scheduler(dara.DSYS_GETWD, syscallInfo)
@@ -218,7 +218,7 @@ func Getwd() (dir string, err error) {
 	if runtime.Is_dara_profiling_on() {
 		runtime.Dara_Debug_Print(func() { println("[GETWD]") })
 		retInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(retInfo1.String[:], dir)
+        retInfo1.String = dir
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_GETWD, 0, 2, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo1, retInfo2}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_GETWD, syscallInfo)
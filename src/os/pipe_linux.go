@@ -16,9 +16,9 @@ func Pipe() (r *File, w *File, err error) {
 	if runtime.Is_dara_profiling_on() {
 		runtime.Dara_Debug_Print(func() { println("[PIPE]") })
 		retInfo1 := dara.GeneralType{Type: dara.FILE}
-        copy(retInfo1.String[:], r.name)
+        retInfo1.String = r.name
 		retInfo2 := dara.GeneralType{Type: dara.FILE}
-        copy(retInfo2.String[:], w.name)
+        retInfo2.String = w.name
 		retInfo3 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_PIPE2, 0, 3, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo1, retInfo2, retInfo3}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_PIPE2, syscallInfo)
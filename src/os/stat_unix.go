@@ -22,7 +22,7 @@ func (f *File) Stat() (FileInfo, error) {
 		    println(f.file.name)
         })
 		argInfo := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo.String[:], f.name)
+        argInfo.String = f.name
 		retInfo1 := dara.GeneralType{Type: dara.FILEINFO, Unsupported: dara.UNSUPPORTEDVAL}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_FSTAT, 1, 2, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo1, retInfo2}}
@@ -32,6 +32,10 @@ func (f *File) Stat() (FileInfo, error) {
 		return nil, ErrInvalid
 	}
 	var fs fileStat
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_FSTAT, 0); e != nil {
+		return nil, &PathError{"stat", f.name, e}
+	}
 	err := f.pfd.Fstat(&fs.sys)
 	if err != nil {
 		return nil, &PathError{"stat", f.name, err}
@@ -47,12 +51,16 @@ func statNolog(name string) (FileInfo, error) {
 	if runtime.Is_dara_profiling_on() {
 		runtime.Dara_Debug_Print(func() { println("[STAT] : " + name) })
 		argInfo := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo.String[:], name)
+        argInfo.String = name
 		retInfo1 := dara.GeneralType{Type: dara.FILEINFO, Unsupported: dara.UNSUPPORTEDVAL}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_STAT, 1, 2, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo1, retInfo2}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_STAT, syscallInfo)
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_STAT, 0); e != nil {
+		return nil, &PathError{"stat", name, e}
+	}
 	err := syscall.Stat(name, &fs.sys)
 	if err != nil {
 		return nil, &PathError{"stat", name, err}
@@ -68,12 +76,16 @@ func lstatNolog(name string) (FileInfo, error) {
 	if runtime.Is_dara_profiling_on() {
 		runtime.Dara_Debug_Print(func() { println("[LSTAT] : " + name) })
 		argInfo := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo.String[:], name)
+        argInfo.String = name
 		retInfo1 := dara.GeneralType{Type: dara.FILEINFO, Unsupported: dara.UNSUPPORTEDVAL}
 		retInfo2 := dara.GeneralType{Type: dara.ERROR, Unsupported: dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_LSTAT, 1, 2, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo1, retInfo2}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_LSTAT, syscallInfo)
 	}
+	// DARA Instrumentation
+	if _, e := daraFault(dara.DSYS_LSTAT, 0); e != nil {
+		return nil, &PathError{"lstat", name, e}
+	}
 	err := syscall.Lstat(name, &fs.sys)
 	if err != nil {
 		return nil, &PathError{"lstat", name, err}
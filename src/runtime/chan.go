@@ -48,6 +48,11 @@ type hchan struct {
 	// (in particular, do not ready a G), as this can deadlock
 	// with stack shrinking.
 	lock mutex
+
+	// darapc and daraindex identify the channel under Dara, see
+	// daraNameChan.
+	darapc    uintptr
+	daraindex int
 }
 
 type waitq struct {
@@ -108,6 +113,10 @@ func makechan(t *chantype, size int) *hchan {
 	c.elemsize = uint16(elem.size)
 	c.elemtype = elem
 	c.dataqsiz = uint(size)
+	if DaraInitialised {
+		daraNameChan(c, getcallerpc())
+		daraClockMakeChan(c)
+	}
 
 	if debugChan {
 		print("makechan: chan=", c, "; elemsize=", elem.size, "; elemalg=", elem.alg, "; dataqsiz=", size, "\n")
@@ -189,13 +198,10 @@ func chansend(c *hchan, ep unsafe.Pointer, block bool, callerpc uintptr) bool {
 	if sg := c.recvq.dequeue(); sg != nil {
 		// Found a waiting receiver. We pass the value we want to send
 		// directly to the receiver, bypassing the channel buffer (if any).
+		partner := sg.g.goid
 		send(c, sg, ep, func() { unlock(&c.lock) }, 3)
 		if DaraInitialised {
-			if v, ok := ChanSendInfo[unsafe.Pointer(c)]; ok {
-				ChanSendInfo[unsafe.Pointer(c)] = v + 1
-			} else {
-				ChanSendInfo[unsafe.Pointer(c)] = 1
-			}
+			daraChanHandoff(dara.SEND_EVENT, c, 0, partner)
 		}
 		return true
 	}
@@ -207,19 +213,19 @@ func chansend(c *hchan, ep unsafe.Pointer, block bool, callerpc uintptr) bool {
 			raceacquire(qp)
 			racerelease(qp)
 		}
+		if DaraInitialised {
+			daraClockSlot(c, c.sendx, getg())
+		}
 		typedmemmove(c.elemtype, qp, ep)
 		c.sendx++
 		if c.sendx == c.dataqsiz {
 			c.sendx = 0
 		}
 		c.qcount++
+		n := c.qcount
 		unlock(&c.lock)
 		if DaraInitialised {
-			if v, ok := ChanSendInfo[unsafe.Pointer(c)]; ok {
-				ChanSendInfo[unsafe.Pointer(c)] = v + 1
-			} else {
-				ChanSendInfo[unsafe.Pointer(c)] = 1
-			}
+			daraChanEvent(dara.SEND_EVENT, c, n, 0, false)
 		}
 		return true
 	}
@@ -265,13 +271,7 @@ func chansend(c *hchan, ep unsafe.Pointer, block bool, callerpc uintptr) bool {
 	}
 	mysg.c = nil
 	releaseSudog(mysg)
-	if DaraInitialised {
-		if v, ok := ChanSendInfo[unsafe.Pointer(c)]; ok {
-			ChanSendInfo[unsafe.Pointer(c)] = v + 1
-		} else {
-			ChanSendInfo[unsafe.Pointer(c)] = 1
-		}
-	}
+	//The receiver which woke us up has logged the send
 	return true
 }
 
@@ -282,6 +282,9 @@ func chansend(c *hchan, ep unsafe.Pointer, block bool, callerpc uintptr) bool {
 // sg must already be dequeued from c.
 // ep must be non-nil and point to the heap or the caller's stack.
 func send(c *hchan, sg *sudog, ep unsafe.Pointer, unlockf func(), skip int) {
+	if DaraInitialised {
+		daraClockChan(c, sg)
+	}
 	if raceenabled {
 		if c.dataqsiz == 0 {
 			racesync(c, sg)
@@ -487,6 +490,9 @@ func chanrecv(c *hchan, ep unsafe.Pointer, block bool) (selected, received bool)
 			typedmemclr(c.elemtype, ep)
 		}
 		dprint(dara.INFO, func() {println("[GoRuntime]chanrecv: channel closed before delivery")})
+		if DaraInitialised {
+			daraChanEvent(dara.REC_EVENT, c, 0, 0, true)
+		}
 		return true, false
 	}
 
@@ -495,13 +501,10 @@ func chanrecv(c *hchan, ep unsafe.Pointer, block bool) (selected, received bool)
 		// directly from sender. Otherwise, receive from head of queue
 		// and add sender's value to the tail of the queue (both map to
 		// the same buffer slot because the queue is full).
+		partner, n := sg.g.goid, c.qcount
 		recv(c, sg, ep, func() { unlock(&c.lock) }, 3)
 		if DaraInitialised {
-			if v, ok := ChanRecvInfo[unsafe.Pointer(c)]; ok {
-				ChanRecvInfo[unsafe.Pointer(c)] = v + 1
-			} else {
-				ChanRecvInfo[unsafe.Pointer(c)] = 1
-			}
+			daraChanHandoff(dara.REC_EVENT, c, n, partner)
 		}
 		return true, true
 	}
@@ -513,6 +516,9 @@ func chanrecv(c *hchan, ep unsafe.Pointer, block bool) (selected, received bool)
 			raceacquire(qp)
 			racerelease(qp)
 		}
+		if DaraInitialised {
+			daraClockSlot(c, c.recvx, getg())
+		}
 		if ep != nil {
 			typedmemmove(c.elemtype, ep, qp)
 		}
@@ -522,13 +528,10 @@ func chanrecv(c *hchan, ep unsafe.Pointer, block bool) (selected, received bool)
 			c.recvx = 0
 		}
 		c.qcount--
+		n := c.qcount
 		unlock(&c.lock)
 		if DaraInitialised {
-			if v, ok := ChanRecvInfo[unsafe.Pointer(c)]; ok {
-				ChanRecvInfo[unsafe.Pointer(c)] = v + 1
-			} else {
-				ChanRecvInfo[unsafe.Pointer(c)] = 1
-			}
+			daraChanEvent(dara.REC_EVENT, c, n, 0, false)
 		}
 		return true, true
 	}
@@ -571,16 +574,11 @@ func chanrecv(c *hchan, ep unsafe.Pointer, block bool) (selected, received bool)
 	gp.param = nil
 	mysg.c = nil
 	releaseSudog(mysg)
-	if DaraInitialised {
-		if !closed {
-			if v, ok := ChanRecvInfo[unsafe.Pointer(c)]; ok {
-				ChanRecvInfo[unsafe.Pointer(c)] = v + 1
-			} else {
-				ChanRecvInfo[unsafe.Pointer(c)] = 1
-			}
-		} else {
-			dprint(dara.INFO, func() {println("[GoRuntime]recv: Channel closed before delivery")} )
-		}
+	//Unless the channel was closed the sender which woke us up has
+	//logged the receive
+	if DaraInitialised && closed {
+		dprint(dara.INFO, func() {println("[GoRuntime]recv: Channel closed before delivery")} )
+		daraChanEvent(dara.REC_EVENT, c, 0, 0, true)
 	}
 	return true, !closed
 }
@@ -599,6 +597,9 @@ func chanrecv(c *hchan, ep unsafe.Pointer, block bool) (selected, received bool)
 // sg must already be dequeued from c.
 // A non-nil ep must point to the heap or the caller's stack.
 func recv(c *hchan, sg *sudog, ep unsafe.Pointer, unlockf func(), skip int) {
+	if DaraInitialised {
+		daraClockChan(c, sg)
+	}
 	if c.dataqsiz == 0 {
 		if raceenabled {
 			racesync(c, sg)
@@ -792,3 +793,63 @@ func racesync(c *hchan, sg *sudog) {
 	racereleaseg(sg.g, chanbuf(c, 0))
 	raceacquire(chanbuf(c, 0))
 }
+
+//daraNameChan gives c its identity under Dara: the pc it is made at,
+//and the number of channels made at that pc before it. Unlike the
+//address of c this is the same in every run which follows the same
+//schedule.
+func daraNameChan(c *hchan, pc uintptr) {
+	c.darapc = pc
+	c.daraindex = ChanMakeInfo[pc]
+	ChanMakeInfo[pc]++
+}
+
+//daraChanEvent counts and logs a completed send or receive on c by the
+//running goroutine. n is the number of elements left in the buffer,
+//partner the goid of the goroutine on the other end of the operation
+//or 0. The partner is only reported for unbuffered channels, on which
+//every operation is a handoff. closed is set by a receive which got
+//the zero value of a closed channel.
+func daraChanEvent(typ int, c *hchan, n uint, partner int64, closed bool) {
+	daraChanEventOf(dproc.RunningRoutine, typ, c, n, partner, closed)
+}
+
+//daraChanHandoff counts and logs a send or receive on c by the running
+//goroutine which completed the opposite operation of partner, which was
+//parked on c. partner is woken up with its operation done, it is logged
+//for it right after the operation of the running goroutine.
+func daraChanHandoff(typ int, c *hchan, n uint, partner int64) {
+	daraChanEvent(typ, c, n, partner, false)
+	other := dara.REC_EVENT
+	if typ == dara.REC_EVENT {
+		other = dara.SEND_EVENT
+	}
+	daraChanEventOf(daraRoutine(partner), other, c, n, getg().goid, false)
+}
+
+//daraRoutine returns what dproc knows about the goroutine goid
+func daraRoutine(goid int64) dara.RoutineInfo {
+	if goid < int64(len(dproc.Routines)) {
+		return dproc.Routines[goid]
+	}
+	return dara.RoutineInfo{Gid: int(goid)}
+}
+
+//daraChanEventOf is daraChanEvent for the operation of the goroutine g
+func daraChanEventOf(g dara.RoutineInfo, typ int, c *hchan, n uint, partner int64, closed bool) {
+	if typ == dara.SEND_EVENT {
+		ChanSendInfo[unsafe.Pointer(c)]++
+	} else if !closed {
+		ChanRecvInfo[unsafe.Pointer(c)]++
+	}
+	m := dara.Message{
+		Chan:   dara.ChanID{Pc: c.darapc, Index: c.daraindex},
+		Len:    int(n),
+		Cap:    int(c.dataqsiz),
+		Closed: closed,
+	}
+	if c.dataqsiz == 0 && partner != 0 {
+		m.Partner = daraRoutine(partner)
+	}
+	logMessageOf(g, typ, m)
+}
@@ -40,8 +40,39 @@ func ReportBlockCoverage(blockID string) {
     }
 }
 
+//DaraSyscallPoint is called by a goroutine about to make the syscall
+//syscallID. It is a scheduling point if the class of the syscall was
+//selected with DARA_SYSCALL_POINTS, and costs no more than a check
+//otherwise.
+func DaraSyscallPoint(syscallID int) {
+	if DaraInitialised && !Nanobenchmark && SyscallPoints&dara.SyscallPoint(syscallID) != 0 {
+		report_syscall(syscallID)
+	}
+}
+
+//DaraFault is called by an instrumented os or net function before it
+//makes the syscall syscallID. It counts the call, and if the global
+//scheduler put a fault for it in DaraProc.Faults it logs the fault and
+//returns what the function must do instead: fail with errno, or if
+//errno is 0 cut its read or write down to short bytes.
+func DaraFault(syscallID int) (errno, short int, ok bool) {
+	if !DaraInitialised || Nanobenchmark {
+		return 0, 0, false
+	}
+	SyscallCalls[syscallID]++
+	call := SyscallCalls[syscallID]
+	for i := 0; i < dproc.NumFaults && i < len(dproc.Faults); i++ {
+		f := dproc.Faults[i]
+		if f.Syscall == syscallID && f.Call == call {
+			dprint(dara.INFO, func() { println("[GoRuntime]DaraFault : Injecting errno", f.Errno, "short", f.Short, "into call", call, "of syscall", syscallID) })
+			LogFault(f)
+			return f.Errno, f.Short, true
+		}
+	}
+	return 0, 0, false
+}
+
 func Report_Syscall_To_Scheduler(syscallID int, syscallInfo dara.GeneralSyscall) {
-	//report_syscall(syscallID, syscallInfo) //TODO remove this it's redundent and slow
 	var start int64
 	if Microbenchmark || Nanobenchmark {
 		// Get the time in nanoseconds (atleast I think this is what the function does)
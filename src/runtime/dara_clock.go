@@ -0,0 +1,255 @@
+package runtime
+
+import (
+	"dara"
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+//Every goroutine keeps a vector clock, which is attached to each event
+//it logs. The clocks are joined wherever the Go memory model orders
+//two goroutines: on channel operations, mutex unlock to lock, WaitGroup
+//Done to Wait, and net writes to the reads of another Dara process.
+//They follow what the race detector does in the same places.
+
+//daraClocks are the clocks of the goroutines indexed by goid, like
+//DaraProc.Routines. Goroutines beyond MAXGOROUTINES have no clock.
+var daraClocks [dara.MAXGOROUTINES]dara.VectorClock
+
+//How many buffer slots of a channel get a clock of their own, slots
+//beyond it share the clocks
+const daraMaxChanClocks = 64
+
+//daraClockDropped is set once a clock has run out of entries
+var daraClockDropped bool
+
+func daraClockOf(gp *g) *dara.VectorClock {
+	if gp == nil || gp.goid < 0 || gp.goid >= int64(len(daraClocks)) {
+		return nil
+	}
+	return &daraClocks[gp.goid]
+}
+
+func daraClockCheck(ok bool) {
+	if !ok && !daraClockDropped {
+		daraClockDropped = true
+		dprint(dara.WARN, func() {
+			println("[GoRuntime]daraClockCheck : More than", dara.MAXCLOCKENTRIES, "goroutines in a vector clock, the happens before relation of events is incomplete")
+		})
+	}
+}
+
+//daraClockEvent counts an event of the goroutine gid and copies its
+//clock to the arena. It may only be called between daraLogSlot and
+//daraLogCommit.
+func daraClockEvent(gid int) dara.ArenaRef {
+	if gid < 0 || gid >= len(daraClocks) {
+		return dara.ArenaRef{}
+	}
+	c := &daraClocks[gid]
+	daraClockCheck(c.Tick(DPid, gid))
+	ref, b := daraArenaAlloc(c.Len * dara.CLOCKENTRYSIZE)
+	ref.Len = uint32(c.Encode(b))
+	return ref
+}
+
+//daraClockFork starts the clock of newg, created by gp, as a copy of
+//the clock of gp
+func daraClockFork(gp, newg *g) {
+	nc := daraClockOf(newg)
+	if nc == nil {
+		return
+	}
+	if c := daraClockOf(gp); c != nil {
+		*nc = *c
+	} else {
+		*nc = dara.VectorClock{}
+	}
+}
+
+//daraClockJoin joins the clock of src into the clock of dst
+func daraClockJoin(dst, src *g) {
+	d, s := daraClockOf(dst), daraClockOf(src)
+	if d != nil && s != nil {
+		daraClockCheck(d.Join(s))
+	}
+}
+
+//daraClockSlot passes the clock of gp through the clock of slot i of
+//the buffer of c: the goroutines which went through the slot before
+//happened before gp, and gp happens before the ones that come after.
+//c must be locked.
+func daraClockSlot(c *hchan, i uint, gp *g) {
+	clocks := ChanClockInfo[unsafe.Pointer(c)]
+	own := daraClockOf(gp)
+	if len(clocks) == 0 || own == nil {
+		return
+	}
+	slot := &clocks[i%uint(len(clocks))]
+	daraClockCheck(own.Join(slot))
+	daraClockCheck(slot.Join(own))
+}
+
+//daraClockChan orders the running goroutine and sg, which it hands a
+//value to or takes a value from on c. On an unbuffered channel the two
+//synchronise, on a buffered one they pass through the slot at the head
+//of the buffer. c must be locked.
+func daraClockChan(c *hchan, sg *sudog) {
+	gp := getg()
+	if c.dataqsiz == 0 {
+		daraClockJoin(gp, sg.g)
+		daraClockJoin(sg.g, gp)
+		return
+	}
+	daraClockSlot(c, c.recvx, gp)
+	daraClockSlot(c, c.recvx, sg.g)
+}
+
+//daraClockMakeChan allocates the slot clocks of the buffered channel c
+func daraClockMakeChan(c *hchan) {
+	if c.dataqsiz == 0 {
+		return
+	}
+	n := c.dataqsiz
+	if n > daraMaxChanClocks {
+		n = daraMaxChanClocks
+	}
+	ChanClockInfo[unsafe.Pointer(c)] = make([]dara.VectorClock, n)
+}
+
+//DaraRelease joins the clock of the running goroutine into the clock
+//of the synchronisation object at addr, the next DaraAcquire of addr
+//happens after it
+func DaraRelease(addr unsafe.Pointer) {
+	if !DaraInitialised {
+		return
+	}
+	own := daraClockOf(getg())
+	if own == nil {
+		return
+	}
+	c := SyncClockInfo[addr]
+	if c == nil {
+		c = new(dara.VectorClock)
+		SyncClockInfo[addr] = c
+	}
+	daraClockCheck(c.Join(own))
+}
+
+//DaraAcquire joins the clock of the synchronisation object at addr
+//into the clock of the running goroutine
+func DaraAcquire(addr unsafe.Pointer) {
+	if !DaraInitialised {
+		return
+	}
+	own := daraClockOf(getg())
+	if c := SyncClockInfo[addr]; c != nil && own != nil {
+		daraClockCheck(own.Join(c))
+	}
+}
+
+//daraConnIs reports whether nc is the connection from local to remote
+func daraConnIs(nc *dara.NetClock, local, remote string) bool {
+	if nc.ConnLen != len(local)+1+len(remote) {
+		return false
+	}
+	for i := 0; i < len(local); i++ {
+		if nc.Conn[i] != local[i] {
+			return false
+		}
+	}
+	n := len(local)
+	if nc.Conn[n] != '>' {
+		return false
+	}
+	n++
+	for i := 0; i < len(remote); i++ {
+		if nc.Conn[n+i] != remote[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//DaraNetWrite publishes the clock of the running goroutine, which has
+//just written to the connection from local to remote, in the
+//NetClocks of this process
+func DaraNetWrite(local, remote string) {
+	if !DaraInitialised || Nanobenchmark {
+		return
+	}
+	own := daraClockOf(getg())
+	if own == nil {
+		return
+	}
+	if len(local)+1+len(remote) > dara.MAXCONNLEN {
+		dprint(dara.WARN, func() { println("[GoRuntime]DaraNetWrite : Connection", local, remote, "has too long a name to publish its clock") })
+		return
+	}
+	var nc *dara.NetClock
+	for i := range dproc.NetClocks {
+		c := &dproc.NetClocks[i]
+		if daraConnIs(c, local, remote) {
+			nc = c
+			break
+		}
+		if nc == nil && c.ConnLen == 0 {
+			nc = c
+		}
+	}
+	if nc == nil {
+		dprint(dara.WARN, func() { println("[GoRuntime]DaraNetWrite : No room for the clock of connection", local, remote) })
+		return
+	}
+	atomic.Xadd(&nc.Seq, 1)
+	if nc.ConnLen == 0 {
+		n := copy(nc.Conn[:], local)
+		nc.Conn[n] = '>'
+		n++
+		n += copy(nc.Conn[n:], remote)
+		nc.ConnLen = n
+	}
+	daraClockCheck(nc.Clock.Join(own))
+	atomic.Xadd(&nc.Seq, 1)
+}
+
+//DaraNetRead joins the clock the other end of the connection from
+//local to remote published with DaraNetWrite into the clock of the
+//running goroutine, which has just read from the connection
+func DaraNetRead(local, remote string) {
+	if !DaraInitialised || Nanobenchmark {
+		return
+	}
+	own := daraClockOf(getg())
+	if own == nil {
+		return
+	}
+	var clock dara.VectorClock
+	for pid := 1; pid <= dheader.NumProcs; pid++ {
+		if pid == DPid {
+			continue
+		}
+		dp := daraProcAt(pid)
+		for i := range dp.NetClocks {
+			nc := &dp.NetClocks[i]
+			for {
+				seq := atomic.Load(&nc.Seq)
+				if seq&1 != 0 {
+					osyield()
+					continue
+				}
+				found := daraConnIs(nc, remote, local)
+				if found {
+					clock = nc.Clock
+				}
+				if atomic.Load(&nc.Seq) != seq {
+					continue
+				}
+				if found {
+					daraClockCheck(own.Join(&clock))
+				}
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// +build linux
+
+package runtime
+
+import (
+	"dara"
+	"runtime/internal/atomic"
+)
+
+// Handing a lock word back and forth between a runtime and the global
+// scheduler is usually quick, so a waiter spins for a short while
+// first. After that it marks the word contended and sleeps on it with
+// futex. Shared memory is MAP_SHARED, so the non-private futex ops
+// used by futexsleep and futexwakeup work across processes.
+const (
+	daraPassiveSpin = 4
+	// Schedulers that do not advertise dara.FEATURE_FUTEX never wake
+	// us, so without it sleeps are bounded and the word is polled.
+	daraPollSleep = 50 * 1000
+)
+
+// daraLock acquires the shared memory lock word at addr.
+func daraLock(addr *uint32) {
+	if atomic.Cas(addr, dara.UNLOCKED, dara.LOCKED) {
+		return
+	}
+	for i := 0; i < daraActiveSpin; i++ {
+		procyield(daraActiveSpinCnt)
+		if atomic.Cas(addr, dara.UNLOCKED, dara.LOCKED) {
+			return
+		}
+	}
+	for i := 0; i < daraPassiveSpin; i++ {
+		osyield()
+		if atomic.Cas(addr, dara.UNLOCKED, dara.LOCKED) {
+			return
+		}
+	}
+	ns := int64(daraPollSleep)
+	if dheader.Features&dara.FEATURE_FUTEX != 0 {
+		ns = -1
+	}
+	// From here on the word is taken as CONTENDED so that whoever
+	// releases it knows to wake us up.
+	for atomic.Xchg(addr, dara.CONTENDED) != dara.UNLOCKED {
+		futexsleep(addr, dara.CONTENDED, ns)
+	}
+}
+
+// daraWait waits until the word at addr no longer holds val. Like the
+// futex it is built on it may return early, callers must recheck.
+func daraWait(addr *uint32, val uint32) {
+	for i := 0; i < daraActiveSpin; i++ {
+		if atomic.Load(addr) != val {
+			return
+		}
+		procyield(daraActiveSpinCnt)
+	}
+	ns := int64(daraPollSleep)
+	if dheader.Features&dara.FEATURE_FUTEX != 0 {
+		ns = -1
+	}
+	futexsleep(addr, val, ns)
+}
+
+// daraWake wakes up the other side if it is waiting on addr.
+func daraWake(addr *uint32) {
+	futexwakeup(addr, 1)
+}
+
+// daraUnlock releases the shared memory lock word at addr and wakes
+// up the other side if it went to sleep waiting for it.
+func daraUnlock(addr *uint32) {
+	if atomic.Xchg(addr, dara.UNLOCKED) == dara.CONTENDED {
+		futexwakeup(addr, 1)
+	}
+}
@@ -0,0 +1,74 @@
+package runtime_test
+
+import (
+	. "runtime"
+	"sync/atomic"
+	"testing"
+)
+
+// daraLockOps is one way of handing a lock word over.
+type daraLockOps struct {
+	lock   func(addr *uint32)
+	unlock func(addr *uint32)
+	wait   func(addr *uint32, val uint32)
+	wake   func(addr *uint32)
+}
+
+// daraHandoff is the state two sides hand back and forth, like a
+// runtime and the global scheduler take turns on a DaraProc.
+type daraHandoff struct {
+	lock   uint32
+	turn   uint32
+	signal uint32
+}
+
+// take waits for the turn of side me n times, and hands the turn to
+// the other side each time.
+func (h *daraHandoff) take(ops *daraLockOps, me uint32, n int) {
+	for i := 0; i < n; i++ {
+		for {
+			signal := atomic.LoadUint32(&h.signal)
+			ops.lock(&h.lock)
+			if h.turn == me {
+				h.turn = 1 - me
+				atomic.AddUint32(&h.signal, 1)
+				ops.unlock(&h.lock)
+				ops.wake(&h.signal)
+				break
+			}
+			ops.unlock(&h.lock)
+			ops.wait(&h.signal, signal)
+		}
+	}
+}
+
+func benchmarkDaraLockHandoff(b *testing.B, ops *daraLockOps) {
+	if GOMAXPROCS(0) < 2 {
+		b.Skip("the two sides of a handoff need their own threads")
+	}
+	h := new(daraHandoff)
+	done := make(chan bool)
+	LockOSThread()
+	defer UnlockOSThread()
+	// A side asleep on a futex can not be stopped, so the world must
+	// not be stopped once the other side has started.
+	b.ResetTimer()
+	go func() {
+		LockOSThread()
+		h.take(ops, 1, b.N)
+		done <- true
+	}()
+	h.take(ops, 0, b.N)
+	<-done
+	b.StopTimer()
+}
+
+func BenchmarkDaraLockHandoffFutex(b *testing.B) {
+	SetDaraFutex(true)
+	defer SetDaraFutex(false)
+	benchmarkDaraLockHandoff(b, &daraLockOps{DaraLock, DaraUnlock, DaraWait, DaraWake})
+}
+
+func BenchmarkDaraLockHandoffSpin(b *testing.B) {
+	benchmarkDaraLockHandoff(b, &daraLockOps{DaraSpinLock, DaraSpinUnlock, DaraSpinWait, func(*uint32) {}})
+}
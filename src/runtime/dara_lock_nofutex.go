@@ -0,0 +1,23 @@
+// +build !linux
+
+package runtime
+
+// daraLock acquires the shared memory lock word at addr.
+func daraLock(addr *uint32) {
+	daraSpinLock(addr)
+}
+
+// daraWait waits until the word at addr no longer holds val. It may
+// return early, callers must recheck.
+func daraWait(addr *uint32, val uint32) {
+	daraSpinWait(addr, val)
+}
+
+// daraWake is a no-op, waiters poll.
+func daraWake(addr *uint32) {
+}
+
+// daraUnlock releases the shared memory lock word at addr.
+func daraUnlock(addr *uint32) {
+	daraSpinUnlock(addr)
+}
@@ -0,0 +1,40 @@
+package runtime
+
+import (
+	"dara"
+	"runtime/internal/atomic"
+)
+
+// Without futex there is no way to sleep on a word in shared memory, so
+// a waiter spins briefly and then yields its thread between attempts.
+// This is how the lock words are handed over on systems other than
+// linux, the futex versions spin the same way before they sleep.
+const (
+	daraActiveSpin    = 64
+	daraActiveSpinCnt = 30
+)
+
+// daraSpinLock acquires the shared memory lock word at addr.
+func daraSpinLock(addr *uint32) {
+	for i := 0; !atomic.Cas(addr, dara.UNLOCKED, dara.LOCKED); i++ {
+		if i < daraActiveSpin {
+			procyield(daraActiveSpinCnt)
+		} else {
+			osyield()
+		}
+	}
+}
+
+// daraSpinWait waits until the word at addr no longer holds val. It may
+// return early, callers must recheck.
+func daraSpinWait(addr *uint32, val uint32) {
+	for i := 0; atomic.Load(addr) == val && i < daraActiveSpin; i++ {
+		procyield(daraActiveSpinCnt)
+	}
+	osyield()
+}
+
+// daraSpinUnlock releases the shared memory lock word at addr.
+func daraSpinUnlock(addr *uint32) {
+	atomic.Store(addr, dara.UNLOCKED)
+}
@@ -0,0 +1,280 @@
+package runtime
+
+import (
+	"dara"
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+//The global scheduler cuts the links between Dara processes through
+//DaraProc.Links. A connection leads to the process which published the
+//remote address of the connection in its DaraProc.Addrs, every process
+//publishes the local addresses of its listeners and connections as
+//they are made.
+//
+//With DARA_HOLD_MESSAGES a write to a connection to another Dara
+//process is held in the outbox of the process until the global
+//scheduler delivers it, see dara.MESSAGE_HELD.
+
+//daraAddrsFull is set once the Addrs of the process have run out
+var daraAddrsFull bool
+
+//daraMessage is a message held for the global scheduler to deliver
+type daraMessage struct {
+	id   int64
+	fd   uintptr
+	dst  int
+	conn string
+	//data is the part of the message which has not been delivered
+	data []byte
+}
+
+var (
+	//daraOutbox holds the messages of the process which have not been
+	//delivered, in the order they were written
+	daraOutbox []*daraMessage
+	//daraMessages counts the messages the process held
+	daraMessages int64
+)
+
+//daraAddrIs reports whether a is addr
+func daraAddrIs(a *dara.NetAddr, addr string) bool {
+	if a.Len != len(addr) {
+		return false
+	}
+	for i := 0; i < len(addr); i++ {
+		if a.Addr[i] != addr[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//DaraNetConn publishes local, the local address of a new listener or
+//connection of the process, in its Addrs
+func DaraNetConn(local string) {
+	if !DaraInitialised || Nanobenchmark {
+		return
+	}
+	if len(local) > dara.MAXADDRLEN {
+		dprint(dara.WARN, func() { println("[GoRuntime]DaraNetConn : Address", local, "is too long to publish, its links can not be cut") })
+		return
+	}
+	n := atomic.Load(&dproc.NumAddrs)
+	for i := uint32(0); i < n; i++ {
+		if daraAddrIs(&dproc.Addrs[i], local) {
+			return
+		}
+	}
+	if n == uint32(len(dproc.Addrs)) {
+		if !daraAddrsFull {
+			daraAddrsFull = true
+			dprint(dara.WARN, func() { println("[GoRuntime]DaraNetConn : More than", dara.MAXNETADDRS, "addresses, the links of new connections can not be cut") })
+		}
+		return
+	}
+	a := &dproc.Addrs[n]
+	a.Len = copy(a.Addr[:], local)
+	atomic.Store(&dproc.NumAddrs, n+1)
+}
+
+//daraAddrMatches reports whether a connection to addr leads to the
+//published address a. A listener on every address of a host is
+//published with an unspecified host, it matches any address with the
+//same port.
+func daraAddrMatches(a *dara.NetAddr, addr string) bool {
+	if daraAddrIs(a, addr) {
+		return true
+	}
+	port := a.Len - 1
+	for port >= 0 && a.Addr[port] != ':' {
+		port--
+	}
+	if port < 0 {
+		return false
+	}
+	host := string(a.Addr[:port])
+	if host != "" && host != "[::]" && host != "0.0.0.0" {
+		return false
+	}
+	n := a.Len - port
+	if len(addr) < n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if addr[len(addr)-n+i] != a.Addr[port+i] {
+			return false
+		}
+	}
+	return true
+}
+
+//daraNetPeer returns the DARAPID of the other process which published
+//remote, or 0 if none did
+func daraNetPeer(remote string) int {
+	for pid := 1; pid <= dheader.NumProcs; pid++ {
+		if pid == DPid {
+			continue
+		}
+		dp := daraProcAt(pid)
+		n := atomic.Load(&dp.NumAddrs)
+		for i := uint32(0); i < n; i++ {
+			if daraAddrMatches(&dp.Addrs[i], remote) {
+				return pid
+			}
+		}
+	}
+	return 0
+}
+
+//daraLinksCut reports whether the global scheduler has cut any of the
+//links of the process
+func daraLinksCut() bool {
+	for i := range dproc.Links {
+		if atomic.Load(&dproc.Links[i]) != dara.NET_DELIVER {
+			return true
+		}
+	}
+	return false
+}
+
+//DaraNetLink returns what happens to the data the process exchanges
+//over its connection to remote, one of the NET_ actions. A goroutine on
+//a delayed link stays runnable and yields to the global scheduler until
+//the link is no longer delayed.
+func DaraNetLink(remote string) int {
+	if !DaraInitialised || Nanobenchmark || !daraLinksCut() {
+		return dara.NET_DELIVER
+	}
+	peer := daraNetPeer(remote)
+	if peer < 1 || peer > len(dproc.Links) {
+		return dara.NET_DELIVER
+	}
+	for {
+		action := int(atomic.Load(&dproc.Links[peer-1]))
+		if action != dara.NET_DELAY {
+			if action != dara.NET_DELIVER {
+				dprint(dara.DEBUG, func() { println("[GoRuntime]DaraNetLink : Link to", peer, "is set to", dara.NetActionString(action)) })
+			}
+			return action
+		}
+		Gosched()
+	}
+}
+
+//DaraHoldMessage holds b, which is about to be written to the file
+//descriptor fd of the connection from local to remote, for the global
+//scheduler to deliver. It reports whether it did, which it only does
+//with DARA_HOLD_MESSAGES if remote belongs to another Dara process.
+func DaraHoldMessage(fd uintptr, local, remote string, b []byte) bool {
+	if !DaraInitialised || Nanobenchmark || FastReplay || !HoldMessages || len(b) == 0 {
+		return false
+	}
+	dst := daraNetPeer(remote)
+	if dst == 0 {
+		return false
+	}
+	daraMessages++
+	m := &daraMessage{
+		id:   daraMessages,
+		fd:   fd,
+		dst:  dst,
+		conn: local + ">" + remote,
+		data: append([]byte(nil), b...),
+	}
+	daraOutbox = append(daraOutbox, m)
+	LogHeldMessage(m, dara.MESSAGE_HELD, len(b))
+	return true
+}
+
+//DaraFlushMessages writes out the messages held on fd, which is about
+//to be closed, without waiting for the global scheduler
+func DaraFlushMessages(fd uintptr) {
+	if DaraInitialised && len(daraOutbox) > 0 {
+		daraFlush(fd, false)
+	}
+}
+
+//daraFlush writes out the messages held on fd, or every message held
+//if all is set, and logs them as flushed
+func daraFlush(fd uintptr, all bool) {
+	kept := daraOutbox[:0]
+	for _, m := range daraOutbox {
+		if !all && m.fd != fd {
+			kept = append(kept, m)
+			continue
+		}
+		n := daraWriteFd(m.fd, m.data)
+		if n < len(m.data) {
+			dprint(dara.WARN, func() { println("[GoRuntime]daraFlush : Lost", len(m.data)-n, "bytes of message", m.id, "which the connection did not take") })
+		}
+		LogHeldMessage(m, dara.MESSAGE_FLUSHED, n)
+	}
+	for i := len(kept); i < len(daraOutbox); i++ {
+		daraOutbox[i] = nil
+	}
+	daraOutbox = kept
+}
+
+//daraDeliver delivers the held message id, as much of it as its
+//connection takes. It returns false if id is not held, or an older
+//message is still held on the same connection.
+func daraDeliver(id int64) bool {
+	for i, m := range daraOutbox {
+		if m.id != id {
+			continue
+		}
+		for _, o := range daraOutbox[:i] {
+			if o.fd == m.fd {
+				dprint(dara.WARN, func() { println("[GoRuntime]daraDeliver : Message", id, "can not be delivered before message", o.id) })
+				return false
+			}
+		}
+		n := daraWriteFd(m.fd, m.data)
+		LogHeldMessage(m, dara.MESSAGE_DELIVERED, n)
+		m.data = m.data[n:]
+		if len(m.data) == 0 {
+			copy(daraOutbox[i:], daraOutbox[i+1:])
+			daraOutbox[len(daraOutbox)-1] = nil
+			daraOutbox = daraOutbox[:len(daraOutbox)-1]
+		}
+		return true
+	}
+	dprint(dara.WARN, func() { println("[GoRuntime]daraDeliver : No message", id, "is held") })
+	return false
+}
+
+//daraWriteFd writes b to fd, which does not block, and returns how
+//many bytes fd took
+func daraWriteFd(fd uintptr, b []byte) int {
+	n := 0
+	for n < len(b) {
+		w := write(fd, unsafe.Pointer(&b[n]), int32(len(b)-n))
+		if w <= 0 {
+			break
+		}
+		n += int(w)
+	}
+	return n
+}
+
+//daraNetWait blocks on the network while the runtime carries out a
+//command and none of its goroutines can run, like findrunnable does,
+//and queues the goroutines it wakes up. The lock is released while it
+//waits, see dara.REPLY_NET_BLOCK.
+func daraNetWait() {
+	if !netpollinited() || atomic.Load(&netpollWaiters) == 0 || atomic.Xchg64(&sched.lastpoll, 0) == 0 {
+		return
+	}
+	dproc.Reply = dara.REPLY_NET_BLOCK
+	daraReleaseLock()
+	list := netpoll(true)
+	daraLock(&dproc.Lock)
+	HasDaraLock = true
+	dproc.Reply = dara.REPLY_NET_WAKEUP
+	atomic.Store64(&sched.lastpoll, uint64(nanotime()))
+	if list != nil {
+		injectglist(list)
+	}
+	daraSyncRoutines()
+}
@@ -0,0 +1,106 @@
+package runtime
+
+import "dara"
+
+//Under Dara the time a program observes is the virtual clock in
+//DaraProc.Now rather than the clock of the machine. time.Now, the
+//deadlines of timers, time.Sleep and the deadlines of network
+//connections all read it through daraNanotime, so a program sees the
+//same times whenever it is run with the same schedule. The runtime
+//itself keeps using nanotime for the garbage collector and sysmon,
+//which a program can not observe.
+//
+//Virtual time stands still while a goroutine runs. The global
+//scheduler moves it forward between commands, and firing a timer moves
+//it to the time the timer was due at. Timer goroutines do not sleep
+//on the clock of the machine but park until virtual time moves, like
+//they do with faketime. Once the runtime detaches from the global
+//scheduler virtual time carries on with the clock of the machine.
+
+var (
+	daraTimeOn      bool  //Is time virtual, or shifted to carry on from virtual time?
+	daraTimeVirtual bool  //Does time only move when the global scheduler moves it?
+	daraTimeOffset  int64 //Added to nanotime once the runtime has detached
+	daraTimeSeen    int64 //DaraProc.Now when the timers were last woken up
+)
+
+//daraTimeStart switches the runtime over to virtual time, it is called
+//once shared memory is set up
+func daraTimeStart() {
+	if Nanobenchmark {
+		return
+	}
+	daraTimeOn = true
+	daraTimeVirtual = true
+	daraTimeSeen = dproc.Now
+}
+
+//daraNanotime is nanotime as far as the program is concerned
+func daraNanotime() int64 {
+	if !daraTimeOn {
+		return nanotime()
+	}
+	if daraTimeVirtual {
+		return dproc.Now
+	}
+	return nanotime() + daraTimeOffset
+}
+
+//daraWalltime returns the wall clock time at the virtual time now
+func daraWalltime(now int64) (sec int64, nsec int32) {
+	t := dheader.WallClock + now
+	return t / 1e9, int32(t % 1e9)
+}
+
+//daraTimeAdvance moves virtual time forward to now, it is used when
+//the runtime fires a timer itself on the command of the global
+//scheduler
+func daraTimeAdvance(now int64) {
+	if !daraTimeVirtual || now <= dproc.Now {
+		return
+	}
+	dproc.Now = now
+	daraTimeCheck()
+}
+
+//daraTimeCheck readies the timer goroutines which have a timer due if
+//virtual time has moved since they were last woken up. It is called
+//whenever the runtime picks up a command.
+func daraTimeCheck() {
+	if !daraTimeVirtual || dproc.Now == daraTimeSeen {
+		return
+	}
+	daraTimeSeen = dproc.Now
+	daraWakeTimers(daraTimeSeen)
+}
+
+//daraWakeTimers readies the timer goroutines that are parked with a
+//timer due at or before now
+func daraWakeTimers(now int64) {
+	for i := range timers {
+		tb := &timers[i].timersBucket
+		lock(&tb.lock)
+		var gp *g
+		if tb.created && tb.rescheduling && len(tb.t) > 0 && tb.t[0].when <= now {
+			tb.rescheduling = false
+			gp = tb.gp
+		}
+		unlock(&tb.lock)
+		if gp != nil {
+			dprint(dara.DEBUG, func() { println("[GoRuntime]daraWakeTimers : Timers of bucket", i, "are due at", now) })
+			ready(gp, 0, true)
+		}
+	}
+}
+
+//daraTimeDetach lets time carry on from the virtual time the runtime
+//detached at with the clock of the machine, and wakes up every timer
+//goroutine so that they go back to sleeping on that clock
+func daraTimeDetach() {
+	if !daraTimeVirtual {
+		return
+	}
+	daraTimeOffset = dproc.Now - nanotime()
+	daraTimeVirtual = false
+	daraWakeTimers(1<<63 - 1)
+}
@@ -0,0 +1,307 @@
+package runtime
+
+import "unsafe"
+
+//Values logged with DaraLog are copied to shared memory in the format
+//below so that the global scheduler gets back exactly what was logged.
+//Every value starts with a tag byte naming its type, followed by
+//	- bool: one byte, 0 or 1
+//	- signed integers: a zig-zag encoded varint
+//	- unsigned integers: a varint
+//	- float32, float64: their IEEE 754 bits, 4 or 8 bytes little endian
+//	- string: a varint length and the bytes
+//	- []byte: a varint length plus one, 0 for nil, and the bytes
+//	- []interface{}: a length as for []byte and the values
+//	- map[string]interface{}: a length as for []byte and the pairs of
+//	  string keys and values
+const (
+	daraTagNil byte = iota
+	daraTagBool
+	daraTagInt
+	daraTagInt8
+	daraTagInt16
+	daraTagInt32
+	daraTagInt64
+	daraTagUint
+	daraTagUint8
+	daraTagUint16
+	daraTagUint32
+	daraTagUint64
+	daraTagUintptr
+	daraTagFloat32
+	daraTagFloat64
+	daraTagString
+	daraTagBytes
+	daraTagSlice
+	daraTagMap
+)
+
+//How deep slices and maps may be nested, a slice which contains
+//itself can not be logged
+const daraMaxValueDepth = 64
+
+//daraAppendValue appends the encoding of v to buf. It returns false if
+//v, or a value nested in it, has a type that can not be logged.
+func daraAppendValue(buf []byte, v interface{}) ([]byte, bool) {
+	return daraAppendValueDepth(buf, v, 0)
+}
+
+func daraAppendValueDepth(buf []byte, v interface{}, depth int) ([]byte, bool) {
+	if depth > daraMaxValueDepth {
+		return buf, false
+	}
+	switch t := v.(type) {
+	case nil:
+		buf = append(buf, daraTagNil)
+	case bool:
+		b := byte(0)
+		if t {
+			b = 1
+		}
+		buf = append(buf, daraTagBool, b)
+	case int:
+		buf = daraAppendVarint(append(buf, daraTagInt), int64(t))
+	case int8:
+		buf = daraAppendVarint(append(buf, daraTagInt8), int64(t))
+	case int16:
+		buf = daraAppendVarint(append(buf, daraTagInt16), int64(t))
+	case int32:
+		buf = daraAppendVarint(append(buf, daraTagInt32), int64(t))
+	case int64:
+		buf = daraAppendVarint(append(buf, daraTagInt64), t)
+	case uint:
+		buf = daraAppendUvarint(append(buf, daraTagUint), uint64(t))
+	case uint8:
+		buf = daraAppendUvarint(append(buf, daraTagUint8), uint64(t))
+	case uint16:
+		buf = daraAppendUvarint(append(buf, daraTagUint16), uint64(t))
+	case uint32:
+		buf = daraAppendUvarint(append(buf, daraTagUint32), uint64(t))
+	case uint64:
+		buf = daraAppendUvarint(append(buf, daraTagUint64), t)
+	case uintptr:
+		buf = daraAppendUvarint(append(buf, daraTagUintptr), uint64(t))
+	case float32:
+		bits := *(*uint32)(unsafe.Pointer(&t))
+		buf = append(buf, daraTagFloat32, byte(bits), byte(bits>>8), byte(bits>>16), byte(bits>>24))
+	case float64:
+		buf = append(buf, daraTagFloat64)
+		bits := float64bits(t)
+		for i := uint(0); i < 64; i += 8 {
+			buf = append(buf, byte(bits>>i))
+		}
+	case string:
+		buf = daraAppendUvarint(append(buf, daraTagString), uint64(len(t)))
+		buf = append(buf, t...)
+	case []byte:
+		buf = daraAppendLength(append(buf, daraTagBytes), len(t), t == nil)
+		buf = append(buf, t...)
+	case []interface{}:
+		buf = daraAppendLength(append(buf, daraTagSlice), len(t), t == nil)
+		for _, x := range t {
+			var ok bool
+			if buf, ok = daraAppendValueDepth(buf, x, depth+1); !ok {
+				return buf, false
+			}
+		}
+	case map[string]interface{}:
+		buf = daraAppendLength(append(buf, daraTagMap), len(t), t == nil)
+		for k, x := range t {
+			buf = daraAppendUvarint(buf, uint64(len(k)))
+			buf = append(buf, k...)
+			var ok bool
+			if buf, ok = daraAppendValueDepth(buf, x, depth+1); !ok {
+				return buf, false
+			}
+		}
+	default:
+		return buf, false
+	}
+	return buf, true
+}
+
+func daraAppendUvarint(buf []byte, x uint64) []byte {
+	for x >= 0x80 {
+		buf = append(buf, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(buf, byte(x))
+}
+
+func daraAppendVarint(buf []byte, x int64) []byte {
+	return daraAppendUvarint(buf, uint64(x<<1)^uint64(x>>63))
+}
+
+func daraAppendLength(buf []byte, n int, isNil bool) []byte {
+	if isNil {
+		return daraAppendUvarint(buf, 0)
+	}
+	return daraAppendUvarint(buf, uint64(n)+1)
+}
+
+//daraValueDecoder reads values written by daraAppendValue. The first
+//error sticks, every later read returns a zero value.
+type daraValueDecoder struct {
+	buf []byte
+	bad bool
+}
+
+func (d *daraValueDecoder) fail() {
+	d.bad = true
+	d.buf = nil
+}
+
+func (d *daraValueDecoder) readByte() byte {
+	if len(d.buf) < 1 {
+		d.fail()
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *daraValueDecoder) uvarint() uint64 {
+	var x uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b := d.readByte()
+		if d.bad {
+			return 0
+		}
+		x |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return x
+		}
+	}
+	d.fail()
+	return 0
+}
+
+func (d *daraValueDecoder) varint() int64 {
+	x := d.uvarint()
+	return int64(x>>1) ^ -int64(x&1)
+}
+
+func (d *daraValueDecoder) raw(n uint64) []byte {
+	if n > uint64(len(d.buf)) {
+		d.fail()
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+//length reads a length written by daraAppendLength, -1 stands for
+//nil. Every element takes at least a byte, so a length longer than
+//what is left is corrupt.
+func (d *daraValueDecoder) length() int {
+	n := d.uvarint()
+	if n == 0 {
+		return -1
+	}
+	if n-1 > uint64(len(d.buf)) {
+		d.fail()
+		return -1
+	}
+	return int(n - 1)
+}
+
+func (d *daraValueDecoder) value(depth int) interface{} {
+	if depth > daraMaxValueDepth {
+		d.fail()
+		return nil
+	}
+	switch d.readByte() {
+	case daraTagNil:
+		return nil
+	case daraTagBool:
+		switch d.readByte() {
+		case 0:
+			return false
+		case 1:
+			return true
+		}
+	case daraTagInt:
+		return int(d.varint())
+	case daraTagInt8:
+		return int8(d.varint())
+	case daraTagInt16:
+		return int16(d.varint())
+	case daraTagInt32:
+		return int32(d.varint())
+	case daraTagInt64:
+		return d.varint()
+	case daraTagUint:
+		return uint(d.uvarint())
+	case daraTagUint8:
+		return uint8(d.uvarint())
+	case daraTagUint16:
+		return uint16(d.uvarint())
+	case daraTagUint32:
+		return uint32(d.uvarint())
+	case daraTagUint64:
+		return d.uvarint()
+	case daraTagUintptr:
+		return uintptr(d.uvarint())
+	case daraTagFloat32:
+		b := d.raw(4)
+		if d.bad {
+			return nil
+		}
+		bits := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+		return *(*float32)(unsafe.Pointer(&bits))
+	case daraTagFloat64:
+		b := d.raw(8)
+		if d.bad {
+			return nil
+		}
+		var bits uint64
+		for i := uint(0); i < 8; i++ {
+			bits |= uint64(b[i]) << (8 * i)
+		}
+		return float64frombits(bits)
+	case daraTagString:
+		return string(d.raw(d.uvarint()))
+	case daraTagBytes:
+		n := d.length()
+		if n < 0 {
+			return []byte(nil)
+		}
+		return append([]byte{}, d.raw(uint64(n))...)
+	case daraTagSlice:
+		n := d.length()
+		if n < 0 {
+			return []interface{}(nil)
+		}
+		s := make([]interface{}, n)
+		for i := range s {
+			s[i] = d.value(depth + 1)
+		}
+		return s
+	case daraTagMap:
+		n := d.length()
+		if n < 0 {
+			return map[string]interface{}(nil)
+		}
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n && !d.bad; i++ {
+			k := string(d.raw(d.uvarint()))
+			m[k] = d.value(depth + 1)
+		}
+		return m
+	}
+	d.fail()
+	return nil
+}
+
+//DecodeValue decodes a value logged with DaraLog, as found in shared
+//memory. It returns false if buffer does not hold exactly one value.
+func DecodeValue(buffer []byte) (interface{}, bool) {
+	d := daraValueDecoder{buf: buffer}
+	v := d.value(0)
+	if d.bad || len(d.buf) != 0 {
+		return nil, false
+	}
+	return v, true
+}
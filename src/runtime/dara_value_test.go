@@ -0,0 +1,140 @@
+package runtime_test
+
+import (
+	"math"
+	"reflect"
+	. "runtime"
+	"strings"
+	"testing"
+)
+
+var daraValues = []interface{}{
+	nil,
+	true,
+	false,
+	0,
+	-1,
+	math.MaxInt64,
+	int(math.MinInt64),
+	int8(-128),
+	int16(math.MaxInt16),
+	int32(math.MinInt32),
+	int64(42),
+	uint(7),
+	uint8(255),
+	uint16(math.MaxUint16),
+	uint32(math.MaxUint32),
+	uint64(math.MaxUint64),
+	uintptr(0xdeadbeef),
+	float32(1.5),
+	float32(math.SmallestNonzeroFloat32),
+	float32(math.Inf(1)),
+	0.1,
+	math.Pi,
+	math.Copysign(0, -1),
+	math.Inf(-1),
+	"",
+	"héllo",
+	strings.Repeat("long/path/", 1000),
+	"nul\x00inside",
+	[]byte(nil),
+	[]byte{},
+	[]byte{0, 1, 2, 255},
+	[]interface{}(nil),
+	[]interface{}{},
+	[]interface{}{1, "two", int64(3), []interface{}{float32(4), nil}},
+	map[string]interface{}(nil),
+	map[string]interface{}{},
+	map[string]interface{}{
+		"leader": 2,
+		"term":   int64(4),
+		"log":    []interface{}{"a", []byte("b")},
+		"peers":  map[string]interface{}{"1": true, "3": uint16(3)},
+	},
+}
+
+func TestDaraValueRoundTrip(t *testing.T) {
+	for _, v := range daraValues {
+		buf, ok := DaraAppendValue(nil, v)
+		if !ok {
+			t.Errorf("encoding %#v failed", v)
+			continue
+		}
+		got, ok := DecodeValue(buf)
+		if !ok {
+			t.Errorf("decoding %#v failed", v)
+			continue
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Errorf("%#v (%T) decoded as %#v (%T)", v, v, got, got)
+		}
+		// Floats must come back bit for bit, including the sign of
+		// zero.
+		if f, ok := v.(float64); ok && math.Float64bits(got.(float64)) != math.Float64bits(f) {
+			t.Errorf("%v decoded as %v", f, got)
+		}
+	}
+}
+
+func TestDaraValueNaN(t *testing.T) {
+	for _, v := range []interface{}{math.NaN(), float32(math.NaN())} {
+		buf, _ := DaraAppendValue(nil, v)
+		got, ok := DecodeValue(buf)
+		if !ok {
+			t.Fatalf("decoding %v failed", v)
+		}
+		switch f := got.(type) {
+		case float64:
+			if math.Float64bits(f) != math.Float64bits(v.(float64)) {
+				t.Errorf("NaN decoded as %x", math.Float64bits(f))
+			}
+		case float32:
+			if math.Float32bits(f) != math.Float32bits(v.(float32)) {
+				t.Errorf("NaN decoded as %x", math.Float32bits(f))
+			}
+		default:
+			t.Errorf("%T NaN decoded as %T", v, got)
+		}
+	}
+}
+
+func TestDaraValueUnsupported(t *testing.T) {
+	loop := []interface{}{nil}
+	loop[0] = loop
+	for _, v := range []interface{}{
+		struct{}{},
+		[]int{1},
+		map[string]int{"a": 1},
+		complex(1, 2),
+		[]interface{}{1, struct{}{}},
+		map[string]interface{}{"a": &loop},
+		loop,
+	} {
+		if _, ok := DaraAppendValue(nil, v); ok {
+			t.Errorf("encoded unsupported value %#v", v)
+		}
+	}
+}
+
+func TestDaraValueCorrupt(t *testing.T) {
+	for _, v := range daraValues {
+		buf, _ := DaraAppendValue(nil, v)
+		for n := 0; n < len(buf); n++ {
+			if got, ok := DecodeValue(buf[:n]); ok {
+				t.Errorf("decoded %d of %d bytes of %#v as %#v", n, len(buf), v, got)
+			}
+		}
+		if _, ok := DecodeValue(append(buf, 0)); ok {
+			t.Errorf("decoded %#v followed by junk", v)
+		}
+	}
+	for _, buf := range [][]byte{
+		{255},
+		{1, 2},
+		{15, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+	} {
+		if got, ok := DecodeValue(buf); ok {
+			t.Errorf("decoded %v as %#v", buf, got)
+		}
+	}
+}
@@ -0,0 +1,565 @@
+package runtime
+
+import (
+	"dara"
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+//The runtime side of the virtual network, see dara/vnet.go. Package net
+//refers to its listeners and connections on the virtual network by
+//handles, the runtime keeps what only this process needs to know about
+//each of them in a daraVNetFD.
+//
+//A goroutine which has to wait parks until the runtime polls the
+//virtual network and finds that it can carry on, or until its deadline
+//passes, which is a timer like any other. It may also be woken up by
+//the global scheduler running it, so it checks again every time it
+//wakes up. Once the process has detached from the global scheduler
+//nobody polls, a waiting goroutine yields and checks again instead.
+
+//daraVNetFD is a listener or one end of a connection on the virtual
+//network
+type daraVNetFD struct {
+	listener bool
+	//ref is the listener, Listeners[ref.Conn] of this process, or the
+	//connection, Conns[ref.Conn] of the process ref.Pid
+	ref dara.VConnRef
+	//side is the end of the connection, VNET_CLIENT or VNET_SERVER
+	side   int
+	closed bool
+	//rdone and wdone are set once the end has been shut down for
+	//reading and writing
+	rdone bool
+	wdone bool
+	//rdeadline and wdeadline are the virtual times reads and writes
+	//time out at, -1 if they never do
+	rdeadline int64
+	wdeadline int64
+}
+
+//The states of a daraVNetWaiter
+const (
+	daraVNetWaiting uint32 = iota //about to park
+	daraVNetParked
+	daraVNetWoken
+)
+
+//daraVNetWaiter is a goroutine waiting on the virtual network
+type daraVNetWaiter struct {
+	gp    *g
+	fd    *daraVNetFD
+	write bool
+	state uint32
+	//t wakes the goroutine up at its deadline
+	t *timer
+}
+
+var (
+	//daraVNetFDs holds the listeners and connections of the process,
+	//handle h is daraVNetFDs[h-1]
+	daraVNetFDs     []*daraVNetFD
+	daraVNetWaiters []*daraVNetWaiter
+	//daraVNetPorts counts the ephemeral ports the process handed out
+	daraVNetPorts int
+)
+
+//DaraVirtualNet reports whether the process reaches other Dara
+//processes over the virtual network
+func DaraVirtualNet() bool {
+	return VirtualNet && !Nanobenchmark && dproc != nil
+}
+
+func daraVNetOpen(fd *daraVNetFD) int {
+	fd.rdeadline, fd.wdeadline = -1, -1
+	for i, f := range daraVNetFDs {
+		if f == nil {
+			daraVNetFDs[i] = fd
+			return i + 1
+		}
+	}
+	daraVNetFDs = append(daraVNetFDs, fd)
+	return len(daraVNetFDs)
+}
+
+func daraVNetGet(h int) *daraVNetFD {
+	if h < 1 || h > len(daraVNetFDs) {
+		return nil
+	}
+	return daraVNetFDs[h-1]
+}
+
+func (fd *daraVNetFD) conn() *dara.VConn {
+	return &daraProcAt(fd.ref.Pid).Conns[fd.ref.Conn]
+}
+
+func (fd *daraVNetFD) recv() *dara.VPipe {
+	return &fd.conn().Pipes[1-fd.side]
+}
+
+func (fd *daraVNetFD) send() *dara.VPipe {
+	return &fd.conn().Pipes[fd.side]
+}
+
+//peer returns the DARAPID of the process at the other end of the
+//connection fd
+func (fd *daraVNetFD) peer() int {
+	if fd.side == dara.VNET_CLIENT {
+		return fd.conn().Server
+	}
+	return fd.ref.Pid
+}
+
+//daraVNetKick tells the global scheduler that the process pid has
+//something new on the virtual network
+func daraVNetKick(pid int) {
+	atomic.Xadd(&daraProcAt(pid).NetSeq, 1)
+}
+
+//daraVNetPortUsed reports whether a listener or a connection of the
+//cluster is bound to port
+func daraVNetPortUsed(port int) bool {
+	for pid := 1; pid <= dheader.NumProcs; pid++ {
+		dp := daraProcAt(pid)
+		for i := range dp.Listeners {
+			if atomic.Load(&dp.Listeners[i].State) == dara.VNET_OPEN && dp.Listeners[i].Port == port {
+				return true
+			}
+		}
+		for i := range dp.Conns {
+			if atomic.Load(&dp.Conns[i].State) == dara.VNET_OPEN && dp.Conns[i].ClientPort == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//daraVNetEphemeral returns an ephemeral port nobody is bound to, or 0
+//if the process has run out of them
+func daraVNetEphemeral() int {
+	for i := 0; i < dara.VNETPORTSPAN; i++ {
+		port := dara.EphemeralPort(DPid, daraVNetPorts)
+		daraVNetPorts++
+		if !daraVNetPortUsed(port) {
+			return port
+		}
+	}
+	return 0
+}
+
+//DaraVNetListen binds a listener to port, or to an ephemeral port if
+//port is 0. It returns the handle of the listener and its port, or one
+//of the VNET_ errors.
+func DaraVNetListen(port int) (h int, bound int, err int) {
+	if port == 0 {
+		if port = daraVNetEphemeral(); port == 0 {
+			return 0, 0, dara.VNET_ADDRINUSE
+		}
+	} else if daraVNetPortUsed(port) {
+		return 0, 0, dara.VNET_ADDRINUSE
+	}
+	for i := range dproc.Listeners {
+		l := &dproc.Listeners[i]
+		if atomic.Load(&l.State) != dara.VNET_FREE {
+			continue
+		}
+		l.Port = port
+		l.BacklogHead, l.BacklogTail = 0, 0
+		atomic.Store(&l.State, dara.VNET_OPEN)
+		dprint(dara.DEBUG, func() { println("[GoRuntime]DaraVNetListen : Listening on port", port) })
+		return daraVNetOpen(&daraVNetFD{listener: true, ref: dara.VConnRef{Pid: DPid, Conn: i}}), port, dara.VNET_OK
+	}
+	return 0, 0, dara.VNET_NOBUFS
+}
+
+//DaraVNetDial connects to the listener bound to port. It returns the
+//handle of the connection and the port of its local end, or one of the
+//VNET_ errors.
+func DaraVNetDial(port int) (h int, local int, err int) {
+	var l *dara.VListener
+	server := 0
+	for pid := 1; pid <= dheader.NumProcs && l == nil; pid++ {
+		dp := daraProcAt(pid)
+		for i := range dp.Listeners {
+			if atomic.Load(&dp.Listeners[i].State) == dara.VNET_OPEN && dp.Listeners[i].Port == port {
+				l, server = &dp.Listeners[i], pid
+				break
+			}
+		}
+	}
+	if l == nil || l.BacklogHead-atomic.Load(&l.BacklogTail) >= dara.VNETBACKLOG {
+		return 0, 0, dara.VNET_REFUSED
+	}
+	slot := -1
+	for i := range dproc.Conns {
+		if atomic.Load(&dproc.Conns[i].State) == dara.VNET_FREE {
+			slot = i
+			break
+		}
+	}
+	if slot < 0 {
+		return 0, 0, dara.VNET_NOBUFS
+	}
+	if local = daraVNetEphemeral(); local == 0 {
+		return 0, 0, dara.VNET_ADDRINUSE
+	}
+	c := &dproc.Conns[slot]
+	for i := range c.Pipes {
+		p := &c.Pipes[i]
+		p.Head, p.Tail, p.Closed, p.Gone = 0, 0, 0, 0
+	}
+	c.Server, c.ClientPort, c.ServerPort = server, local, port
+	c.Ends = 2
+	atomic.Store(&c.State, dara.VNET_OPEN)
+	ref := dara.VConnRef{Pid: DPid, Conn: slot}
+	head := l.BacklogHead
+	l.Backlog[head%dara.VNETBACKLOG] = ref
+	atomic.Store(&l.BacklogHead, head+1)
+	daraVNetKick(server)
+	dprint(dara.DEBUG, func() { println("[GoRuntime]DaraVNetDial : Connected from port", local, "to port", port, "of process", server) })
+	return daraVNetOpen(&daraVNetFD{ref: ref, side: dara.VNET_CLIENT}), local, dara.VNET_OK
+}
+
+//DaraVNetAccept accepts a connection on the listener h, waiting for one
+//to be dialed. It returns the handle of the connection and the ports of
+//its local and remote end, or one of the VNET_ errors.
+func DaraVNetAccept(h int) (c int, local int, remote int, err int) {
+	fd := daraVNetGet(h)
+	if fd == nil || !fd.listener {
+		return 0, 0, 0, dara.VNET_CLOSED
+	}
+	l := &dproc.Listeners[fd.ref.Conn]
+	for {
+		if fd.closed {
+			return 0, 0, 0, dara.VNET_CLOSED
+		}
+		if tail := l.BacklogTail; atomic.Load(&l.BacklogHead) != tail {
+			nfd := &daraVNetFD{ref: l.Backlog[tail%dara.VNETBACKLOG], side: dara.VNET_SERVER}
+			atomic.Store(&l.BacklogTail, tail+1)
+			vc := nfd.conn()
+			return daraVNetOpen(nfd), vc.ServerPort, vc.ClientPort, dara.VNET_OK
+		}
+		if daraVNetExpired(fd.rdeadline) {
+			return 0, 0, 0, dara.VNET_TIMEOUT
+		}
+		daraVNetWait(fd, false)
+	}
+}
+
+//DaraVNetRead reads from the connection h into b, waiting for the
+//other end to write if there is nothing to read. It returns how many
+//bytes it read, and VNET_EOF once the other end has closed and
+//everything it wrote has been read, or one of the VNET_ errors.
+func DaraVNetRead(h int, b []byte) (int, int) {
+	fd := daraVNetGet(h)
+	if fd == nil || fd.listener {
+		return 0, dara.VNET_CLOSED
+	}
+	for {
+		if fd.closed {
+			return 0, dara.VNET_CLOSED
+		}
+		if len(b) == 0 {
+			return 0, dara.VNET_OK
+		}
+		p := fd.recv()
+		if fd.rdone || atomic.Load(&p.Closed) != 0 && atomic.Load(&p.Head) == p.Tail {
+			return 0, dara.VNET_EOF
+		}
+		if n := daraVNetTake(p, b); n > 0 {
+			daraVNetKick(fd.peer())
+			return n, dara.VNET_OK
+		}
+		if daraVNetExpired(fd.rdeadline) {
+			return 0, dara.VNET_TIMEOUT
+		}
+		daraVNetWait(fd, false)
+	}
+}
+
+//DaraVNetWrite writes b to the connection h, waiting for the other end
+//to read whenever the connection buffers as much as it can. It returns
+//how many bytes it wrote, and one of the VNET_ errors if that is not
+//all of b.
+func DaraVNetWrite(h int, b []byte) (int, int) {
+	fd := daraVNetGet(h)
+	if fd == nil || fd.listener {
+		return 0, dara.VNET_CLOSED
+	}
+	n := 0
+	for {
+		if fd.closed {
+			return n, dara.VNET_CLOSED
+		}
+		p := fd.send()
+		if fd.wdone || atomic.Load(&p.Gone) != 0 {
+			return n, dara.VNET_PIPE
+		}
+		if m := daraVNetPut(p, b[n:]); m > 0 {
+			n += m
+			daraVNetKick(fd.peer())
+		}
+		if n == len(b) {
+			return n, dara.VNET_OK
+		}
+		if daraVNetExpired(fd.wdeadline) {
+			return n, dara.VNET_TIMEOUT
+		}
+		daraVNetWait(fd, true)
+	}
+}
+
+//DaraVNetShutdown shuts the connection h down for reading, writing or
+//both. The other end reads VNET_EOF once the connection is shut down
+//for writing.
+func DaraVNetShutdown(h int, read, write bool) int {
+	fd := daraVNetGet(h)
+	if fd == nil || fd.listener {
+		return dara.VNET_CLOSED
+	}
+	if read {
+		fd.rdone = true
+	}
+	if write && !fd.wdone {
+		fd.wdone = true
+		atomic.Store(&fd.send().Closed, 1)
+		daraVNetKick(fd.peer())
+	}
+	daraVNetWakeFD(fd)
+	return dara.VNET_OK
+}
+
+//DaraVNetClose closes the listener or connection h. The connections
+//waiting to be accepted by a listener are closed along with it.
+func DaraVNetClose(h int) int {
+	fd := daraVNetGet(h)
+	if fd == nil {
+		return dara.VNET_CLOSED
+	}
+	fd.closed = true
+	daraVNetFDs[h-1] = nil
+	daraVNetWakeFD(fd)
+	if !fd.listener {
+		daraVNetRelease(fd)
+		return dara.VNET_OK
+	}
+	l := &dproc.Listeners[fd.ref.Conn]
+	atomic.Store(&l.State, dara.VNET_FREE)
+	for tail := l.BacklogTail; tail != l.BacklogHead; tail++ {
+		daraVNetRelease(&daraVNetFD{ref: l.Backlog[tail%dara.VNETBACKLOG], side: dara.VNET_SERVER})
+	}
+	l.BacklogTail = l.BacklogHead
+	return dara.VNET_OK
+}
+
+//daraVNetRelease closes the end fd of its connection, the connection is
+//free again once both ends are closed
+func daraVNetRelease(fd *daraVNetFD) {
+	c := fd.conn()
+	peer := fd.peer()
+	atomic.Store(&c.Pipes[fd.side].Closed, 1)
+	atomic.Store(&c.Pipes[1-fd.side].Gone, 1)
+	if atomic.Xadd(&c.Ends, -1) == 0 {
+		atomic.Store(&c.State, dara.VNET_FREE)
+	}
+	daraVNetKick(peer)
+}
+
+//DaraVNetSetDeadline sets the deadline of the reads ('r'), writes ('w')
+//or both ('r'+'w') of h to timeout nanoseconds from now, or clears it
+//if timeout is negative. The reads and writes already waiting are held
+//to the new deadline too.
+func DaraVNetSetDeadline(h int, mode int, timeout int64) int {
+	fd := daraVNetGet(h)
+	if fd == nil {
+		return dara.VNET_CLOSED
+	}
+	d := int64(-1)
+	if timeout >= 0 {
+		d = daraNanotime() + timeout
+	}
+	if mode == 'r' || mode == 'r'+'w' {
+		fd.rdeadline = d
+	}
+	if mode == 'w' || mode == 'r'+'w' {
+		fd.wdeadline = d
+	}
+	for _, w := range daraVNetWaiters {
+		if w.fd == fd && atomic.Load(&w.state) != daraVNetWoken {
+			daraVNetArm(w)
+		}
+	}
+	return dara.VNET_OK
+}
+
+//daraVNetExpired reports whether the deadline d has passed
+func daraVNetExpired(d int64) bool {
+	return d >= 0 && daraNanotime() >= d
+}
+
+//daraVNetPut writes as much of b to p as p takes and returns how much
+//that was
+func daraVNetPut(p *dara.VPipe, b []byte) int {
+	head := p.Head
+	n := dara.VNETPIPESIZE - int(head-atomic.Load(&p.Tail))
+	if n > len(b) {
+		n = len(b)
+	}
+	for i := 0; i < n; {
+		i += copy(p.Buf[(head+uint32(i))%dara.VNETPIPESIZE:], b[i:n])
+	}
+	atomic.Store(&p.Head, head+uint32(n))
+	return n
+}
+
+//daraVNetTake reads as much of p into b as there is and b takes, and
+//returns how much that was
+func daraVNetTake(p *dara.VPipe, b []byte) int {
+	tail := p.Tail
+	n := int(atomic.Load(&p.Head) - tail)
+	if n > len(b) {
+		n = len(b)
+	}
+	for i := 0; i < n; {
+		i += copy(b[i:n], p.Buf[(tail+uint32(i))%dara.VNETPIPESIZE:])
+	}
+	atomic.Store(&p.Tail, tail+uint32(n))
+	return n
+}
+
+//daraVNetWait waits until the runtime finds that fd can carry on with a
+//read, or a write if write is set, the deadline of that passes or fd is
+//closed. The running goroutine may be woken up early, the caller checks
+//again.
+func daraVNetWait(fd *daraVNetFD, write bool) {
+	if !DaraInitialised {
+		usleep(100)
+		Gosched()
+		return
+	}
+	w := &daraVNetWaiter{gp: getg(), fd: fd, write: write}
+	daraVNetWaiters = append(daraVNetWaiters, w)
+	dproc.NetWaiters = uint32(len(daraVNetWaiters))
+	daraVNetArm(w)
+	gopark(daraVNetParkCommit, unsafe.Pointer(w), "dara net", traceEvGoBlockNet, 1)
+	atomic.Store(&w.state, daraVNetWoken)
+	if w.t != nil {
+		deltimer(w.t)
+	}
+	for i, o := range daraVNetWaiters {
+		if o == w {
+			copy(daraVNetWaiters[i:], daraVNetWaiters[i+1:])
+			daraVNetWaiters[len(daraVNetWaiters)-1] = nil
+			daraVNetWaiters = daraVNetWaiters[:len(daraVNetWaiters)-1]
+			break
+		}
+	}
+	if DaraInitialised {
+		dproc.NetWaiters = uint32(len(daraVNetWaiters))
+	}
+}
+
+//daraVNetParkCommit lets the goroutine of the daraVNetWaiter wp park,
+//unless it has been woken up already
+func daraVNetParkCommit(gp *g, wp unsafe.Pointer) bool {
+	return atomic.Cas(&(*daraVNetWaiter)(wp).state, daraVNetWaiting, daraVNetParked)
+}
+
+//daraVNetArm sets up the timer which wakes w up at the deadline of its
+//read or write, after stopping the one it had
+func daraVNetArm(w *daraVNetWaiter) {
+	if w.t != nil {
+		deltimer(w.t)
+		w.t = nil
+	}
+	d := w.fd.rdeadline
+	if w.write {
+		d = w.fd.wdeadline
+	}
+	switch {
+	case d < 0:
+	case daraVNetExpired(d):
+		daraVNetWake(w)
+	default:
+		w.t = &timer{when: d, f: daraVNetTimeout, arg: w}
+		addtimer(w.t)
+	}
+}
+
+//daraVNetTimeout wakes up the daraVNetWaiter arg, whose deadline has
+//passed
+func daraVNetTimeout(arg interface{}, seq uintptr) {
+	daraVNetWake(arg.(*daraVNetWaiter))
+}
+
+//daraVNetWake readies the goroutine of w, unless it has been woken up
+//already, or keeps it from parking if it has not parked yet. It
+//reports whether it readied it.
+func daraVNetWake(w *daraVNetWaiter) bool {
+	for {
+		switch atomic.Load(&w.state) {
+		case daraVNetWaiting:
+			if atomic.Cas(&w.state, daraVNetWaiting, daraVNetWoken) {
+				return false
+			}
+		case daraVNetParked:
+			if !atomic.Cas(&w.state, daraVNetParked, daraVNetWoken) {
+				continue
+			}
+			//The global scheduler may have readied it by running it
+			if readgstatus(w.gp)&^_Gscan != _Gwaiting {
+				return false
+			}
+			goready(w.gp, 0)
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+//daraVNetWakeFD wakes up the goroutines waiting on fd
+func daraVNetWakeFD(fd *daraVNetFD) {
+	for _, w := range daraVNetWaiters {
+		if w.fd == fd {
+			daraVNetWake(w)
+		}
+	}
+}
+
+//daraVNetReady reports whether the goroutine of w can carry on
+func daraVNetReady(w *daraVNetWaiter) bool {
+	fd := w.fd
+	switch {
+	case fd.closed:
+		return true
+	case fd.listener:
+		l := &dproc.Listeners[fd.ref.Conn]
+		return atomic.Load(&l.BacklogHead) != l.BacklogTail
+	case w.write:
+		p := fd.send()
+		return fd.wdone || atomic.Load(&p.Gone) != 0 || p.Head-atomic.Load(&p.Tail) < dara.VNETPIPESIZE
+	}
+	p := fd.recv()
+	return fd.rdone || atomic.Load(&p.Closed) != 0 || atomic.Load(&p.Head) != p.Tail
+}
+
+//daraVNetPoll readies the goroutines waiting on the virtual network
+//which can carry on, and reports whether there were any
+func daraVNetPoll() bool {
+	woke := false
+	for _, w := range daraVNetWaiters {
+		if atomic.Load(&w.state) == daraVNetWoken || !daraVNetReady(w) || !daraVNetWake(w) {
+			continue
+		}
+		woke = true
+		if gid := w.gp.goid; gid < int64(len(dproc.Routines)) {
+			dproc.Routines[gid].Status = readgstatus(w.gp)
+		}
+	}
+	if woke {
+		dprint(dara.DEBUG, func() { println("[GoRuntime]daraVNetPoll : Readied goroutines waiting on the virtual network") })
+	}
+	return woke
+}
@@ -6,5 +6,28 @@
 
 package runtime
 
+import "dara"
+
 var NewOSProc0 = newosproc0
 var Mincore = mincore
+
+// The lock word handoff of Dara, with futex and spinning.
+var (
+	DaraLock       = daraLock
+	DaraUnlock     = daraUnlock
+	DaraWait       = daraWait
+	DaraWake       = daraWake
+	DaraSpinLock   = daraSpinLock
+	DaraSpinUnlock = daraSpinUnlock
+	DaraSpinWait   = daraSpinWait
+)
+
+// SetDaraFutex sets whether the global scheduler wakes up the waiters on
+// lock words, as it does with dara.FEATURE_FUTEX.
+func SetDaraFutex(on bool) {
+	if on {
+		dheader.Features |= dara.FEATURE_FUTEX
+	} else {
+		dheader.Features &^= dara.FEATURE_FUTEX
+	}
+}
@@ -8,8 +8,7 @@
 
 package runtime
 
-var Mmap = mmap
-var Munmap = munmap
+// Mmap and Munmap are exported by debug.go for Dara.
 
 const ENOMEM = _ENOMEM
 const MAP_ANON = _MAP_ANON
@@ -413,3 +413,5 @@ func TracebackSystemstack(stk []uintptr, i int) int {
 	})
 	return n
 }
+
+var DaraAppendValue = daraAppendValue
@@ -193,14 +193,21 @@ func gcinit() {
 	// and set the GC trigger and goal.
 	_ = setGCPercent(readgogc())
 
+	//DARA Inject - Under Dara every collection stops the world and
+	//sweeps before it returns. It runs on the goroutine whose
+	//allocation triggered it, never on background workers that would
+	//have to be scheduled, so it happens at the same point of every
+	//replay.
+	if Is_Dara_On() {
+		debug.gcstoptheworld = 2
+	}
+	//\DARA Inject
+
 	work.startSema = 1
 	work.markDoneSema = 1
 }
 
 func readgogc() int32 {
-	//DARA MOD TURN OFF GARBAGE COLLECTION
-	return -1
-	//END DARA MOD
 	p := gogetenv("GOGC")
 	if p == "off" {
 		return -1
@@ -1224,6 +1231,13 @@ func (t gcTrigger) test() bool {
 		if gcpercent < 0 {
 			return false
 		}
+		//DARA Inject - A collection forced by sysmon after a while
+		//happens at a point that depends on timing, under Dara garbage
+		//is only collected when allocations trigger it
+		if DaraInitialised && !Nanobenchmark {
+			return false
+		}
+		//\DARA Inject
 		lastgc := int64(atomic.Load64(&memstats.last_gc_nanotime))
 		return lastgc != 0 && t.now-lastgc > forcegcperiod
 	case gcTriggerCycle:
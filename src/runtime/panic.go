@@ -555,7 +555,7 @@ func gopanic(e interface{}) {
     //DARA Inject. Log call to panic
     if DaraInitialised {
         dprint(dara.INFO, func() {println("[GoRuntime]dopanic : Inside panic now")})
-        LogCrash(procchan[DPid].Routines[int(gp.goid)])
+        LogCrash(dproc.Routines[int(gp.goid)])
         endDara()
     }
 	dopanic(0)       // should not return
@@ -625,7 +625,7 @@ func throw(s string) {
 	}
     if DaraInitialised {
         dprint(dara.INFO, func() {println("[GoRuntime]throw : Inside throw now")})
-        LogCrash(procchan[DPid].Routines[int(gp.goid)])
+        LogCrash(dproc.Routines[int(gp.goid)])
         endDara()
     }
 	startpanic()
@@ -202,7 +202,7 @@ func main() {
 		initDara(g.m.procid)
 		RunningGoid = g.goid
 		dprint(dara.INFO, func(){ println("Adding scheduling event with RunningGoid", RunningGoid) })
-		LogSchedulingEvent(procchan[DPid].Routines[int(RunningGoid)])
+		LogSchedulingEvent(dproc.Routines[int(RunningGoid)])
 		println(DPid, "is going to execute")
 	}
 	//\@DARA INJECT
@@ -249,9 +249,12 @@ func os_beforeExit() {
 
 // start forcegc helper goroutine
 func init() {
-	//@DARA Inject - Do not run garbage collection
-	dprint(dara.DEBUG, func() { println("Initializing runtime") })
-	return
+	//@DARA Inject - forcegc collects garbage when the clock of the
+	//machine says so, under Dara garbage is only collected when
+	//allocation triggers it
+	if Is_Dara_On() {
+		return
+	}
 	//\@DARA Inject
 	go forcegchelper()
 }
@@ -583,14 +586,9 @@ func mcommoninit(mp *m) {
 	//mp.fastrand[0] = 1597334677 * uint32(mp.id)
 	//mp.fastrand[1] = uint32(cputicks())
 	//INJECTED
-	mp.fastrand[0] = 1597334677 * uint32(0)
-	mp.fastrand[1] = uint32(0)
+	daraSeedM(mp)
 	//end @DARA INJECT
 
-	if mp.fastrand[0]|mp.fastrand[1] == 0 {
-		mp.fastrand[1] = 1
-	}
-
 	mpreinit(mp)
 	if mp.gsignal != nil {
 		mp.gsignal.stackguard1 = mp.gsignal.stack.lo + _StackGuard
@@ -628,6 +626,10 @@ func ready(gp *g, traceskip int, next bool) {
 		throw("bad g->status in ready")
 	}
 
+	if daraFiring {
+		daraReadied.set(gp)
+	}
+
 	// status is Gwaiting or Gscanwaiting, make Grunnable and put on runq
 	casgstatus(gp, _Gwaiting, _Grunnable)
 	runqput(_g_.m.p.ptr(), gp, next)
@@ -2356,6 +2358,24 @@ top:
 			})
 			goto top
 		}
+		// A recording process polls the virtual network itself, in
+		// replay and exploration that is up to the global scheduler.
+		if (Record || FastReplay) && len(daraVNetWaiters) > 0 && daraVNetPoll() {
+			goto top
+		}
+		if FastReplay && len(daraVNetWaiters) > 0 {
+			osyield()
+			goto top
+		}
+		// Only a timer can wake a goroutine up, which is up to the
+		// global scheduler. Goroutines waiting on the network are left
+		// to the netpoll below, unless the process holds messages,
+		// which only the global scheduler can deliver, or they wait on
+		// the virtual network, which only the global scheduler polls.
+		if !FastReplay && (len(TimerInfo) > 0 && atomic.Load(&netpollWaiters) == 0 || len(daraOutbox) > 0 || len(daraVNetWaiters) > 0) {
+			daraIdle()
+			goto top
+		}
 	}
 
 	// Steal work from other P's.
@@ -2500,24 +2520,19 @@ stop:
 		dprint(dara.INFO, func() { println("[GoRuntime]findrunnable (STOPPED) - Releasing lock before polling on network") })
 		if DaraInitialised {
 			// DARA Release lock to the global scheduler so that we can get network messages from other nodes
-			procchan[DPid].Run = -6
-            LogCoverage()
-			atomic.Store(&(procchan[DPid].Lock), dara.UNLOCKED)
-			HasDaraLock = false
+			dproc.Reply = dara.REPLY_NET_BLOCK
+			daraReleaseLock()
 		}
 		gp := netpoll(true) // block until new work is available
 		if DaraInitialised {
 			// Need to reacquire the lock before continuing
 			dprint(dara.INFO, func() {println("[GoRuntime] Woken up after netpoll")})
-			procchan[DPid].Run = -7
-			for {
-				if atomic.Cas(&(procchan[DPid].Lock), dara.UNLOCKED, dara.LOCKED) {
-					// We got the lock, time to get out of this loop!
-					dprint(dara.DEBUG, func() {println("[GoRuntime] Reacquired lock after netpoll")})
-					HasDaraLock = true
-					break
-				}
-			}
+			daraLock(&dproc.Lock)
+			dprint(dara.DEBUG, func() {println("[GoRuntime] Reacquired lock after netpoll")})
+			HasDaraLock = true
+			//Only with the lock held, the global scheduler would take
+			//it for an answer otherwise
+			dproc.Reply = dara.REPLY_NET_WAKEUP
 		}
 		atomic.Store64(&sched.lastpoll, uint64(nanotime()))
 		dprint(dara.INFO, func() {println("[GoRuntime]findrunnable gp after netpoll is nil?", gp == nil)} )
@@ -2712,12 +2727,141 @@ var dgStatusStrings = [...]string{
 //timerID is the unique timer ID that the local scheduler gives to each timer.
 func daraExecuteTimer(timerID int64) {
 	if t, ok := TimerInfo[timerID]; ok {
+		//The timer fires at the time it was due at
+		due := t.when
+		daraTimeAdvance(due)
 		// Extract the function pointer and the arguments from the timer object
 		f := t.f
 		arg := t.arg
 		seq := t.seq
+		daraFireTimer(t)
 		// Fire the timer!
-		f(arg, seq)
+		daraRunTimer(timerID, t, due, f, arg, seq)
+	} else {
+		dprint(dara.WARN, func() { println("[GoRuntime]daraExecuteTimer : No timer with id", timerID, "is armed") })
+	}
+}
+
+//daraLogSlot returns the slot of the event ring the next event is
+//written to. Events are never overwritten before the global scheduler
+//has read them: if the ring is full the runtime wakes up the global
+//scheduler, which drains the ring whenever it is woken up while
+//waiting for Lock, and waits for it to make room. The goroutine can
+//not be preempted until it calls daraLogCommit, so that two events
+//never end up in the same slot.
+func daraLogSlot() *dara.EncEvent {
+	acquirem()
+	head := dproc.LogHead
+	for {
+		tail := atomic.Load(&dproc.LogTail)
+		if head-tail < dara.MAXLOGENTRIES {
+			e := &dproc.Log[head%dara.MAXLOGENTRIES]
+			//Only a faulted syscall sets the fault of its event
+			e.Fault = dara.Fault{}
+			return e
+		}
+		dprint(dara.DEBUG, func() { println("[GoRuntime]daraLogSlot : Event ring full, waiting for the global scheduler to drain it") })
+		daraWake(&dproc.Lock)
+		daraWait(&dproc.LogTail, tail)
+	}
+}
+
+//daraLogCommit hands the event written to the slot returned by
+//daraLogSlot over to the global scheduler
+func daraLogCommit() {
+	e := &dproc.Log[dproc.LogHead%dara.MAXLOGENTRIES]
+	e.Clock = daraClockEvent(e.G.Gid)
+	e.Now = dproc.Now
+	e.ArenaEnd = dproc.ArenaHead
+	atomic.Store(&dproc.LogHead, dproc.LogHead+1)
+	if Nanobenchmark {
+		//Nobody drains the ring while nanobenchmarking
+		atomic.Store(&dproc.ArenaTail, dproc.ArenaHead)
+		atomic.Store(&dproc.LogTail, dproc.LogHead)
+	}
+	releasem(getg().m)
+}
+
+//daraArenaAlloc allocates n bytes of the arena to the event being
+//written, it may only be called between daraLogSlot and
+//daraLogCommit. Like daraLogSlot it waits for the global scheduler to
+//free older events if the arena is full. If the data of the event
+//itself fills the arena the allocation is cut short, and the returned
+//slice is shorter than n.
+func daraArenaAlloc(n int) (dara.ArenaRef, []byte) {
+	if n > dara.ARENASIZE {
+		n = dara.ARENASIZE
+	}
+	for {
+		//LogTail is stored after ArenaTail by the global scheduler
+		tail := atomic.Load(&dproc.LogTail)
+		free := dara.ARENASIZE - (dproc.ArenaHead - atomic.Load(&dproc.ArenaTail))
+		off := dproc.ArenaHead % dara.ARENASIZE
+		var skip uint32
+		if off+uint32(n) > dara.ARENASIZE {
+			//Data does not wrap around, skip the end of the arena
+			skip = dara.ARENASIZE - off
+		}
+		if uint32(n)+skip > free && tail == dproc.LogHead {
+			//Every other event has been read, the space will not
+			//grow by waiting
+			skip = 0
+			if room := dara.ARENASIZE - off; room < free {
+				free = room
+			}
+			n = int(free)
+			dprint(dara.WARN, func() { println("[GoRuntime]daraArenaAlloc : Event data does not fit in the arena, truncating it") })
+		}
+		if uint32(n)+skip <= free {
+			off = (off + skip) % dara.ARENASIZE
+			dproc.ArenaHead += skip + uint32(n)
+			return dara.ArenaRef{Off: off, Len: uint32(n)}, dproc.Arena[off : off+uint32(n)]
+		}
+		dprint(dara.DEBUG, func() { println("[GoRuntime]daraArenaAlloc : Arena full, waiting for the global scheduler to drain the event ring") })
+		daraWake(&dproc.Lock)
+		daraWait(&dproc.LogTail, tail)
+	}
+}
+
+//daraArenaString copies s to the arena
+func daraArenaString(s string) dara.ArenaRef {
+	ref, b := daraArenaAlloc(len(s))
+	copy(b, s)
+	return ref
+}
+
+//daraArenaBytes copies b to the arena
+func daraArenaBytes(b []byte) dara.ArenaRef {
+	ref, dst := daraArenaAlloc(len(b))
+	copy(dst, b)
+	return ref
+}
+
+//daraEncodeSyscall copies a syscall to an event, moving its strings
+//to the arena
+func daraEncodeSyscall(dst *dara.EncGeneralSyscall, src *dara.GeneralSyscall) {
+	dst.SyscallNum = src.SyscallNum
+	dst.NumArgs = src.NumArgs
+	dst.NumRets = src.NumRets
+	for i := range src.Args {
+		daraEncodeGeneralType(&dst.Args[i], &src.Args[i])
+	}
+	for i := range src.Rets {
+		daraEncodeGeneralType(&dst.Rets[i], &src.Rets[i])
+	}
+}
+
+func daraEncodeGeneralType(dst *dara.EncGeneralType, src *dara.GeneralType) {
+	*dst = dara.EncGeneralType{
+		Type:        src.Type,
+		Integer:     src.Integer,
+		Bool:        src.Bool,
+		Float:       src.Float,
+		Integer64:   src.Integer64,
+		Unsupported: src.Unsupported,
+	}
+	if src.String != "" {
+		dst.String = daraArenaString(src.String)
 	}
 }
 
@@ -2732,36 +2876,43 @@ func DaraLog(LogID, names string, values ...interface{}) {
 		// Function is a no-op if Dara is not initialised!
 		return
 	}
-	index := procchan[DPid].LogIndex
-	if index >= dara.MAXLOGENTRIES {
-		panic("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
-	}
 	if len(values) >= dara.MAXLOGVARIABLES {
 		panic("variables logged in " + LogID + " Exceeds MAXLOGVARIABLES, either modify dara/const or log fewer variables OwO")
 	}
-	e := &(procchan[DPid].Log[index])
+	splitnames := splitstring(names)
+	//Encode the values before taking a slot, an unsupported value
+	//panics and must not leave a half written event behind
+	var encoded []byte
+	var ends [dara.MAXLOGVARIABLES]int
+	for i := range values {
+		var ok bool
+		if encoded, ok = daraAppendValue(encoded, values[i]); !ok {
+			panic("variable " + splitnames[i] + " logged in " + LogID + " has a type DaraLog does not support")
+		}
+		ends[i] = len(encoded)
+	}
+	e := daraLogSlot()
 	(*e).Type = dara.LOG_EVENT
 	(*e).P = DPid
-	(*e).G = procchan[DPid].RunningRoutine
-	(*e).Epoch = procchan[DPid].Epoch
+	(*e).G = dproc.RunningRoutine
+	(*e).Epoch = dproc.Epoch
 
 	(*e).ELE.Length = len(values)
-	(*e).ELE.LogID = str2byte64(LogID)
-	splitnames := splitstring(names)
+	(*e).ELE.LogID = daraArenaString(LogID)
+	start := 0
 	for i := range values {
-		(*e).ELE.Vars[i].VarName = str2byte64(splitnames[i])
-		(*e).ELE.Vars[i].Value = encode(values[i])
-		(*e).ELE.Vars[i].Type = str2byte64(getType(values[i]))
+		(*e).ELE.Vars[i].VarName = daraArenaString(splitnames[i])
+		(*e).ELE.Vars[i].Value = daraArenaBytes(encoded[start:ends[i]])
+		(*e).ELE.Vars[i].Type = daraArenaString(getType(values[i]))
+		start = ends[i]
 	}
 	//This type of event is log, not syscall or sched. Zero the rest
 	//of memory in the log to prevent bugs
-	(*e).SyscallInfo = dara.GeneralSyscall{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{}
 	(*e).EM = dara.EncodedMessage{}
+	(*e).Select = dara.EncSelectInfo{}
 	//logging finished update index
-	procchan[DPid].LogIndex++
-	if Nanobenchmark {
-		procchan[DPid].LogIndex = 0
-	}
+	daraLogCommit()
 }
 
 // DaraDeleteLogVar Deletes log variables
@@ -2769,245 +2920,340 @@ func DaraLog(LogID, names string, values ...interface{}) {
 // Effect: VarA, VarB, and VarC will be deleted from the current context used for property checking
 // Note: A subsequent call to DaraLog with any of these variables will add the variable back to the context.
 func DaraDeleteLogVar(LogID string, names ...string) {
-	index := procchan[DPid].LogIndex	
-	if index >= dara.MAXLOGENTRIES {
-		panic("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
-	}
 	if len(names) >= dara.MAXLOGVARIABLES {
 		panic("variables logged in " + LogID + " Exceeds MAXLOGVARIABLES, either modify dara/const or log fewer variables")
 	}
-	e := &(procchan[DPid].Log[index])
+	e := daraLogSlot()
 	(*e).Type = dara.DELETEVAR_EVENT
 	(*e).P = DPid
-	(*e).G = procchan[DPid].RunningRoutine
-	(*e).Epoch = procchan[DPid].Epoch
+	(*e).G = dproc.RunningRoutine
+	(*e).Epoch = dproc.Epoch
 
 	(*e).ELE.Length = len(names)
-	(*e).ELE.LogID = str2byte64(LogID)
+	(*e).ELE.LogID = daraArenaString(LogID)
 	for i := range names {
-		(*e).ELE.Vars[i].VarName = str2byte64(names[i])
+		(*e).ELE.Vars[i] = dara.EncNameValuePair{VarName: daraArenaString(names[i])}
 	}
 	//This type of event is log, not syscall or sched. Zero the rest
 	//of memory in the log to prevent bugs
-	(*e).SyscallInfo = dara.GeneralSyscall{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{}
 	(*e).EM = dara.EncodedMessage{}
+	(*e).Select = dara.EncSelectInfo{}
 	//logging finished update index
-	procchan[DPid].LogIndex++
-	if Nanobenchmark {
-		procchan[DPid].LogIndex = 0
-	}
+	daraLogCommit()
 }
 
+//LogInitEvent logs the start of the process along with its DARA_SEED,
+//DARA_SYSCALL_POINTS, DARA_HOLD_MESSAGES and DARA_VIRTUAL_NET, which a
+//replay must run the process with again
 func LogInitEvent() {
-	index := procchan[DPid].LogIndex
-	if index >= dara.MAXLOGENTRIES {
-		panic("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
-	}
-	e := &(procchan[DPid].Log[index])
+	e := daraLogSlot()
 	(*e).Type = dara.INIT_EVENT
 	(*e).P = DPid
 	//Zero the rest of memory
-	(*e).G = procchan[DPid].RunningRoutine
-	(*e).Epoch = procchan[DPid].Epoch
+	(*e).G = dproc.RunningRoutine
+	(*e).Epoch = dproc.Epoch
 	(*e).ELE = dara.EncLogEntry{}
-	(*e).SyscallInfo = dara.GeneralSyscall{}
+	argInfo1 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: DaraSeed}
+	argInfo2 := dara.EncGeneralType{Type: dara.INTEGER, Integer: SyscallPoints}
+	argInfo3 := dara.EncGeneralType{Type: dara.BOOL, Bool: HoldMessages}
+	argInfo4 := dara.EncGeneralType{Type: dara.BOOL, Bool: VirtualNet}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{dara.DSYS_INIT, 4, 0, [10]dara.EncGeneralType{argInfo1, argInfo2, argInfo3, argInfo4}, [10]dara.EncGeneralType{}}
 	(*e).EM = dara.EncodedMessage{}
-	procchan[DPid].LogIndex++
-	if Nanobenchmark {
-		procchan[DPid].LogIndex = 0
-	}
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
 }
 
 //go:yeswritebarrierrec
 func LogEndEvent() {
-	index := procchan[DPid].LogIndex
 	if FastReplay {
 		return
 	}
-	if index >= dara.MAXLOGENTRIES {
-		panic("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
-	}
-	e := &(procchan[DPid].Log[index])
+	e := daraLogSlot()
 	(*e).Type = dara.END_EVENT
 	(*e).P = DPid
 	//Zero the rest of memory
-	//dprint(dara.INFO, func() {println("[GoRuntime]LogEndEvent : Running Routine is", procchan[DPid].RunningRoutine.Gid)})
-	(*e).G = procchan[DPid].RunningRoutine
-	(*e).Epoch = procchan[DPid].Epoch
+	//dprint(dara.INFO, func() {println("[GoRuntime]LogEndEvent : Running Routine is", dproc.RunningRoutine.Gid)})
+	(*e).G = dproc.RunningRoutine
+	(*e).Epoch = dproc.Epoch
 	(*e).ELE = dara.EncLogEntry{}
-	(*e).SyscallInfo = dara.GeneralSyscall{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{}
 	(*e).EM = dara.EncodedMessage{}
-	procchan[DPid].LogIndex++
-	if Nanobenchmark {
-		procchan[DPid].LogIndex = 0
-	}
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
 	dprint(dara.DEBUG, func() {
-		println("[GoRuntime]LogEndEvent : LogIndex after logging end event is", procchan[DPid].LogIndex)
+		println("[GoRuntime]LogEndEvent : LogHead after logging end event is", dproc.LogHead)
 	})
 }
 
 func LogCoverage() {
-    var index int
-    for blockID, value := range CoverageInfo {
-        procchan[DPid].Coverage[index].Count = value
-        copy(procchan[DPid].Coverage[index].BlockID[:], blockID)
-        // Remove the key as we have the info stored
-        // We also don't want old info creeping into
-        // future reports....
-        index += 1
-        procchan[DPid].CoverageIndex += 1
-        delete(CoverageInfo, blockID)
+	if dproc.CoverageIndex == 0 {
+		//The global scheduler has collected the last report
+		dproc.CoverageNamesLen = 0
+	}
+	for blockID, value := range CoverageInfo {
+		index := dproc.CoverageIndex
+		off := dproc.CoverageNamesLen
+		if index >= dara.MAXBLOCKS || int(off)+len(blockID) > dara.COVERAGENAMESSIZE {
+			//The report is full, the remaining blocks stay in
+			//CoverageInfo until the next one
+			break
+		}
+		dproc.Coverage[index].Count = value
+		dproc.Coverage[index].BlockID = dara.ArenaRef{Off: off, Len: uint32(len(blockID))}
+		copy(dproc.CoverageNames[off:], blockID)
+		dproc.CoverageNamesLen += uint32(len(blockID))
+		dproc.CoverageIndex++
+		// Remove the key as we have the info stored
+		// We also don't want old info creeping into
+		// future reports....
+		delete(CoverageInfo, blockID)
 	}
 	if Nanobenchmark {
-		procchan[DPid].CoverageIndex = 0
+		dproc.CoverageIndex = 0
 	}
 }
 
 func LogSchedulingEvent(routine dara.RoutineInfo) {
-	index := procchan[DPid].LogIndex
 	if FastReplay {
 		return
 	}
-	if index >= dara.MAXLOGENTRIES {
-		panic("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
-	}
 	//println("Local Runtime : Recording Scheduling event")
-	e := &(procchan[DPid].Log[index])
+	e := daraLogSlot()
 	(*e).Type = dara.SCHED_EVENT
 	(*e).P = DPid
 	(*e).G = routine //This is the key bit
-	(*e).Epoch = procchan[DPid].Epoch
+	(*e).Epoch = dproc.Epoch
 
 	//Zero the rest of memory
 	(*e).ELE = dara.EncLogEntry{}
-	(*e).SyscallInfo = dara.GeneralSyscall{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{}
 	(*e).EM = dara.EncodedMessage{}
-	procchan[DPid].LogIndex++
-	if Nanobenchmark {
-		procchan[DPid].LogIndex = 0
-	}
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
 }
 
-func LogTimerEvent(t *timer) {
-	index := procchan[DPid].LogIndex
-	if index >= dara.MAXLOGENTRIES {
-		panic("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
+//LogTimerEvent logs that the timer with the given id was armed or
+//cancelled, op is one of the dara.TIMER_ constants
+func LogTimerEvent(id int64, t *timer, op int) {
+	e := daraLogSlot()
+	(*e).Type = dara.TIMER_EVENT
+	(*e).P = DPid
+	(*e).G = dproc.RunningRoutine //Redundant reporting for ease
+	(*e).Epoch = dproc.Epoch
+
+	(*e).ELE = dara.EncLogEntry{}
+	argInfo1 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: id}
+	argInfo2 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: t.when}
+	argInfo3 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: t.period}
+	argInfo4 := dara.EncGeneralType{Type: dara.INTEGER, Integer: op}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{dara.DSYS_TIMER, 4, 0, [10]dara.EncGeneralType{argInfo1, argInfo2, argInfo3, argInfo4}, [10]dara.EncGeneralType{}}
+	(*e).EM = dara.EncodedMessage{}
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
+}
+
+//LogTimerFired logs that the timer with the given id, which was due
+//at due, fired and readied gp. gp is nil if firing the timer readied
+//no goroutine. A fired timer is a scheduling decision, the global
+//scheduler replays it with CMD_FIRE_TIMER.
+func LogTimerFired(id int64, t *timer, due int64, gp *g) {
+	var gid, lid int64
+	if gp != nil {
+		gid, lid = gp.goid, int64(gp.daralid)
 	}
-	e := &(procchan[DPid].Log[index])
+	e := daraLogSlot()
 	(*e).Type = dara.TIMER_EVENT
 	(*e).P = DPid
-	(*e).G = procchan[DPid].RunningRoutine //Redundant reporting for ease
-	(*e).Epoch = procchan[DPid].Epoch
+	(*e).G = dproc.RunningRoutine
+	(*e).Epoch = dproc.Epoch
 
 	(*e).ELE = dara.EncLogEntry{}
-	argInfo1 := dara.GeneralType{Type: dara.INTEGER64, Integer64: TimerCount}
-	argInfo2 := dara.GeneralType{Type: dara.INTEGER64, Integer64: t.when}
-	argInfo3 := dara.GeneralType{Type: dara.INTEGER64, Integer64: t.period}
-	(*e).SyscallInfo = dara.GeneralSyscall{dara.DSYS_TIMER, 3, 0, [10]dara.GeneralType{argInfo1, argInfo2, argInfo3}, [10]dara.GeneralType{}}
+	argInfo1 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: id}
+	argInfo2 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: due}
+	argInfo3 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: t.period}
+	argInfo4 := dara.EncGeneralType{Type: dara.INTEGER, Integer: dara.TIMER_FIRED}
+	argInfo5 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: gid}
+	argInfo6 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: lid}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{dara.DSYS_TIMER, 6, 0, [10]dara.EncGeneralType{argInfo1, argInfo2, argInfo3, argInfo4, argInfo5, argInfo6}, [10]dara.EncGeneralType{}}
 	(*e).EM = dara.EncodedMessage{}
-	procchan[DPid].LogIndex++
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
 }
 
-func LogThreadCreation(routine dara.RoutineInfo) {
-	index := procchan[DPid].LogIndex
+//LogSelectEvent reports which case of a select with more than one
+//ready case was taken
+func LogSelectEvent(info *dara.EncSelectInfo) {
 	if FastReplay {
 		return
 	}
-	if index >= dara.MAXLOGENTRIES {
-		panic("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
+	e := daraLogSlot()
+	(*e).Type = dara.SELECT_EVENT
+	(*e).P = DPid
+	(*e).G = dproc.RunningRoutine
+	(*e).Epoch = dproc.Epoch
+
+	//Zero the rest of memory
+	(*e).ELE = dara.EncLogEntry{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{}
+	(*e).EM = dara.EncodedMessage{}
+	(*e).Select = *info
+	daraLogCommit()
+}
+
+func LogThreadCreation(routine dara.RoutineInfo) {
+	if FastReplay {
+		return
 	}
-	e := &(procchan[DPid].Log[index])
+	e := daraLogSlot()
 	(*e).Type = dara.THREAD_EVENT
 	(*e).P = DPid
 	(*e).G = routine
-	(*e).Epoch = procchan[DPid].Epoch
+	(*e).Epoch = dproc.Epoch
 
 	//Zero the rest of memory
 	(*e).ELE = dara.EncLogEntry{}
-	(*e).SyscallInfo = dara.GeneralSyscall{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{}
 	(*e).EM = dara.EncodedMessage{}
-	procchan[DPid].LogIndex++
-	if Nanobenchmark {
-		procchan[DPid].LogIndex = 0
-	}
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
 }
 
 //go:yeswritebarrierrec
 func LogCrash(routine dara.RoutineInfo) {
-	index := procchan[DPid].LogIndex
-	// Set the Run variable to -100 to tell global scheduler that process is going to die
-	procchan[DPid].Run = -100
+	// Tell the global scheduler that the process is going to die
+	daraReply(dara.REPLY_CRASHED)
+	dproc.State = dara.STATE_FINISHED
 	if FastReplay {
 		return
 	}
-	if index >= dara.MAXLOGENTRIES {
-		throw("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
-	}
-	e := &(procchan[DPid].Log[index])
+	e := daraLogSlot()
 	(*e).Type = dara.CRASH_EVENT
 	(*e).P = DPid
 	(*e).G = routine
-	(*e).Epoch = procchan[DPid].Epoch
+	(*e).Epoch = dproc.Epoch
 
 	//Zero the rest of memory
 	(*e).ELE = dara.EncLogEntry{}
-	(*e).SyscallInfo = dara.GeneralSyscall{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{}
 	(*e).EM = dara.EncodedMessage{}
-	procchan[DPid].LogIndex++
-	if Nanobenchmark {
-		procchan[DPid].LogIndex = 0
-	}
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
 }
 
 func LogSyscall(syscallInfo dara.GeneralSyscall) {
 	if DaraInitialised && !FastReplay {
-		index := procchan[DPid].LogIndex
-		if index >= dara.MAXLOGENTRIES {
-			panic("logging entries exceeded MAXLOGENTRIES, either modify dara/const.go or log less OwO")
-		}
 		//println("Local Runtime : Recording syscall event")
-		procchan[DPid].LogIndex++
-		//println("New LogIndex is", procchan[DPid].LogIndex)
-		e := &(procchan[DPid].Log[index])
+		e := daraLogSlot()
 		(*e).Type = dara.SYSCALL_EVENT
 		(*e).P = DPid
-		//dprint(dara.INFO, func() {println("[GoRuntime]LogSyscall : Running Routine is", procchan[DPid].RunningRoutine.Gid)})
-		(*e).G = procchan[DPid].RunningRoutine //Redundant reporting for ease
-		(*e).Epoch = procchan[DPid].Epoch
+		//dprint(dara.INFO, func() {println("[GoRuntime]LogSyscall : Running Routine is", dproc.RunningRoutine.Gid)})
+		(*e).G = dproc.RunningRoutine //Redundant reporting for ease
+		(*e).Epoch = dproc.Epoch
 
 		(*e).ELE = dara.EncLogEntry{}
-		(*e).SyscallInfo = syscallInfo
+		daraEncodeSyscall(&(*e).SyscallInfo, &syscallInfo)
 		(*e).EM = dara.EncodedMessage{}
+		(*e).Select = dara.EncSelectInfo{}
 		//buf := dara_Stack()
 		//println(buf)
-		if Nanobenchmark {
-			procchan[DPid].LogIndex = 0
-		}
+		daraLogCommit()
 	}
 }
 
+//LogFault logs the SYSCALL_EVENT of a syscall the global scheduler
+//injected the fault f into
+func LogFault(f dara.Fault) {
+	if FastReplay {
+		return
+	}
+	e := daraLogSlot()
+	(*e).Type = dara.SYSCALL_EVENT
+	(*e).P = DPid
+	(*e).G = dproc.RunningRoutine
+	(*e).Epoch = dproc.Epoch
+
+	(*e).ELE = dara.EncLogEntry{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{f.Syscall, 0, 0, [10]dara.EncGeneralType{}, [10]dara.EncGeneralType{}}
+	(*e).EM = dara.EncodedMessage{}
+	(*e).Select = dara.EncSelectInfo{}
+	(*e).Fault = f
+	daraLogCommit()
+}
+
+//LogHeldMessage logs what happened to the message m held for the
+//global scheduler, op is one of the dara.MESSAGE_ constants and n the
+//number of bytes it concerns. The bytes of a held message are logged
+//along with it.
+func LogHeldMessage(m *daraMessage, op int, n int) {
+	if FastReplay {
+		return
+	}
+	e := daraLogSlot()
+	(*e).Type = dara.MESSAGE_EVENT
+	(*e).P = DPid
+	(*e).G = dproc.RunningRoutine
+	(*e).Epoch = dproc.Epoch
+
+	(*e).ELE = dara.EncLogEntry{}
+	argInfo1 := dara.EncGeneralType{Type: dara.INTEGER64, Integer64: m.id}
+	argInfo2 := dara.EncGeneralType{Type: dara.INTEGER, Integer: m.dst}
+	argInfo3 := dara.EncGeneralType{Type: dara.STRING, String: daraArenaString(m.conn)}
+	argInfo4 := dara.EncGeneralType{Type: dara.INTEGER, Integer: op}
+	argInfo5 := dara.EncGeneralType{Type: dara.INTEGER, Integer: n}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{dara.DSYS_MESSAGE, 5, 0, [10]dara.EncGeneralType{argInfo1, argInfo2, argInfo3, argInfo4, argInfo5}, [10]dara.EncGeneralType{}}
+	(*e).EM = dara.EncodedMessage{}
+	if op == dara.MESSAGE_HELD {
+		(*e).EM.Body = daraArenaBytes(m.data[:n])
+	}
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
+}
+
+//LogMessage reports a message passed over a channel, msgtype is
+//SEND_EVENT or REC_EVENT
 func LogMessage(msgtype int, m dara.Message) {
-	//TODO
+	logMessageOf(dproc.RunningRoutine, msgtype, m)
 }
 
-func report_syscall(syscallID int, syscallInfo dara.GeneralSyscall) {
+//logMessageOf is LogMessage for a message passed by the goroutine g
+func logMessageOf(g dara.RoutineInfo, msgtype int, m dara.Message) {
+	if FastReplay {
+		return
+	}
+	e := daraLogSlot()
+	(*e).Type = msgtype
+	(*e).P = DPid
+	(*e).G = g
+	(*e).Epoch = dproc.Epoch
+
+	//Zero the rest of memory
+	(*e).ELE = dara.EncLogEntry{}
+	(*e).SyscallInfo = dara.EncGeneralSyscall{}
+	(*e).EM = dara.EncodedMessage{
+		Chan:    m.Chan,
+		Len:     m.Len,
+		Cap:     m.Cap,
+		Closed:  m.Closed,
+		Partner: m.Partner,
+	}
+	if m.Body != "" {
+		(*e).EM.Body = daraArenaString(m.Body)
+	}
+	(*e).Select = dara.EncSelectInfo{}
+	daraLogCommit()
+}
+
+//report_syscall hands control to the global scheduler before the
+//running goroutine makes the syscall syscallID. The goroutine stays
+//runnable and carries on with the syscall once it is scheduled again,
+//the global scheduler finds the syscall in DaraProc.Syscall until it
+//issues its next command.
+func report_syscall(syscallID int) {
 	if DaraInitialised {
-		procchan[DPid].Syscall = syscallID
-		//procchan[DPid].RunningRoutine.SyscallInfo = syscallInfo
-		atomic.Store(&(procchan[DPid].SyscallLock), dara.UNLOCKED)
-		moveForward := false
 		dprint(dara.DEBUG, func() { println("[GoRuntime]report_syscall : Syscall#", syscallID) })
-		for !moveForward {
-			if atomic.Cas(&(procchan[DPid].SyscallLock), dara.UNLOCKED, dara.LOCKED) {
-				if procchan[DPid].Syscall == -1 {
-					moveForward = true
-				} else {
-					atomic.Store(&(procchan[DPid].SyscallLock), dara.UNLOCKED)
-				}
-			}
-		}
-		//procchan[DPid].RunningRoutine.SyscallInfo = dara.GeneralSyscall{}
-		procchan[DPid].Syscall = -1
+		dproc.Syscall = syscallID
+		Gosched()
 	}
 }
 
@@ -3029,84 +3275,33 @@ func splitstring(names string) []string {
 	return parsednames
 }
 
-//TODO more granular typing
+//getType returns the kind of value v, as reported to the global
+//scheduler along with the value
 func getType(v interface{}) string {
 	switch v.(type) {
+	case nil:
+		return dara.NIL_STRING
 	case bool:
 		return dara.BOOL_STRING
 	case int, int8, int16, int32, int64:
 		return dara.INT_STRING
+	case uint, uint8, uint16, uint32, uint64, uintptr:
+		return dara.UINT_STRING
 	case float32, float64:
 		return dara.FLOAT_STRING
 	case string:
 		return dara.STRING_STRING
+	case []byte:
+		return dara.BYTES_STRING
+	case []interface{}:
+		return dara.SLICE_STRING
+	case map[string]interface{}:
+		return dara.MAP_STRING
 	default:
 		panic("unsuported-type")
 	}
 }
 
-func encode(v interface{}) [dara.VARBUFLEN]byte {
-	var copy [dara.VARBUFLEN]byte
-	var intcast int64
-	var floatcast float64
-	switch t := v.(type) {
-	case bool:
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&t))
-	case int:
-		intcast = int64(t)
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&intcast))
-	case int8:
-		intcast = int64(t)
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&intcast))
-	case int16:
-		intcast = int64(t)
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&intcast))
-	case int32:
-		intcast = int64(t)
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&intcast))
-	case int64:
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&t))
-	case float32:
-		floatcast = float64(t)
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&floatcast))
-	case float64:
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&t))
-	case string:
-		if len(t) > dara.VARBUFLEN {
-			panic("CANT ENCODE STRING IT's TOO LONG!!!")
-		}
-		copy = *(*[dara.VARBUFLEN]byte)(unsafe.Pointer(&t))
-	default:
-		panic("ERR CANNOT ENCODE!")
-	}
-	return copy
-}
-
-func DecodeValue(name, buffer [dara.VARBUFLEN]byte) interface{} {
-	a := string(name[:])
-	if a[0:len(dara.BOOL_STRING)] == dara.BOOL_STRING {
-		return *(*bool)(unsafe.Pointer(&buffer))
-	} else if a[:len(dara.INT_STRING)] == dara.INT_STRING {
-		return *(*int)(unsafe.Pointer(&buffer))
-	} else if a[:len(dara.FLOAT_STRING)] == dara.FLOAT_STRING {
-		return *(*float32)(unsafe.Pointer(&buffer))
-	} else if a[:len(dara.STRING_STRING)] == dara.STRING_STRING {
-		return *(*string)(unsafe.Pointer(&buffer))
-	}
-	panic("unsupported decode type: " + string(a) + "\n")
-	return nil
-}
-
-func str2byte64(s string) (ret [dara.VARBUFLEN]byte) {
-	if len(s) > dara.VARBUFLEN {
-		panic("String to long to convert to [dara.VARBUFLEN]byte")
-	}
-	for i := 0; i < len(s); i++ {
-		ret[i] = s[i]
-	}
-	return
-}
-
 func initDara(proc_pid uint64) {
 	DaraInitialised = true
 	Running = true
@@ -3120,16 +3315,45 @@ func initDara(proc_pid uint64) {
 	CoverageInfo = make(map[string]uint64)
 	ChanSendInfo = make(map[unsafe.Pointer]int)
 	ChanRecvInfo = make(map[unsafe.Pointer]int)
+	ChanMakeInfo = make(map[uintptr]int)
+	ChanClockInfo = make(map[unsafe.Pointer][]dara.VectorClock)
+	SyncClockInfo = make(map[unsafe.Pointer]*dara.VectorClock)
 	TimerInfo    = make(map[int64]*timer)
+	TimerIDs     = make(map[*timer]int64)
+	SyscallCalls = make(map[int]int)
 
 	mode := gogetenv("DARA_MODE")
 	switch mode {
+	case "record":
+		Record = true
 	case "replay":
 		Replay = true
 	case "explore":
 		Explore = true
 	}
 
+	if seed := gogetenv("DARA_SEED"); seed != "" {
+		if v, ok := atoi(seed); ok {
+			DaraSeed = int64(v)
+		} else {
+			dprint(dara.WARN, func() { println("[GoRuntime]initDara : DARA_SEED", seed, "is not a number, using 0") })
+		}
+	}
+	if points := gogetenv("DARA_SYSCALL_POINTS"); points != "" {
+		if v, ok := atoi(points); ok {
+			SyscallPoints = v
+		} else {
+			dprint(dara.WARN, func() { println("[GoRuntime]initDara : DARA_SYSCALL_POINTS", points, "is not a number, no syscall is a scheduling point") })
+		}
+	}
+	//The Ms that exist were seeded before DARA_SEED was known
+	for mp := allm; mp != nil; mp = mp.alllink {
+		daraSeedM(mp)
+	}
+
+	HoldMessages = gogetenv("DARA_HOLD_MESSAGES") == "true"
+	VirtualNet = gogetenv("DARA_VIRTUAL_NET") == "true"
+
 	fast_replay := gogetenv("FAST_REPLAY")
 	if fast_replay == "true" {
 		FastReplay = true
@@ -3147,22 +3371,6 @@ func initDara(proc_pid uint64) {
 	// Remove once testing complete
 	//Microbenchmark = true
 
-	var err int
-	smptr, err = mmap(nil, dara.CHANNELS*dara.DARAPROCSIZE, _PROT_READ|_PROT_WRITE, dara.MAP_SHARED, dara.DARAFD, 0)
-	if err != 0 {
-		switch err {
-		case _EINTR:
-			dprint(dara.WARN, func() { println("[GoRuntime]initDara : EINR") })
-		case _EAGAIN:
-			dprint(dara.WARN, func() { println("[GoRuntime]initDara : EAGAIN") })
-		case _ENOMEM:
-			dprint(dara.WARN, func() { println("[GoRuntime]initDara : ENOMEM") })
-		default:
-			dprint(dara.WARN, func() { println("[GoRuntime]initDara : Unknown Error") })
-		}
-	}
-	procchan = (*[dara.CHANNELS]dara.DaraProc)(smptr)
-
 	if pid, ok := atoi32(gogetenv("DARAPID")); ok {
 		DPid = int(pid)
 		dprint(dara.INFO, func() { println("[GoRuntime]initDara : DaraPID is", pid)})
@@ -3170,8 +3378,36 @@ func initDara(proc_pid uint64) {
 		dprint(dara.FATAL, func() { println("[GoRuntime]initDara : DARA turned on but DARAPID not set") })
 	}
 
+	// Map the header on its own first, it tells us how much memory
+	// the scheduler set up and where our DaraProc lives in it.
+	hdrptr, err := mmap(nil, dara.HEADERSIZE, _PROT_READ|_PROT_WRITE, dara.MAP_SHARED, dara.DARAFD, 0)
+	if err != 0 {
+		daraMmapFailed(err)
+	}
+	dheader = *(*dara.SharedHeader)(hdrptr)
+	munmap(hdrptr, dara.HEADERSIZE)
+	if field, local, shared := dheader.Mismatch(); field != "" {
+		if field == "Magic" {
+			println("[GoRuntime]initDara : shared memory has not been initialised by a global scheduler (magic", hex(shared), ", expected", hex(local), ")")
+		} else {
+			println("[GoRuntime]initDara : shared memory layout mismatch in", field, ": this runtime has", local, "but the global scheduler wrote", shared)
+			println("[GoRuntime]initDara : rebuild the global scheduler and the program with the same toolchain")
+		}
+		throw("dara: incompatible shared memory header")
+	}
+	if dheader.NumProcs <= 0 {
+		println("[GoRuntime]initDara : shared memory header reports", dheader.NumProcs, "processes")
+		throw("dara: bad shared memory header")
+	}
+
+	smptr, err = mmap(nil, dheader.MapSize(), _PROT_READ|_PROT_WRITE, dara.MAP_SHARED, dara.DARAFD, 0)
+	if err != 0 {
+		daraMmapFailed(err)
+	}
+	dproc = daraProcAt(DPid)
+
 	// Set the process ID
-	procchan[DPid].PID = proc_pid
+	dproc.PID = proc_pid
 
 	//Set up goid table for the inital threads
 
@@ -3185,64 +3421,103 @@ func initDara(proc_pid uint64) {
 		if allgs[i].goid == 1 {
 			fname = "main.main"
 		}
-		procchan[DPid].Routines[allgs[i].goid].Status = readgstatus(allgs[i])
-		procchan[DPid].Routines[allgs[i].goid].Gid = int(allgs[i].goid)
-		procchan[DPid].Routines[allgs[i].goid].Gpc = allgs[i].gopc
-		copy(procchan[DPid].Routines[allgs[i].goid].FuncInfo[:], fname)
+		dproc.Routines[allgs[i].goid].Status = readgstatus(allgs[i])
+		dproc.Routines[allgs[i].goid].Gid = int(allgs[i].goid)
+		dproc.Routines[allgs[i].goid].Gpc = allgs[i].gopc
+		copy(dproc.Routines[allgs[i].goid].FuncInfo[:], fname)
 		dprint(dara.DEBUG, func() { println("[GoRuntime]initDara : Goroutine", allgs[i].goid, "is running", fname) })
 		var duplicateCounter = 1
-		for j := 0; j < len(procchan[DPid].Routines); j++ {
-			if procchan[DPid].Routines[i].Gpc > 0 && procchan[DPid].Routines[j].Gpc == allgs[i].gopc {
+		for j := 0; j < len(dproc.Routines); j++ {
+			if dproc.Routines[i].Gpc > 0 && dproc.Routines[j].Gpc == allgs[i].gopc {
 				duplicateCounter++
 			}
 		}
-		procchan[DPid].Routines[allgs[i].goid].RoutineCount = duplicateCounter
+		dproc.Routines[allgs[i].goid].RoutineCount = duplicateCounter
+		//The goroutines that exist before Dara are named by their goids,
+		//which are deterministic this early
+		allgs[i].daralid = dara.ChildID(dara.ROOTID, uint64(allgs[i].goid))
+		allgs[i].daraspawns = 0
+		dproc.Routines[allgs[i].goid].LogicalID = allgs[i].daralid
 	}
 
 	if !Nanobenchmark {
-		for {
-			if atomic.Cas(&(procchan[DPid].Lock), dara.UNLOCKED, dara.LOCKED) {
-				dprint(dara.INFO, func() { println("[GoRuntime]initDara : Obtained Shared mem lock for the first time") })
-				HasDaraLock = true
-				break
-			}
-		}
+		daraLock(&dproc.Lock)
+		dprint(dara.INFO, func() { println("[GoRuntime]initDara : Obtained Shared mem lock for the first time") })
+		HasDaraLock = true
 	}
 
 	dprint(dara.DEBUG, func() {println("[GoRuntime]Moving forward with the execution after grabbing the initial lock")})
 
-	atomic.Store(&(procchan[DPid].SyscallLock), dara.LOCKED)
-	procchan[DPid].RunningRoutine = procchan[DPid].Routines[1]
+	dproc.Syscall = -1
+	daraTimeStart()
+	dproc.RunningRoutine = dproc.Routines[1]
 	LogInitEvent()
 	dprint(dara.DEBUG, func() { println("[GoRuntime]initDara : Dara Initialization Complete") })
 	//\DARA
 }
 
+//daraSeedM seeds the fastrand of mp with DaraSeed. Every M gets the
+//same state, so that the values do not depend on the M a goroutine
+//runs on. Maps made before initDara hash with the seed of 0.
+func daraSeedM(mp *m) {
+	mp.fastrand[0] = uint32(DaraSeed)
+	mp.fastrand[1] = uint32(DaraSeed >> 32)
+	if mp.fastrand[0]|mp.fastrand[1] == 0 {
+		mp.fastrand[1] = 1
+	}
+}
+
+//daraProcAt returns the DaraProc in shared memory that belongs to
+//DARAPID pid. An out of range pid is fatal, writing through it would
+//silently corrupt another process's slot or memory past the mapping.
+func daraProcAt(pid int) *dara.DaraProc {
+	if !dheader.ValidPid(pid) {
+		println("[GoRuntime]daraProcAt : DARAPID", pid, "is out of range, the global scheduler set up", dheader.NumProcs, "processes (valid DARAPIDs are 1 to", dheader.NumProcs, ")")
+		throw("dara: DARAPID out of range")
+	}
+	return (*dara.DaraProc)(add(smptr, dheader.ProcOffset(pid)))
+}
+
+func daraMmapFailed(err int) {
+	switch err {
+	case _EINTR:
+		println("[GoRuntime]initDara : mmap of shared memory failed with EINTR")
+	case _EAGAIN:
+		println("[GoRuntime]initDara : mmap of shared memory failed with EAGAIN")
+	case _ENOMEM:
+		println("[GoRuntime]initDara : mmap of shared memory failed with ENOMEM")
+	default:
+		println("[GoRuntime]initDara : mmap of shared memory failed with errno", err)
+	}
+	println("[GoRuntime]initDara : is the shared memory file open on fd", dara.DARAFD, "?")
+	throw("dara: unable to map shared memory")
+}
+
 func endDara() {
 	// Indicate that all of the goroutines have run its course.
 	dprint(dara.INFO, func() { println("[GoRuntime]endDara : Ending dara") })
 	for i := 0; i < len(allgs); i++ {
-		procchan[DPid].Routines[allgs[i].goid].Status = _Gdead
+		dproc.Routines[allgs[i].goid].Status = _Gdead
 	}
-	//println("Prochchan run status is ", procchan[DPid].Run)
 	DaraInitialised = false
 	LogEndEvent()
     LogCoverage()
 	HasDaraLock = false
-	procchan[DPid].Run = -100
+	if dproc.Reply != dara.REPLY_CRASHED {
+		daraReply(dara.REPLY_FINISHED)
+	}
+	dproc.State = dara.STATE_FINISHED
 	dprint(dara.INFO, func() { println("[GoRuntime]endDara : Dara end sequence completed") })
-	atomic.Store(&(procchan[DPid].Lock), dara.UNLOCKED)
+	daraUnlock(&dproc.Lock)
+	daraWake(&dproc.ReplySignal)
 }
 
 //go:yeswritebarrierrec
 func getScheduledGp(gp *g) *g {
 	origgp := gp
-	end_of_Replay := false
 	if DaraInitialised && !Nanobenchmark{
 	top:
-		for i := 0; i < len(allgs); i++ {
-			procchan[DPid].Routines[allgs[i].goid].Status = readgstatus(allgs[i])
-		}
+		daraSyncRoutines()
 
 		//casgstatus(gp,readgstatus(gp),_Gwaiting) //set g status to
 		//waiting (used for reference)
@@ -3250,240 +3525,472 @@ func getScheduledGp(gp *g) *g {
 			println("[GoRuntime]getScheduledGp : Scheduled routine (Proc: ", DPid, ", Goroutine:", RunningGoid, ") finished running")
 		})
 		if Running {
-			//report updates to state and unlock variable TODO this
-			//must be more expressive
+			//Answer the command that let the last goroutine run and
+			//hand control back to the global scheduler
 			dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Inside running") })
-			dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Value of run variable :", procchan[DPid].Run) })
-			if procchan[DPid].Run == -3 {
-				dprint(dara.INFO, func() {
-					println("[GoRuntime]getScheduledGp : Reporting Scheduling event with GoID", RunningGoid, origgp.goid)
-				})
-				procchan[DPid].Run = int(RunningGoid) //use the channel in the opposite direction
-
-			} else if procchan[DPid].Run == -4 {
-				end_of_Replay = true
-			} else if FastReplay && procchan[DPid].Run == -5 {
-				dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp: Inside Fast Replay mode") })
-			} else if procchan[DPid].Run != -7{
-				//this is the replay state
-				procchan[DPid].Run = -1
-			}
-
-			if procchan[DPid].Run == -4 {
-				end_of_Replay = true
-				dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Setting end of replay") })
-			}
-			if end_of_Replay {
-				dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : End of Replay") })
+			if dproc.State == dara.STATE_INIT {
+				daraReply(dara.REPLY_READY)
+			} else {
+				daraReply(dara.REPLY_RAN)
 			}
-			//Unlock
 			Running = false
 			dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Unlocking global lock on process ", DPid) })
-            LogCoverage()
-			atomic.Store(&(procchan[DPid].Lock), dara.UNLOCKED) //TODO unlock using scheduler api
-			HasDaraLock = false
+			if Microbenchmark {
+				handoffStart = nanotime()
+			}
+			daraReleaseLock()
 		} else {
 			dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Not Inside running") })
 		}
 
 		//wait for the global scheduler
 		for {
-			if atomic.Cas(&(procchan[DPid].Lock), dara.UNLOCKED, dara.LOCKED) {
-				//dprint(dara.DEBUG, func() {println("Unlocked")})
-				HasDaraLock = true
-				if procchan[DPid].Run == -5 {
-					dprint(dara.DEBUG, func() { println("I am with value -5") })
+			signal := atomic.Load(&dproc.CmdSignal)
+			daraLock(&dproc.Lock)
+			HasDaraLock = true
+			//In fast replay the schedule is already in the log, keep
+			//running it without waiting for a new command
+			fastReplaying := FastReplay && dproc.Cmd == dara.CMD_RUN_GOROUTINE
+			if dproc.CmdSeq == dproc.ReplySeq && !fastReplaying {
+				// No new command from the Global Scheduler. give up lock
+				// and sleep until one is signalled
+				daraReleaseLock()
+				daraWait(&dproc.CmdSignal, signal)
+				continue
+			}
+			//The round trip through the global scheduler, from handing
+			//it the lock to holding the command it answered with
+			if Microbenchmark && handoffStart != 0 {
+				println("HANDOFF,", nanotime()-handoffStart)
+				handoffStart = 0
+			}
+			cmd := dproc.Cmd
+			next, ok := dara.NextState(dproc.State, cmd)
+			if !ok {
+				dprint(dara.WARN, func() {
+					println("[GoRuntime]getScheduledGp : Illegal command", cmd.String(), "( seq", dproc.CmdSeq, ") in state", dproc.State.String())
+				})
+				daraReply(dara.REPLY_ILLEGAL)
+				daraReleaseLock()
+				continue
+			}
+			dproc.State = next
+			dproc.Syscall = -1
+			dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Received command", cmd.String(), "seq", dproc.CmdSeq) })
+			daraTimeCheck()
+
+			switch cmd {
+			case dara.CMD_START:
+				//first instance
+				dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : first instance") })
+				Running = true
+				return gp
+			case dara.CMD_RECORD_FREELY:
+				//Record mode, let the goruntime do whatever
+				//it wants and report back to the CS
+				RunningGoid = gp.goid
+				Record = true
+				Running = true
+				dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Running goroutine with id :", gp.goid, "and gopc:", gp.gopc) })
+				dproc.RunningRoutine = dproc.Routines[int(RunningGoid)]
+				//Running a timer goroutine is not a decision of its
+				//own, the timers it fires are logged instead
+				if gp.startpc != funcPC(timerproc) {
+					LogSchedulingEvent(dproc.RunningRoutine)
 				}
-				//dprint(dara.INFO, func () {println("Obtained lock with Run value",procchan[DPid].Run)})
-				if procchan[DPid].Run >= 0 {
-					// Waiting for command from Global Scheduler. give up lock
-					LogCoverage()
-					atomic.Store(&(procchan[DPid].Lock), dara.UNLOCKED)
-					HasDaraLock = false
-					continue
+				return gp
+			case dara.CMD_FIRE_TIMER:
+				timerID := dproc.CmdArg
+				dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Firing off timer:", timerID)})
+				daraExecuteTimer(timerID)
+				// Reset status and go back to waiting state
+				daraReply(dara.REPLY_TIMER_FIRED)
+				daraReleaseLock()
+				continue
+			case dara.CMD_DELIVER_MESSAGE:
+				dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Delivering message:", dproc.CmdArg) })
+				if !daraDeliver(dproc.CmdArg) {
+					daraReply(dara.REPLY_ILLEGAL)
+				} else {
+					daraReply(dara.REPLY_DELIVERED)
 				}
-				if procchan[DPid].Run != -1 { //&& procchan[DPid].Run != -2 && procchan[DPid].Run != -3 {
-					//print("active")
-					if procchan[DPid].Run == -2 {
-						//first instance
-						dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : first instance") })
-						Running = true
-						return gp
-					}
-					if procchan[DPid].Run == -3 {
-						//Record mode, let the goruntime do whatever
-						//it wants and report back to the CS
-						RunningGoid = gp.goid
-						Record = true
-						Running = true
-						//dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : gp record status: ",dgStatusStrings[readgstatus(gp)]) })
-						dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Running goroutine with id :", gp.goid, "and gopc:", gp.gopc) })
-						procchan[DPid].RunningRoutine.Status = procchan[DPid].Routines[int(RunningGoid)].Status
-						procchan[DPid].RunningRoutine.Gid = procchan[DPid].Routines[int(RunningGoid)].Gid
-						procchan[DPid].RunningRoutine.Gpc = procchan[DPid].Routines[int(RunningGoid)].Gpc
-						procchan[DPid].RunningRoutine.RoutineCount = procchan[DPid].Routines[int(RunningGoid)].RoutineCount
-						procchan[DPid].RunningRoutine.FuncInfo = procchan[DPid].Routines[int(RunningGoid)].FuncInfo
-						LogSchedulingEvent(procchan[DPid].RunningRoutine)
-						return gp
-					}
+				daraReleaseLock()
+				continue
+			case dara.CMD_POLL_NET:
+				dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Polling the virtual network") })
+				daraVNetPoll()
+				daraReply(dara.REPLY_POLLED)
+				daraReleaseLock()
+				continue
+			case dara.CMD_END_REPLAY:
+				dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Received ending message from Global Scheduler") })
+				daraDetach()
+				return gp
+			case dara.CMD_SHUTDOWN:
+				dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Shutting down on request of the Global Scheduler") })
+				endDara()
+				exit(0)
+			}
 
-					if procchan[DPid].Run == -4 {
-                        LogCoverage()
-						atomic.Store(&(procchan[DPid].Lock), dara.UNLOCKED)
-						dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Received ending message from Global Scheduler") })
-						HasDaraLock = false
-					}
-					if Record {
-						dprint(dara.INFO, func() {println("[GoRuntime]In record state")})
-						atomic.Store(&(procchan[DPid].Lock), dara.UNLOCKED) //unlock using scheduler api
-						HasDaraLock = false
-						continue
-					}
+			//CMD_RUN_GOROUTINE, Should not reach here if record
+		replay:
+			if FastReplay {
+				dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : CurrentFastReplay replay index:", ReplayIndex) })
+				dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : nextProc: ", dproc.Log[ReplayIndex%dara.MAXLOGENTRIES].G.Gid) })
+				dproc.RunningRoutine = dproc.Log[ReplayIndex%dara.MAXLOGENTRIES].G
+				ReplayIndex += 1
+			}
 
-					//Should not reach here if record
-
-				replay:
-				
-					// Fire off a specific timer
-					if procchan[DPid].Run == -5 {
-						// Check if there is a special code for timers
-						if procchan[DPid].RunningRoutine.RoutineCount == -5 {
-							timerID := int64(procchan[DPid].RunningRoutine.Gid)
-							dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Firing off timer:", timerID)})
-							daraExecuteTimer(timerID)
-							// Reset status and go back to waiting state
-							procchan[DPid].Run = -1
-							atomic.Store(&(procchan[DPid].Lock), dara.UNLOCKED)
-							HasDaraLock = false
-							continue
-						}
+			dprint(dara.INFO, func() {
+				println("[GoRuntime]getScheduledGp : Replay/Explore will try to schedule Goroutine #", dproc.RunningRoutine.Gid)
+			})
+			//If the goroutine in the schedule is ready run it
+
+			if gp.daralid == dproc.RunningRoutine.LogicalID {
+				dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Chosen goroutine is ready to be run") })
+			} 
+
+			/* Attempt 2 read everything off of allgs */
+			/* This did not seem to work, somewhere I was
+			* not correctly scheduling threads.
+			 */
+
+			/* In this loop we are almost guarenteed to
+			* find the g we are looking for but scheduling
+			* it can kill us. Use this loop to try and
+			* reason about dead threads, if they are dead
+			* then we can bork*/
+			if gp.daralid != dproc.RunningRoutine.LogicalID {
+				dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Unable to schedule g, triaging root cause") })
+
+				//Find g by looking in allgs should always
+				//work
+				for i := 0; i < len(allgs); i++ {
+					if allgs[i].daralid == dproc.RunningRoutine.LogicalID {
+						globrunqput(gp)
+						dprint(dara.DEBUG, func() {println("Found the g we were looking for")})
+						gp = allgs[i]
+						break
 					}
+				}
+				//If g is not in allg something is terribly
+				//wrong
+				if gp.daralid != dproc.RunningRoutine.LogicalID {
+					dprint(dara.FATAL, func() {
+						println("[GoRuntime]getScheduledGp : No goroutine with logical ID", dproc.RunningRoutine.LogicalID, "recorded as goid", dproc.RunningRoutine.Gid, "at Gopc", dproc.RunningRoutine.Gpc)
+					})
+				}
+				/* Old Check for routine count. We don't need it.
+				if gp.gopc != dproc.RunningRoutine.Gpc {
+					dprint(dara.FATAL, func() {
+						println("[GoRuntime]getScheduledGp : Scheduled G nowhere to be found. Are the recorded and replayed systems the same? Consider rebuilding and trying again")
+					})
+				} */
+
+				//BORK if Dead
+				if readgstatus(gp) == _Gdead {
+					dprint(dara.WARN, func() { println("[GoRuntime]getScheduledGp : Scheduled G is dead, replay may be invalid: Skipping G") })
+					//if the thread is allready dead I'm not
+					//sure what the best course of action is.
+					//It cannot possibly be scheduled. I think
+					//that pretending that the thread was run
+					//might be the right course for now.
+					gp = origgp
+					ResetProcArr(gp)
+					globrunqput(gp)
+					//Set running to true so that the schedule
+					//moves forward
+					Running = true
+					goto top
+				}
 
-					if FastReplay {
-						dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : CurrentFastReplay replay index:", ReplayIndex) })
-						dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : nextProc: ", procchan[DPid].Log[ReplayIndex].G.Gid) })
-						procchan[DPid].RunningRoutine = procchan[DPid].Log[ReplayIndex].G
-						ReplayIndex += 1
-					}
+				//This has the potential to kill
+				if readgstatus(gp) == _Gwaiting {
+					//stopm()
+					//if gp.goid == 1 {
+					//	ready(gp, 0, true)
+					//}
 
 					dprint(dara.INFO, func() {
-						println("[GoRuntime]getScheduledGp : Replay/Explore will try to schedule Goroutine #", procchan[DPid].RunningRoutine.Gid)
+						println("[GoRuntime]getScheduledGp : Scheduled Goroutine (", gp.goid, ") currently waiting. Busywaiting on routine")
 					})
-					//If the goroutine in the schedule is ready run it
-
-					if gp.gopc == procchan[DPid].RunningRoutine.Gpc && gp.goid == int64(procchan[DPid].RunningRoutine.Gid) {
-						dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Chosen goroutine is ready to be run") })
-					} 
-
-					/* Attempt 2 read everything off of allgs */
-					/* This did not seem to work, somewhere I was
-					* not correctly scheduling threads.
-					 */
-
-					/* In this loop we are almost guarenteed to
-					* find the g we are looking for but scheduling
-					* it can kill us. Use this loop to try and
-					* reason about dead threads, if they are dead
-					* then we can bork*/
-					if gp.gopc != procchan[DPid].RunningRoutine.Gpc || gp.goid != int64(procchan[DPid].RunningRoutine.Gid) {
-						dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Unable to schedule g, triaging root cause") })
-
-						//Find g by looking in allgs should always
-						//work
-						for i := 0; i < len(allgs); i++ {
-							if allgs[i].gopc == procchan[DPid].RunningRoutine.Gpc && allgs[i].goid == int64(procchan[DPid].RunningRoutine.Gid) {
-								globrunqput(gp)
-								dprint(dara.DEBUG, func() {println("Found the g we were looking for")})
-								gp = allgs[i]
-								break
-							}
-						}
-						//If g is not in allg something is terribly
-						//wrong
-						if gp.gopc != procchan[DPid].RunningRoutine.Gpc || gp.goid != int64(procchan[DPid].RunningRoutine.Gid){
-							dprint(dara.FATAL, func() {
-								println("[GoRuntime]getScheduledGp : Mismatched Gopc", gp.gopc, procchan[DPid].RunningRoutine.Gpc, "with goids", gp.goid, procchan[DPid].RunningRoutine.Gid)
-							})
-						}
-						/* Old Check for routine count. We don't need it.
-						if gp.gopc != procchan[DPid].RunningRoutine.Gpc {
-							dprint(dara.FATAL, func() {
-								println("[GoRuntime]getScheduledGp : Scheduled G nowhere to be found. Are the recorded and replayed systems the same? Consider rebuilding and trying again")
-							})
-						} */
-
-						//BORK if Dead
-						if readgstatus(gp) == _Gdead {
-							dprint(dara.WARN, func() { println("[GoRuntime]getScheduledGp : Scheduled G is dead, replay may be invalid: Skipping G") })
-							//if the thread is allready dead I'm not
-							//sure what the best course of action is.
-							//It cannot possibly be scheduled. I think
-							//that pretending that the thread was run
-							//might be the right course for now.
-							gp = origgp
-							ResetProcArr(gp)
-							globrunqput(gp)
-							//Set running to true so that the schedule
-							//moves forward
-							Running = true
-							goto top
-						}
-
-						//This has the potential to kill
-						if readgstatus(gp) == _Gwaiting {
-							//stopm()
-							//if gp.goid == 1 {
-							//	ready(gp, 0, true)
-							//}
-
-							dprint(dara.INFO, func() {
-								println("[GoRuntime]getScheduledGp : Scheduled Goroutine (", gp.goid, ") currently waiting. Busywaiting on routine")
-							})
-							//Don't bother replaying garbage collection, or
-							//finalization if they can not be found
-							if procchan[DPid].RunningRoutine.Gid == 1 ||
-								procchan[DPid].RunningRoutine.Gid == 2 ||
-								procchan[DPid].RunningRoutine.Gid == 3 {
-								dprint(dara.DEBUG, func() {
-									println("[GoRuntime]getScheduledGp : Skipping out on the garbage collector and finalizer threads")
-								})
-								ready(gp, 0, true)
-								goto replay
-							}
-							// The scheduled goroutine is most likely sleeping at this point. Try to fastforward the time of this goroutine :)
-							dprint(dara.INFO, func() {
-								println("[GoRuntime]getScheduledGp : Adding the waiting goroutine", gp.goid, "on the ready queue")
-							})
-							ready(gp, 0, true)
-							//retry
-							gp = origgp
-							ResetProcArr(gp)
-							globrunqput(gp)
-							//Here is the potential to die BUG
-							dstopm()
-							goto replay
-						}
-
+					//Don't bother replaying garbage collection, or
+					//finalization if they can not be found
+					if dproc.RunningRoutine.Gid == 1 ||
+						dproc.RunningRoutine.Gid == 2 ||
+						dproc.RunningRoutine.Gid == 3 {
 						dprint(dara.DEBUG, func() {
-							println("[GoRuntime]getScheduledGp : Trying to Run (", DPid, ",", procchan[DPid].Run, ",", procchan[DPid].RunningRoutine.Gpc, ")")
+							println("[GoRuntime]getScheduledGp : Skipping out on the garbage collector and finalizer threads")
 						})
-					} // If the g was not found end
+						daraReadyWaiting(gp)
+						goto replay
+					}
+					// The scheduled goroutine is most likely sleeping at this point. Try to fastforward the time of this goroutine :)
+					dprint(dara.INFO, func() {
+						println("[GoRuntime]getScheduledGp : Adding the waiting goroutine", gp.goid, "on the ready queue")
+					})
+					daraReadyWaiting(gp)
+					//retry
+					gp = origgp
+					ResetProcArr(gp)
+					globrunqput(gp)
+					//Here is the potential to die BUG
+					dstopm()
+					goto replay
+				}
 
-					dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Running (", DPid, ",", gp.goid, ",", gp.gopc, ")") })
-					dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : gp status: ", dgStatusStrings[readgstatus(gp)]) })
+				dprint(dara.DEBUG, func() {
+					println("[GoRuntime]getScheduledGp : Trying to Run (", DPid, ",", dproc.RunningRoutine.Gid, ",", dproc.RunningRoutine.Gpc, ")")
+				})
+			} // If the g was not found end
 
-					Running = true
-					dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Running", gp.goid) })
-					LogSchedulingEvent(procchan[DPid].Routines[int(gp.goid)])
-					return gp
-				}
-				atomic.Store(&(procchan[DPid].Lock), dara.UNLOCKED) //unlock using scheduler api
-			}
+			dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : Running (", DPid, ",", gp.goid, ",", gp.gopc, ")") })
+			dprint(dara.DEBUG, func() { println("[GoRuntime]getScheduledGp : gp status: ", dgStatusStrings[readgstatus(gp)]) })
+
+			Running = true
+			dprint(dara.INFO, func() { println("[GoRuntime]getScheduledGp : Running", gp.goid) })
+			LogSchedulingEvent(dproc.Routines[int(gp.goid)])
+			return gp
 		}
 	}
 	return gp
 }
 
+//daraReply answers the current command. The global scheduler chooses
+//what to run next from the goroutine statuses in Routines, which are
+//brought up to date first: firing a timer, delivering a message or
+//polling the network may have readied goroutines.
+func daraReply(r dara.Reply) {
+	daraSyncRoutines()
+	dproc.Reply = r
+	dproc.ReplySeq = dproc.CmdSeq
+	atomic.Xadd(&dproc.ReplySignal, 1)
+}
+
+//daraSyncRoutines copies the status of every goroutine into Routines
+func daraSyncRoutines() {
+	for i := 0; i < len(allgs); i++ {
+		dproc.Routines[allgs[i].goid].Status = readgstatus(allgs[i])
+	}
+}
+
+//daraAskSelect hands the choice among the ready cases in dproc.Select
+//to the global scheduler and waits for it to answer with
+//CMD_CHOOSE_SELECT. It returns the index of the chosen case with the
+//lock held again.
+func daraAskSelect() int {
+	dproc.State = dara.STATE_SELECTING
+	daraReply(dara.REPLY_SELECT)
+	daraReleaseLock()
+	for {
+		signal := atomic.Load(&dproc.CmdSignal)
+		daraLock(&dproc.Lock)
+		HasDaraLock = true
+		if dproc.CmdSeq == dproc.ReplySeq {
+			daraReleaseLock()
+			daraWait(&dproc.CmdSignal, signal)
+			continue
+		}
+		cmd := dproc.Cmd
+		next, ok := dara.NextState(dproc.State, cmd)
+		if !ok {
+			dprint(dara.WARN, func() {
+				println("[GoRuntime]daraAskSelect : Illegal command", cmd.String(), "( seq", dproc.CmdSeq, ") in state", dproc.State.String())
+			})
+			daraReply(dara.REPLY_ILLEGAL)
+			daraReleaseLock()
+			continue
+		}
+		dproc.State = next
+		if cmd == dara.CMD_SHUTDOWN {
+			dprint(dara.INFO, func() { println("[GoRuntime]daraAskSelect : Shutting down on request of the Global Scheduler") })
+			endDara()
+			exit(0)
+		}
+		dprint(dara.DEBUG, func() { println("[GoRuntime]daraAskSelect : Global scheduler chose case", dproc.CmdArg) })
+		return int(dproc.CmdArg)
+	}
+}
+
+//daraIdle hands control to the global scheduler while no goroutine can
+//run but a timer can still ready one, messages are held for the global
+//scheduler to deliver, or goroutines wait on the virtual network. It
+//answers the command that let the last goroutine run, and carries out
+//the commands that follow until a goroutine can run again, or the last
+//held message has been delivered. CMD_FIRE_TIMER fires a timer,
+//CMD_DELIVER_MESSAGE delivers a message and CMD_POLL_NET, like
+//CMD_RECORD_FREELY, polls the virtual network. A command to run a
+//goroutine moves virtual time on, which may make a timer goroutine due,
+//and readies the goroutine it names if that one is waiting, like
+//getScheduledGp does. If that leaves a goroutine to run the command is
+//left to getScheduledGp, otherwise it is answered with REPLY_RAN
+//straight away.
+//
+//go:yeswritebarrierrec
+func daraIdle() {
+	if Running {
+		if dproc.State == dara.STATE_INIT {
+			daraReply(dara.REPLY_READY)
+		} else {
+			daraReply(dara.REPLY_RAN)
+		}
+		Running = false
+	}
+	if HasDaraLock {
+		daraReleaseLock()
+	}
+	for {
+		signal := atomic.Load(&dproc.CmdSignal)
+		daraLock(&dproc.Lock)
+		HasDaraLock = true
+		if dproc.CmdSeq == dproc.ReplySeq {
+			daraReleaseLock()
+			daraWait(&dproc.CmdSignal, signal)
+			continue
+		}
+		cmd := dproc.Cmd
+		next, ok := dara.NextState(dproc.State, cmd)
+		if !ok {
+			dprint(dara.WARN, func() {
+				println("[GoRuntime]daraIdle : Illegal command", cmd.String(), "( seq", dproc.CmdSeq, ") in state", dproc.State.String())
+			})
+			daraReply(dara.REPLY_ILLEGAL)
+			daraReleaseLock()
+			continue
+		}
+		daraTimeCheck()
+		switch cmd {
+		case dara.CMD_FIRE_TIMER:
+			dproc.State = next
+			dprint(dara.INFO, func() { println("[GoRuntime]daraIdle : Firing off timer:", dproc.CmdArg) })
+			daraExecuteTimer(dproc.CmdArg)
+			daraReply(dara.REPLY_TIMER_FIRED)
+			daraReleaseLock()
+			if daraRunnable() {
+				return
+			}
+			continue
+		case dara.CMD_DELIVER_MESSAGE:
+			dproc.State = next
+			dprint(dara.INFO, func() { println("[GoRuntime]daraIdle : Delivering message:", dproc.CmdArg) })
+			if !daraDeliver(dproc.CmdArg) {
+				daraReply(dara.REPLY_ILLEGAL)
+				daraReleaseLock()
+				continue
+			}
+			if len(daraOutbox) == 0 && !daraRunnable() {
+				daraNetWait()
+			}
+			daraReply(dara.REPLY_DELIVERED)
+			daraReleaseLock()
+			if daraRunnable() || len(daraOutbox) == 0 {
+				return
+			}
+			continue
+		case dara.CMD_POLL_NET:
+			dproc.State = next
+			dprint(dara.DEBUG, func() { println("[GoRuntime]daraIdle : Polling the virtual network") })
+			daraVNetPoll()
+			daraReply(dara.REPLY_POLLED)
+			daraReleaseLock()
+			if daraRunnable() {
+				return
+			}
+			continue
+		case dara.CMD_RECORD_FREELY:
+			daraVNetPoll()
+		case dara.CMD_RUN_GOROUTINE:
+			if gp := daraRoutineOf(dproc.RunningRoutine.LogicalID); gp != nil && readgstatus(gp) == _Gwaiting {
+				daraReadyWaiting(gp)
+			}
+		case dara.CMD_END_REPLAY:
+			dproc.State = next
+			daraDetach()
+			return
+		case dara.CMD_SHUTDOWN:
+			dprint(dara.INFO, func() { println("[GoRuntime]daraIdle : Shutting down on request of the Global Scheduler") })
+			dproc.State = next
+			endDara()
+			exit(0)
+		}
+		if daraRunnable() {
+			daraReleaseLock()
+			return
+		}
+		dprint(dara.DEBUG, func() { println("[GoRuntime]daraIdle : Nothing to run for", cmd.String()) })
+		dproc.State = next
+		daraReply(dara.REPLY_RAN)
+		daraReleaseLock()
+	}
+}
+
+//daraReadyWaiting readies gp, which the global scheduler wants to run
+//while it waits. A goroutine waiting on the network is left for netpoll
+//to wake up, readying it by hand would corrupt its pollDesc, so the
+//network is waited on until it does. A goroutine asleep or blocked on
+//channels is woken up by the timer it waits for, if there is one,
+//readying it by hand would leave its timer armed or its sudogs queued
+//for a later send to hand them out again.
+func daraReadyWaiting(gp *g) {
+	if gp.waitreason == "sleep" || gp.waiting != nil {
+		if id, ok := daraTimerOf(gp); ok {
+			daraExecuteTimer(id)
+		}
+		return
+	}
+	if gp.waitreason != "IO wait" {
+		ready(gp, 0, true)
+		return
+	}
+	for readgstatus(gp) == _Gwaiting {
+		if list := netpoll(false); list != nil {
+			injectglist(list)
+			continue
+		}
+		daraNetWait()
+		osyield()
+	}
+}
+
+//daraRunnable reports whether a goroutine is queued to run
+func daraRunnable() bool {
+	return !runqempty(getg().m.p.ptr()) || sched.runqsize > 0
+}
+
+//daraRoutineOf returns the live goroutine with the logical ID lid, or
+//nil if there is none
+func daraRoutineOf(lid uint64) *g {
+	for i := 0; i < len(allgs); i++ {
+		if allgs[i].daralid == lid && readgstatus(allgs[i]) != _Gdead {
+			return allgs[i]
+		}
+	}
+	return nil
+}
+
+//daraReleaseLock hands the shared memory back to the global scheduler
+//and wakes it up in case it is waiting for a reply
+func daraReleaseLock() {
+	LogCoverage()
+	daraUnlock(&dproc.Lock)
+	HasDaraLock = false
+	daraWake(&dproc.ReplySignal)
+}
+
+//daraDetach hands the process back to the go scheduler once the global
+//scheduler has ended the replay. Nothing is reported from here on.
+func daraDetach() {
+	//Nobody delivers the held messages from now on
+	daraFlush(0, true)
+	LogEndEvent()
+	daraReply(dara.REPLY_DETACHED)
+	daraTimeDetach()
+	DaraInitialised = false
+	Running = false
+	Record, Replay, Explore, FastReplay = false, false, false, false
+	daraReleaseLock()
+}
+
 // Function written for Dara.
 func findallrunnableprocs(gp *g) *g {
 
@@ -3590,8 +4097,7 @@ gather:
 		//print(ProcArr[i].gopc)
 		//print(")\n")
 
-		if ProcArr[i].gopc == procchan[DPid].RunningRoutine.Gpc &&
-			procchan[DPid].Routines[ProcArr[i].goid].RoutineCount == procchan[DPid].RunningRoutine.RoutineCount {
+		if ProcArr[i].daralid == dproc.RunningRoutine.LogicalID {
 			dprint(dara.DEBUG, func() { println("[GoRuntime]finallrunnableprocs : Found the goroutine") })
 			globrunqput(gp)
 			gp = ProcArr[i]
@@ -3616,8 +4122,7 @@ func CheckAndResetProcArr(gp *g) (*g, bool) {
 		dprint(dara.DEBUG, func() {
 			print("[GoRuntime]CheckAndResetProcArr : Inspecting (", DPid, ",", ProcArr[i].goid, ",", ProcArr[i].gopc, ")\n")
 		})
-		if ProcArr[i].gopc == procchan[DPid].RunningRoutine.Gpc &&
-			procchan[DPid].Routines[ProcArr[i].goid].RoutineCount == procchan[DPid].RunningRoutine.RoutineCount {
+		if ProcArr[i].daralid == dproc.RunningRoutine.LogicalID {
 			dprint(dara.DEBUG, func() {
 				print("[GoRuntime]CheckAndResetProcArr : Specified Routine Found(", DPid, ",", ProcArr[i].goid, ",", ProcArr[i].gopc, ")\n")
 			})
@@ -3665,8 +4170,9 @@ func ResetProcArr(gp *g) {
 
 // Variables for shared memory
 var (
-	smptr    unsafe.Pointer //unsafe shared memory pointer
-	procchan *[dara.CHANNELS]dara.DaraProc
+	smptr   unsafe.Pointer    //unsafe shared memory pointer
+	dheader dara.SharedHeader // Copy of the header the global scheduler wrote at the start of shared memory
+	dproc   *dara.DaraProc    // This process's DaraProc, set from DARAPID once shared memory is mapped
 )
 
 const PROC_SIZE = 4096
@@ -3685,13 +4191,27 @@ var (
 	Explore         bool = false // Specifies if we are in the exploration mode
 	FastReplay      bool = false // Specifies if we are in the fast replay mode
 	DaraInitialised bool = false // Is Dara Initialised?
+	DaraSeed        int64 // The DARA_SEED of the process, seeds fastrand, the hashes of maps and math/rand
+	SyscallCalls    map[int]int // Mapping between a DSYS syscall number and how many calls of it the process has made, used to find the calls to inject faults into
+	SyscallPoints   int // The classes of syscalls which are scheduling points, from DARA_SYSCALL_POINTS
+	HoldMessages    bool // Hold the messages written to other Dara processes for the global scheduler to deliver, from DARA_HOLD_MESSAGES
+	VirtualNet      bool // Connect to other Dara processes over the virtual network in shared memory, from DARA_VIRTUAL_NET
 	HasDaraLock     bool = false // Do we currently have the lock to shared memory?
 	CoverageInfo    map[string]uint64 // Mapping between unique block ID and the counter of how many times the block was hit
 	ChanSendInfo    map[unsafe.Pointer]int // Mapping between address of a channel and the number of successful sends on the channel
 	ChanRecvInfo    map[unsafe.Pointer]int // Mapping between address of a channel and the number of successful receives on the channel
-	TimerInfo       map[int64]*timer
+	ChanMakeInfo    map[uintptr]int // Mapping between a pc and the number of channels made at the pc
+	ChanClockInfo   map[unsafe.Pointer][]dara.VectorClock // Mapping between address of a buffered channel and the vector clocks of its buffer slots
+	SyncClockInfo   map[unsafe.Pointer]*dara.VectorClock // Mapping between address of a mutex or WaitGroup and its vector clock
+	SystemSpawns    uint64 // Number of runtime goroutines launched since Dara was initialised, used for their logical IDs
+	TimerSpawns     uint64 // Number of timer goroutines launched since Dara was initialised, used for their logical IDs
+	TimerInfo       map[int64]*timer // Mapping between the ID of a timer and the timer, for every timer that is armed
+	TimerIDs        map[*timer]int64 // Mapping between an armed timer and its ID in TimerInfo
+	daraFiring      bool // Is a timer being fired with daraRunTimer?
+	daraReadied     guintptr // The goroutine the timer being fired readied
 	TimerCount      int64 = 0 // Current count of timers. This is montonously increasing and serves as an ID for the timer.
 	Microbenchmark  bool = false // Are we collecting microbenchmarking as part of this run
+	handoffStart    int64 // When the lock was last handed to the global scheduler, for microbenchmarking
 	Nanobenchmark   bool = false // Are we doing nanobenchmarking. Global Scheduler will not be contacted at all but writes to shared memory will be recorded. DO NOT USE. CURRENTLY DOES NOT WORK CORRECTLY.
 )
 
@@ -3726,12 +4246,6 @@ func park_m(gp *g) {
 	casgstatus(gp, _Grunning, _Gwaiting)
 	dropg()
 
-	//Dara's way of handling sleeping threads during replay
-	//Instead of making the threads wait, put them on the ready queue instead
-	if (Replay || Explore) && gp.waitreason == "sleep" {
-		ready(gp, 0, true)
-	}
-
 	if _g_.m.waitunlockf != nil {
 		fn := *(*func(*g, unsafe.Pointer) bool)(unsafe.Pointer(&_g_.m.waitunlockf))
 		ok := fn(gp, _g_.m.waitlock)
@@ -4469,7 +4983,6 @@ tryagain:
 		// it.
 		if writeBarrier.needed && !_g_.m.curg.gcscandone {
 			f := findfunc(fn.fn)
-			print(funcname(f) + "\n")
 			stkmap := (*stackmap)(funcdata(f, _FUNCDATA_ArgsPointerMaps))
 			// We're in the prologue, so it's always stack map index 0.
 			bv := stackmapdata(stkmap, 0)
@@ -4511,6 +5024,7 @@ tryagain:
 		traceGoCreate(newg, newg.startpc)
 	}
 	runqput(_p_, newg, true)
+	newg.daraspawns = 0
 
 	//DARA
 	//this is the trick addition of goroutines part
@@ -4534,24 +5048,41 @@ tryagain:
 		f := findfunc(fn.fn)
 		name := funcname(f)
 		//set everything including status
-		procchan[DPid].Routines[newg.goid].Status = readgstatus(newg)
-		procchan[DPid].Routines[newg.goid].Gid = int(newg.goid)
-		procchan[DPid].Routines[newg.goid].Gpc = newg.gopc
-		for i := 0; i < len(procchan[DPid].Routines[newg.goid].FuncInfo) && i < len(name); i++ {
-			procchan[DPid].Routines[newg.goid].FuncInfo[i] = name[i]
-			//print(string(procchan[DPid].Routines[newg.goid].FuncInfo[i]))
+		dproc.Routines[newg.goid].Status = readgstatus(newg)
+		dproc.Routines[newg.goid].Gid = int(newg.goid)
+		dproc.Routines[newg.goid].Gpc = newg.gopc
+		for i := 0; i < len(dproc.Routines[newg.goid].FuncInfo) && i < len(name); i++ {
+			dproc.Routines[newg.goid].FuncInfo[i] = name[i]
+			//print(string(dproc.Routines[newg.goid].FuncInfo[i]))
 		}
 		dprint(dara.INFO, func() { println("[GoRuntime]Launching new thread at func:", name, "with goid", newg.goid) })
-		//print("-BYTE-",string(procchan[DPid].Routines[newg.goid].FuncInfo[:64]),"\n")
+		//print("-BYTE-",string(dproc.Routines[newg.goid].FuncInfo[:64]),"\n")
 
 		var duplicateCounter = 0
-		for i := 0; i < len(procchan[DPid].Routines); i++ {
-			if procchan[DPid].Routines[i].Gpc > 0 && procchan[DPid].Routines[i].Gpc == newg.gopc {
+		for i := 0; i < len(dproc.Routines); i++ {
+			if dproc.Routines[i].Gpc > 0 && dproc.Routines[i].Gpc == newg.gopc {
 				duplicateCounter++
 			}
 		}
-		procchan[DPid].Routines[newg.goid].RoutineCount = duplicateCounter
-		LogThreadCreation(procchan[DPid].Routines[newg.goid])
+		dproc.Routines[newg.goid].RoutineCount = duplicateCounter
+		//Neither goids nor (Gpc, RoutineCount) survive a change in the
+		//number of goroutines started elsewhere in the program, so
+		//replay matches goroutines by a logical ID derived from their
+		//parent instead. Goroutines of the runtime, and those started
+		//from the system stack, are children of SYSTEMID.
+		if parent := _g_.m.curg; parent != nil && !isSystemGoroutine(newg) {
+			newg.daralid = dara.ChildID(parent.daralid, parent.daraspawns)
+			parent.daraspawns++
+		} else if newg.startpc == funcPC(timerproc) {
+			newg.daralid = dara.ChildID(dara.TIMERID, TimerSpawns)
+			TimerSpawns++
+		} else {
+			newg.daralid = dara.ChildID(dara.SYSTEMID, SystemSpawns)
+			SystemSpawns++
+		}
+		dproc.Routines[newg.goid].LogicalID = newg.daralid
+		daraClockFork(_g_.m.curg, newg)
+		LogThreadCreation(dproc.Routines[newg.goid])
 	}
 	//\DARA
 
@@ -4562,10 +5093,14 @@ tryagain:
 	if _g_.m.locks == 0 && _g_.preempt { // restore the preemption request in case we've cleared it in newstack
 		_g_.stackguard0 = stackPreempt
 	}
-	if DaraInitialised && (Explore || Replay) {
+	if DaraInitialised && (Explore || Replay || Record) {
+		// Record yields here too so that a recorded schedule has the
+		// same scheduling points as its replay.
 		// To explore different path orderings we need to do schedule a different thread
 		// Only do this if the goroutine is not with id 1,2,and 3 which are the system goroutines
-		if newg.goid != 1 && newg.goid != 2 && newg.goid != 3 {
+		// A creator that holds runtime locks, like addtimerLocked
+		// starting a timer goroutine, can not be switched away from.
+		if newg.goid != 1 && newg.goid != 2 && newg.goid != 3 && _g_.m.locks == 0 {
 			// Change the status of the running 
 			var gp *g
 			for i := 0; i < len(allgs); i++ {
@@ -5406,7 +5941,7 @@ func checkdead() {
 	getg().m.throwing = -1 // do not dump full stacks
 	if DaraInitialised {
 		dprint(dara.INFO, func() { println("[GoRuntime]checkdead : Found deadlock") })
-		//LogCrash(procchan[DPid].Routines[int(getg().m.curg.goid)])
+		//LogCrash(dproc.Routines[int(getg().m.curg.goid)])
 		//endDara()
 	}
 	throw("all goroutines are asleep - deadlock!")
@@ -5551,6 +6086,15 @@ type sysmontick struct {
 const forcePreemptNS = 10 * 1000 * 1000 // 10ms
 
 func retake(now int64) uint32 {
+	//DARA: goroutines only switch at the scheduling points of the
+	//global scheduler. Preempting a goroutine that ran for too long, or
+	//handing the P of a goroutine in a syscall to another M, would add
+	//points that depend on timing and that a replay does not have. A
+	//goroutine blocked in a syscall holds up the process until it
+	//returns.
+	if DaraInitialised && !Nanobenchmark {
+		return 0
+	}
 	n := 0
 	// Prevent allp slice changes. This lock will be completely
 	// uncontended unless we're already stopping the world.
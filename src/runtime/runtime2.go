@@ -397,6 +397,11 @@ type g struct {
 	// and check for debt in the malloc hot path. The assist ratio
 	// determines how this corresponds to scan work debt.
 	gcAssistBytes int64
+
+	//Dara logical identity, see dara.ChildID. daraspawns counts the
+	//goroutines this one has spawned.
+	daralid    uint64
+	daraspawns uint64
 }
 
 type m struct {
@@ -7,6 +7,8 @@ package runtime
 // This file contains the implementation of Go select statements.
 
 import (
+	"dara"
+	"runtime/internal/atomic"
 	"runtime/internal/sys"
 	"unsafe"
 )
@@ -284,6 +286,11 @@ func selectgo(sel *hselect) int {
 	// lock all the channels involved in the select
 	sellock(scases, lockorder)
 
+	//DARA Instrumentation
+	if DaraInitialised && !Nanobenchmark && sel.ncase > 1 {
+		daraSelect(scases, pollorder, lockorder)
+	}
+
 	var (
 		gp     *g
 		sg     *sudog
@@ -293,6 +300,12 @@ func selectgo(sel *hselect) int {
 		sgnext *sudog
 		qp     unsafe.Pointer
 		nextp  **sudog
+
+		// What daraChanEvent reports about the chosen case
+		daraN       uint
+		daraPartner int64
+		daraClosed  bool
+		daraLogged  bool
 	)
 
 loop:
@@ -424,6 +437,8 @@ loop:
 			// sg has already been dequeued by the G that woke us up.
 			casi = int(casei)
 			cas = k
+			//The G that woke us up has logged the case for us
+			daraLogged = true
 		} else {
 			c = k.c
 			if k.kind == caseSend {
@@ -491,6 +506,9 @@ bufrecv:
 	if msanenabled && cas.elem != nil {
 		msanwrite(cas.elem, c.elemtype.size)
 	}
+	if DaraInitialised {
+		daraClockSlot(c, c.recvx, getg())
+	}
 	if cas.receivedp != nil {
 		*cas.receivedp = true
 	}
@@ -504,6 +522,7 @@ bufrecv:
 		c.recvx = 0
 	}
 	c.qcount--
+	daraN = c.qcount
 	selunlock(scases, lockorder)
 	goto retc
 
@@ -517,17 +536,22 @@ bufsend:
 	if msanenabled {
 		msanread(cas.elem, c.elemtype.size)
 	}
+	if DaraInitialised {
+		daraClockSlot(c, c.sendx, getg())
+	}
 	typedmemmove(c.elemtype, chanbuf(c, c.sendx), cas.elem)
 	c.sendx++
 	if c.sendx == c.dataqsiz {
 		c.sendx = 0
 	}
 	c.qcount++
+	daraN = c.qcount
 	selunlock(scases, lockorder)
 	goto retc
 
 recv:
 	// can receive from sleeping sender (sg)
+	daraN, daraPartner = c.qcount, sg.g.goid
 	recv(c, sg, cas.elem, func() { selunlock(scases, lockorder) }, 2)
 	if debugSelect {
 		print("syncrecv: sel=", sel, " c=", c, "\n")
@@ -539,6 +563,7 @@ recv:
 
 rclose:
 	// read at end of closed channel
+	daraClosed = true
 	selunlock(scases, lockorder)
 	if cas.receivedp != nil {
 		*cas.receivedp = false
@@ -559,6 +584,7 @@ send:
 	if msanenabled {
 		msanread(cas.elem, c.elemtype.size)
 	}
+	daraN, daraPartner = c.qcount, sg.g.goid
 	send(c, sg, cas.elem, func() { selunlock(scases, lockorder) }, 2)
 	if debugSelect {
 		print("syncsend: sel=", sel, " c=", c, "\n")
@@ -569,6 +595,18 @@ retc:
 	if cas.releasetime > 0 {
 		blockevent(cas.releasetime-t0, 1)
 	}
+	//DARA Instrumentation
+	if DaraInitialised && !daraLogged {
+		typ := dara.SEND_EVENT
+		if cas.kind == caseRecv {
+			typ = dara.REC_EVENT
+		}
+		if daraPartner != 0 {
+			daraChanHandoff(typ, cas.c, daraN, daraPartner)
+		} else {
+			daraChanEvent(typ, cas.c, daraN, 0, daraClosed)
+		}
+	}
 	return casi
 
 sclose:
@@ -659,3 +697,106 @@ func (q *waitq) dequeueSudoG(sgp *sudog) {
 		q.last = nil
 	}
 }
+
+//daraSelect decides which of the ready cases of a select is taken.
+//With more than one ready case the global scheduler chooses while
+//replaying, otherwise the case selectgo would take anyway is kept. The
+//chosen case is moved to the front of pollorder, so that pass 1 of
+//selectgo takes it, and logged with a SELECT_EVENT. All the channels
+//of the select are locked, they are unlocked while the global
+//scheduler chooses. A select which blocks after all is logged with
+//the choice of the global scheduler.
+func daraSelect(scases []scase, pollorder []uint16, lockorder []uint16) {
+	if FastReplay {
+		return
+	}
+	info := daraSelectReady(scases, pollorder)
+	if info.NumReady < 2 {
+		return
+	}
+	if dproc.State == dara.STATE_REPLAYING {
+		dproc.Select = info
+		selunlock(scases, lockorder)
+		chosen := daraAskSelect()
+		sellock(scases, lockorder)
+		//Revalidate the cases, the channels were not locked while
+		//the global scheduler chose. The choice is logged whatever
+		//became of the cases, with the case that is taken.
+		info = daraSelectReady(scases, pollorder)
+		switch {
+		case daraSelectListed(&info, chosen):
+			info.Chosen = chosen
+		case info.NumReady == 0:
+			dprint(dara.WARN, func() {
+				println("[GoRuntime]daraSelect : Global scheduler chose case", chosen, "but no case is ready any more, blocking")
+			})
+			info.Chosen = chosen
+		default:
+			dprint(dara.WARN, func() {
+				println("[GoRuntime]daraSelect : Global scheduler chose case", chosen, "which is not ready, taking case", info.Chosen)
+			})
+		}
+	}
+	for i, o := range pollorder {
+		if int(o) == info.Chosen {
+			pollorder[0], pollorder[i] = pollorder[i], pollorder[0]
+			break
+		}
+	}
+	LogSelectEvent(&info)
+}
+
+//daraSelectReady lists the ready cases of a select, with the first of
+//them in pollorder chosen
+func daraSelectReady(scases []scase, pollorder []uint16) dara.EncSelectInfo {
+	var info dara.EncSelectInfo
+	for i := range scases {
+		if info.NumReady == dara.MAXSELECTCASES {
+			break
+		}
+		if daraCaseReady(&scases[i]) {
+			info.Ready[info.NumReady] = dara.SelectCase{Index: i, PC: scases[i].pc}
+			info.NumReady++
+		}
+	}
+	for _, o := range pollorder {
+		if daraSelectListed(&info, int(o)) {
+			info.Chosen = int(o)
+			break
+		}
+	}
+	return info
+}
+
+//daraCaseReady reports whether pass 1 of selectgo could take cas
+func daraCaseReady(cas *scase) bool {
+	c := cas.c
+	switch cas.kind {
+	case caseRecv:
+		return daraWaitqReady(&c.sendq) || c.qcount > 0 || c.closed != 0
+	case caseSend:
+		return c.closed != 0 || daraWaitqReady(&c.recvq) || c.qcount < c.dataqsiz
+	}
+	return false
+}
+
+//daraWaitqReady reports whether q holds a goroutine dequeue would
+//return, a select which has already been woken up by another channel
+//does not count
+func daraWaitqReady(q *waitq) bool {
+	for sgp := q.first; sgp != nil; sgp = sgp.next {
+		if !sgp.isSelect || atomic.Load(&sgp.g.selectDone) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func daraSelectListed(info *dara.EncSelectInfo, casi int) bool {
+	for i := 0; i < info.NumReady; i++ {
+		if info.Ready[i].Index == casi {
+			return true
+		}
+	}
+	return false
+}
@@ -102,21 +102,23 @@ func timeSleep(ns int64) {
 		gp.timer = t
 	}
 	*t = timer{}
+	t.when = daraNanotime() + ns
+	t.f = goroutineReady
+	t.arg = gp
     //Dara injection
-    if Replay || Explore {
+    if DaraInitialised {
+        //The global scheduler wakes the goroutine up by firing the timer
+        //at virtual time t.when
+        daraAddTimer(t)
+    }
+    if Explore || Replay {
         dprint(dara.INFO, func () {println("[GoRoutine]timeSleep : Goroutine here for nap time")})
-        //Don't install the timer in replay but obtain the lock :)
+        //Don't install the timer in exploration and replay but obtain the lock :)
         tb := t.assignBucket()
         lock(&tb.lock)
         goparkunlock(&tb.lock, "sleep", traceEvGoSleep, 2)
         return
     }
-    if Replay || Explore{
-        dprint(dara.WARN, func () {println("[GoRuntime]timeSleep : We shouldn't be here wtf")})
-    }
-	t.when = nanotime() + ns
-	t.f = goroutineReady
-	t.arg = gp
 	tb := t.assignBucket()
 	lock(&tb.lock)
 	tb.addtimerLocked(t)
@@ -147,21 +149,112 @@ func goroutineReady(arg interface{}, seq uintptr) {
 	goready(arg.(*g), 0)
 }
 
+// Add the timer to a list of timers which is exposed to the global
+// scheduler and have it choose firing off the timer as one of its
+// actions.
+func daraAddTimer(t *timer) {
+	if id, ok := TimerIDs[t]; ok {
+		// Armed again without being stopped, only its due time changed
+		LogTimerEvent(id, t, dara.TIMER_ARMED)
+		return
+	}
+	TimerCount += 1
+	TimerInfo[TimerCount] = t
+	TimerIDs[t] = TimerCount
+	LogTimerEvent(TimerCount, t, dara.TIMER_ARMED)
+}
+
+// daraForgetTimer takes t out of the list of timers exposed to the
+// global scheduler and returns its ID, or false if it was not in it.
+func daraForgetTimer(t *timer) (int64, bool) {
+	id, ok := TimerIDs[t]
+	if ok {
+		delete(TimerIDs, t)
+		delete(TimerInfo, id)
+	}
+	return id, ok
+}
+
+// daraCancelTimer forgets the stopped timer t and tells the global
+// scheduler that it can no longer be fired. It reports whether t was
+// armed.
+func daraCancelTimer(t *timer) bool {
+	id, ok := daraForgetTimer(t)
+	if ok {
+		LogTimerEvent(id, t, dara.TIMER_CANCELLED)
+	}
+	return ok
+}
+
+// daraTimerOf returns the ID of the armed timer that wakes gp up: the
+// timer of its sleep, or the first timer sending on a channel gp is
+// blocked on, like the channel of a time.Timer.
+func daraTimerOf(gp *g) (int64, bool) {
+	if gp.waitreason == "sleep" && gp.timer != nil {
+		id, ok := TimerIDs[gp.timer]
+		return id, ok
+	}
+	var found int64
+	for id, t := range TimerInfo {
+		if found != 0 && id > found {
+			continue
+		}
+		for sg := gp.waiting; sg != nil; sg = sg.waitlink {
+			if efaceOf(&t.arg).data == unsafe.Pointer(sg.c) {
+				found = id
+				break
+			}
+		}
+	}
+	return found, found != 0
+}
+
+// daraRunTimer runs the function f of the timer t with the given id,
+// which was due at due, and logs the firing along with the goroutine f
+// readied. A periodic timer stays armed with its next due time, any
+// other is forgotten.
+func daraRunTimer(id int64, t *timer, due int64, f func(interface{}, uintptr), arg interface{}, seq uintptr) {
+	if t.period <= 0 {
+		daraForgetTimer(t)
+	}
+	daraFiring, daraReadied = true, 0
+	f(arg, seq)
+	daraFiring = false
+	LogTimerFired(id, t, due, daraReadied.ptr())
+	daraReadied = 0
+	if t.period > 0 {
+		LogTimerEvent(id, t, dara.TIMER_ARMED)
+	}
+}
+
+// daraFireTimer prepares t to be run with daraRunTimer on the command
+// of the global scheduler, which has already moved virtual time up to
+// t.when. It does what timerproc does to a timer it fires: a periodic
+// timer is due again one period later, any other is taken out of the
+// timer heap if it was installed.
+func daraFireTimer(t *timer) {
+	inheap := delheaptimer(t)
+	if t.period > 0 {
+		if now := daraNanotime(); t.when <= now {
+			t.when += t.period * (1 + (now-t.when)/t.period)
+		}
+		if inheap {
+			tb := t.assignBucket()
+			lock(&tb.lock)
+			tb.addtimerLocked(t)
+			unlock(&tb.lock)
+		}
+	}
+}
+
 func addtimer(t *timer) {
 	if DaraInitialised {
-		// Add the timer to a list of timers which is exposed
-		// to the global scheduler and have it choose firing off the timer as one of its actions.
-		TimerCount += 1
-		TimerInfo[TimerCount] = t
-		LogTimerEvent(t)
-		println(t.when)
-	}
-	if Explore {
-		// Only prevent a timer from getting installed if this is exploration!
-		return
+		daraAddTimer(t)
 	}
-	if Record {
-		// Fuck timers for now
+	if Explore || Replay {
+		// The global scheduler fires the timers of exploration and
+		// replay, they are not installed. A recording runtime fires
+		// its own and logs them.
 		return
 	}
 	tb := t.assignBucket()
@@ -199,9 +292,20 @@ func (tb *timersBucket) addtimerLocked(t *timer) {
 	}
 }
 
+// Delete timer t from the heap, and from the timers the global
+// scheduler may fire. Under exploration and replay timers are never
+// installed in the heap, they are stopped if they were still armed.
+func deltimer(t *timer) bool {
+	removed := delheaptimer(t)
+	if DaraInitialised && daraCancelTimer(t) && (Explore || Replay) {
+		removed = true
+	}
+	return removed
+}
+
 // Delete timer t from the heap.
 // Do not need to update the timerproc: if it wakes up early, no big deal.
-func deltimer(t *timer) bool {
+func delheaptimer(t *timer) bool {
 	if t.tb == nil {
 		// t.tb can be nil if the user created a timer
 		// directly, without invoking startTimer e.g
@@ -245,7 +349,7 @@ func timerproc(tb *timersBucket) {
 	for {
 		lock(&tb.lock)
 		tb.sleeping = false
-		now := nanotime()
+		now := daraNanotime()
 		delta := int64(-1)
 		for {
 			if len(tb.t) == 0 {
@@ -257,6 +361,7 @@ func timerproc(tb *timersBucket) {
 			if delta > 0 {
 				break
 			}
+			due := t.when
 			if t.period > 0 {
 				// leave in heap but adjust next time to fire
 				t.when += t.period * (1 + -delta/t.period)
@@ -282,11 +387,17 @@ func timerproc(tb *timersBucket) {
 			if raceenabled {
 				raceacquire(unsafe.Pointer(t))
 			}
-			f(arg, seq)
+			if id, ok := TimerIDs[t]; DaraInitialised && ok {
+				daraRunTimer(id, t, due, f, arg, seq)
+			} else {
+				f(arg, seq)
+			}
 			lock(&tb.lock)
 		}
-		if delta < 0 || faketime > 0 {
+		if delta < 0 || faketime > 0 || daraTimeVirtual {
 			// No timers left - put goroutine to sleep.
+			// Under Dara the timers are woken up when virtual time
+			// moves, see daraWakeTimers.
 			tb.rescheduling = true
 			goparkunlock(&tb.lock, "timer goroutine (idle)", traceEvGoBlock, 1)
 			continue
@@ -426,12 +537,12 @@ func siftdownTimer(t []*timer, i int) {
 
 //go:linkname poll_runtimeNano internal/poll.runtimeNano
 func poll_runtimeNano() int64 {
-	return nanotime()
+	return daraNanotime()
 }
 
 //go:linkname time_runtimeNano time.runtimeNano
 func time_runtimeNano() int64 {
-	return nanotime()
+	return daraNanotime()
 }
 
 // Monotonic times are reported as offsets from startNano.
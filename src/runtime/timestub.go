@@ -16,6 +16,11 @@ func walltime() (sec int64, nsec int32)
 
 //go:linkname time_now time.now
 func time_now() (sec int64, nsec int32, mono int64) {
+	if daraTimeOn {
+		mono = daraNanotime()
+		sec, nsec = daraWalltime(mono)
+		return sec, nsec, mono
+	}
 	sec, nsec = walltime()
 	return sec, nsec, nanotime() - startNano
 }
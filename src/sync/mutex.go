@@ -72,11 +72,14 @@ const (
 // If the lock is already in use, the calling goroutine
 // blocks until the mutex is available.
 func (m *Mutex) Lock() {
+	// DARA Instrumentation
+	runtime.DaraSyscallPoint(dara.MUX_LOCK)
 	// Fast path: grab unlocked mutex.
 	if atomic.CompareAndSwapInt32(&m.state, 0, mutexLocked) {
 		if race.Enabled {
 			race.Acquire(unsafe.Pointer(m))
 		}
+		runtime.DaraAcquire(unsafe.Pointer(m))
         if runtime.Is_dara_profiling_on() {
             runtime.Dara_Debug_Print(func() {
                 print("[Mutex.Lock] : ")
@@ -175,6 +178,7 @@ func (m *Mutex) Lock() {
 	if race.Enabled {
 		race.Acquire(unsafe.Pointer(m))
 	}
+	runtime.DaraAcquire(unsafe.Pointer(m))
 
     if runtime.Is_dara_profiling_on() {
         runtime.Dara_Debug_Print(func() {
@@ -198,6 +202,7 @@ func (m *Mutex) Unlock() {
 		_ = m.state
 		race.Release(unsafe.Pointer(m))
 	}
+	runtime.DaraRelease(unsafe.Pointer(m))
 
 	// Fast path: drop lock bit.
 	new := atomic.AddInt32(&m.state, -mutexLocked)
@@ -72,6 +72,10 @@ func (wg *WaitGroup) Add(delta int) {
 		race.Disable()
 		defer race.Enable()
 	}
+	if delta < 0 {
+		// Synchronize decrements with Wait.
+		runtime.DaraRelease(unsafe.Pointer(wg))
+	}
 	state := atomic.AddUint64(statep, uint64(delta)<<32)
 	v := int32(state >> 32)
 	w := uint32(state)
@@ -135,6 +139,7 @@ func (wg *WaitGroup) Wait() {
 				race.Enable()
 				race.Acquire(unsafe.Pointer(wg))
 			}
+			runtime.DaraAcquire(unsafe.Pointer(wg))
 			return
 		}
 		// Increment waiters count.
@@ -154,6 +159,7 @@ func (wg *WaitGroup) Wait() {
 				race.Enable()
 				race.Acquire(unsafe.Pointer(wg))
 			}
+			runtime.DaraAcquire(unsafe.Pointer(wg))
 			return
 		}
 	}
@@ -71,7 +71,7 @@ func Unsetenv(key string) error {
 	if (runtime.Is_dara_profiling_on()) {
 		runtime.Dara_Debug_Print(func() { println("[UNSETENV] : " + key) })
 		argInfo := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo.String[:], key)
+        argInfo.String = key
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported : dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_UNSETENV, 1, 1, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_UNSETENV, syscallInfo)
@@ -81,7 +81,7 @@ func Unsetenv(key string) error {
 
 func Getenv(key string) (value string, found bool) {
 	argInfo := dara.GeneralType{Type: dara.STRING}
-    copy(argInfo.String[:], key)
+    argInfo.String = key
 	envOnce.Do(copyenv)
 	if len(key) == 0 {
 		return "", false
@@ -109,7 +109,7 @@ func Getenv(key string) (value string, found bool) {
 			if (runtime.Is_dara_profiling_on()) {
 				runtime.Dara_Debug_Print(func() {println("[GETENV] : " + key)})
 				retInfo1 := dara.GeneralType{Type: dara.STRING}
-                copy(retInfo1.String[:], s[i+1:])
+                retInfo1.String = s[i+1:]
 				retInfo2 := dara.GeneralType{Type: dara.BOOL, Bool:false}
 				syscallInfo := dara.GeneralSyscall{dara.DSYS_GETENV, 1, 2, [10]dara.GeneralType{argInfo}, [10]dara.GeneralType{retInfo1, retInfo2}}
 				runtime.Report_Syscall_To_Scheduler(dara.DSYS_GETENV, syscallInfo)
@@ -133,9 +133,9 @@ func Setenv(key, value string) error {
 	if (runtime.Is_dara_profiling_on()) {
 		runtime.Dara_Debug_Print(func() { println("[SETENV] : " + key +  " "  + value) })
 		argInfo1 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo1.String[:], key)
+        argInfo1.String = key
 		argInfo2 := dara.GeneralType{Type: dara.STRING}
-        copy(argInfo2.String[:], value)
+        argInfo2.String = value
 		retInfo := dara.GeneralType{Type: dara.ERROR, Unsupported : dara.UNSUPPORTEDVAL}
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_GETENV, 2, 1, [10]dara.GeneralType{argInfo1, argInfo2}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_SETENV, syscallInfo)
@@ -1051,7 +1051,7 @@ func Now() Time {
 		if runtime.Is_dara_profiling_on() {
             runtime.Dara_Debug_Print(func() { println("[TIME.NOW]") })
 			retInfo := dara.GeneralType{Type : dara.TIME}
-            copy(retInfo.String[:], t.String())
+            retInfo.String = t.String()
 			syscallInfo := dara.GeneralSyscall{dara.DSYS_TIMENOW, 0, 1, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo}}
 			runtime.Report_Syscall_To_Scheduler(dara.DSYS_TIMENOW, syscallInfo)
 		}
@@ -1062,7 +1062,7 @@ func Now() Time {
 	if runtime.Is_dara_profiling_on() {
         runtime.Dara_Debug_Print(func() { println("[TIME.NOW]") })
 		retInfo := dara.GeneralType{Type : dara.TIME}
-        copy(retInfo.String[:], t.String())
+        retInfo.String = t.String()
 		syscallInfo := dara.GeneralSyscall{dara.DSYS_TIMENOW, 0, 1, [10]dara.GeneralType{}, [10]dara.GeneralType{retInfo}}
 		runtime.Report_Syscall_To_Scheduler(dara.DSYS_TIMENOW, syscallInfo)
 	}